@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/backup"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+func runPull(cfg *config.Config, args []string) error {
+	fs := newFlagSet("pull")
+	method := fs.String("method", "", "transfer method: sftp or usb (default: transfer.method from config)")
+	folders := fs.String("folders", strings.Join(backup.DefaultFolders, ","), "comma-separated device folders to back up")
+	dest := fs.String("dest", cfg.BackupRoot(), "backup directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	backend, err := connectBackend(ctx, cfg, *method)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	result, err := backup.Pull(ctx, backend, *dest, splitList(*folders), func(current, total int, name string) {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", current, total, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Snapshot %s\n", result.Snapshot.Path)
+	fmt.Printf("  %d files downloaded (%d bytes), %d unchanged\n", result.Downloaded, result.BytesCopied, result.Linked)
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "  error: %v\n", e)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d files could not be downloaded", len(result.Errors))
+	}
+	return nil
+}
+
+func runRestore(cfg *config.Config, args []string) error {
+	fs := newFlagSet("restore")
+	method := fs.String("method", "", "transfer method: sftp or usb (default: transfer.method from config)")
+	folders := fs.String("folders", strings.Join(backup.DefaultFolders, ","), "comma-separated folders to restore")
+	src := fs.String("dest", cfg.BackupRoot(), "backup directory")
+	list := fs.Bool("list", false, "list available snapshots and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: romwrangler restore [flags] <snapshot|latest>\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		snaps, err := backup.ListSnapshots(*src)
+		if err != nil {
+			return err
+		}
+		for _, s := range snaps {
+			fmt.Printf("%s  %s\n", s.Name, s.Time.Format("2006-01-02 15:04:05"))
+		}
+		return nil
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("restore needs a snapshot name or 'latest'")
+	}
+
+	snap, err := backup.FindSnapshot(*src, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	backend, err := connectBackend(ctx, cfg, *method)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	plan, err := backup.BuildRestorePlan(ctx, backend, snap, splitList(*folders))
+	if err != nil {
+		return err
+	}
+
+	progressCh := make(chan transfer.TransferProgress, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range progressCh {
+			if p.Done {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", p.FileIndex+1, p.TotalFiles, p.Filename)
+			}
+		}
+	}()
+	opts := transfer.ExecuteOptions{Concurrency: cfg.Transfer.Concurrency, Verify: cfg.Transfer.Verify}
+	err = transfer.ExecuteWithOptions(ctx, backend, plan, opts, progressCh)
+	<-done
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d files from snapshot %s\n", len(plan.Items)-plan.SkipCount, snap.Name)
+	return nil
+}
+
+// connectBackend creates and connects the per-file backend for method.
+func connectBackend(ctx context.Context, cfg *config.Config, method string) (transfer.TransferBackend, error) {
+	backend, err := transfer.NewBackend(cfg, method)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	return backend, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+// command is a headless subcommand that runs without the TUI.
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{name: "scan", summary: "Scan source directories and print the inventory as JSON", run: runScan},
+	{name: "sort", summary: "Sort scanned ROMs into ReplayOS folders (supports --dry-run)", run: runSort},
+	{name: "diff", summary: "Compare the library with the device per system folder", run: runDiff},
+	{name: "verify", summary: "Check cue/gdi disc sets against Redump DATs: full, partial or miss", run: runVerify},
+	{name: "transfer", summary: "Send library folders to the device (--rebuild-manifest, --dry-run)", run: runTransfer},
+	{name: "overrides", summary: "List, copy and find orphaned per-game/system overrides (--pull, --copy)", run: runOverrides},
+	{name: "provision", summary: "Set up a fresh ReplayOS SD card or USB drive: folders, BIOS, ROMs, replay.cfg", run: runProvision},
+	{name: "pull", summary: "Back up saves, captures and config from the device into a snapshot", run: runPull},
+	{name: "restore", summary: "Push a backup snapshot back to the device (--list to show snapshots)", run: runRestore},
+}
+
+// runCommand dispatches a subcommand and returns the process exit code.
+func runCommand(name string, args []string, configPath string) int {
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			return 1
+		}
+		if err := c.run(cfg, args); err != nil {
+			if err == flag.ErrHelp {
+				return 0
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	usage()
+	return 2
+}
+
+// newFlagSet creates a flag set for a subcommand that reports parse errors
+// instead of exiting, so runCommand controls the exit code.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("romwrangler "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// writeJSON writes v as indented JSON followed by a newline.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// errorStrings converts errors to strings for JSON output; encoding/json
+// renders error values as empty objects.
+func errorStrings(errs []error) []string {
+	out := make([]string, 0, len(errs))
+	for _, err := range errs {
+		out = append(out, err.Error())
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+// diffReport is the JSON form of a transfer.InventoryDiff.
+type diffReport struct {
+	Hashed  bool               `json:"hashed"`
+	Systems []systemDiffReport `json:"systems"`
+}
+
+type systemDiffReport struct {
+	Folder     string          `json:"folder"`
+	Same       int             `json:"same"`
+	OnlyLocal  []diffEntryJSON `json:"only_local"`
+	OnlyDevice []diffEntryJSON `json:"only_device"`
+	Different  []diffEntryJSON `json:"different"`
+}
+
+type diffEntryJSON struct {
+	Path       string `json:"path"`
+	LocalPath  string `json:"local_path,omitempty"`
+	LocalSize  int64  `json:"local_size,omitempty"`
+	DeviceSize int64  `json:"device_size,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+func runDiff(cfg *config.Config, args []string) error {
+	fs := newFlagSet("diff")
+	method := fs.String("method", "", "transfer method: sftp or usb (default: transfer.method from config)")
+	hash := fs.Bool("hash", false, "compare files of equal size by SHA1 (slow over the network)")
+	format := fs.String("format", "table", "output format: table or json")
+	all := fs.Bool("all", false, "table: also list systems with no differences")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want table or json)", *format)
+	}
+
+	dirs := cfg.ROMDirs()
+	if len(dirs) == 0 {
+		return fmt.Errorf("no source_dirs configured")
+	}
+	result := organizer.Scan(dirs, cfg.Aliases)
+	for _, err := range result.Errors {
+		fmt.Fprintf(os.Stderr, "scan: %v\n", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	backend, err := connectBackend(ctx, cfg, *method)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	opts := transfer.DiffOptions{Hash: *hash}
+	if *hash {
+		opts.ProgressFn = func(current, total int, filename string) {
+			fmt.Fprintf(os.Stderr, "[%d/%d] hashing %s\n", current, total, filename)
+		}
+	}
+	diff, err := transfer.DiffInventory(ctx, backend, result, dirs, opts)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		return writeJSON(os.Stdout, newDiffReport(diff))
+	}
+	writeDiffTable(os.Stdout, diff, *all)
+	return nil
+}
+
+func newDiffReport(diff *transfer.InventoryDiff) diffReport {
+	report := diffReport{Hashed: diff.Hashed, Systems: make([]systemDiffReport, 0, len(diff.Systems))}
+	for _, s := range diff.Systems {
+		report.Systems = append(report.Systems, systemDiffReport{
+			Folder:     s.Folder,
+			Same:       s.Same,
+			OnlyLocal:  diffEntries(s.OnlyLocal),
+			OnlyDevice: diffEntries(s.OnlyDevice),
+			Different:  diffEntries(s.Different),
+		})
+	}
+	return report
+}
+
+func diffEntries(entries []transfer.InventoryEntry) []diffEntryJSON {
+	out := make([]diffEntryJSON, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, diffEntryJSON{
+			Path:       e.Path,
+			LocalPath:  e.LocalPath,
+			LocalSize:  e.LocalSize,
+			DeviceSize: e.RemoteSize,
+			Reason:     e.Reason,
+		})
+	}
+	return out
+}
+
+func writeDiffTable(w io.Writer, diff *transfer.InventoryDiff, all bool) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "SYSTEM\tSAME\tONLY LOCAL\tONLY DEVICE\tDIFFERENT\n")
+	var changes int
+	for _, s := range diff.Systems {
+		changes += s.Changes()
+		if s.Changes() == 0 && !all {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", s.Folder, s.Same, len(s.OnlyLocal), len(s.OnlyDevice), len(s.Different))
+	}
+	tw.Flush()
+
+	if changes == 0 {
+		fmt.Fprintf(w, "\nLibrary and device are in sync.\n")
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "STATUS\tSYSTEM\tPATH\tLOCAL\tDEVICE\n")
+	for _, s := range diff.Systems {
+		for _, e := range s.OnlyLocal {
+			fmt.Fprintf(tw, "only-local\t%s\t%s\t%d\t\n", s.Folder, e.Path, e.LocalSize)
+		}
+		for _, e := range s.OnlyDevice {
+			fmt.Fprintf(tw, "only-device\t%s\t%s\t\t%d\n", s.Folder, e.Path, e.RemoteSize)
+		}
+		for _, e := range s.Different {
+			fmt.Fprintf(tw, "differs-%s\t%s\t%s\t%d\t%d\n", e.Reason, s.Folder, e.Path, e.LocalSize, e.RemoteSize)
+		}
+	}
+	tw.Flush()
+
+	if !diff.Hashed {
+		fmt.Fprintf(w, "\nFiles of equal size were not hashed; use --hash to compare contents.\n")
+	}
+}
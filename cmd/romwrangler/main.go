@@ -13,8 +13,15 @@ import (
 
 func main() {
 	configPath := flag.String("config", "", "path to config file")
+	flag.Usage = usage
 	flag.Parse()
 
+	// Any positional argument selects a headless subcommand; with none we
+	// launch the TUI as before.
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Arg(0), flag.Args()[1:], *configPath))
+	}
+
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
@@ -45,6 +52,22 @@ func main() {
 			return screens.NewBIOSSetupScreen(cfg, width, height)
 		case tui.ScreenM3U:
 			return screens.NewM3UScreen(cfg, width, height)
+		case tui.ScreenInventory:
+			return screens.NewInventoryScreen(cfg, width, height)
+		case tui.ScreenBackup:
+			return screens.NewBackupScreen(cfg, width, height)
+		case tui.ScreenFanout:
+			return screens.NewFanoutScreen(cfg, width, height)
+		case tui.ScreenDeviceStatus:
+			return screens.NewDeviceStatusScreen(cfg, width, height)
+		case tui.ScreenReplayCfg:
+			return screens.NewReplayCfgScreen(cfg, width, height)
+		case tui.ScreenLists:
+			return screens.NewListsScreen(cfg, width, height)
+		case tui.ScreenOverrides:
+			return screens.NewOverridesScreen(cfg, width, height)
+		case tui.ScreenProvision:
+			return screens.NewProvisionScreen(cfg, width, height)
 		default:
 			return screens.NewHomeScreen(cfg, width, height)
 		}
@@ -57,3 +80,12 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: romwrangler [--config path] [command] [flags]\n\n")
+	fmt.Fprintf(os.Stderr, "With no command, the interactive TUI is started.\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'romwrangler <command> --help' for command flags.\n")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+	"github.com/kurlmarx/romwrangler/internal/overrides"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+// overrideJSON is the JSON form of an overrides.Match.
+type overrideJSON struct {
+	Path    string   `json:"path"`
+	Kind    string   `json:"kind"`
+	Scope   string   `json:"scope"`
+	Display string   `json:"display"`
+	Name    string   `json:"name"`
+	Games   []string `json:"games,omitempty"`
+	Orphan  bool     `json:"orphan"`
+}
+
+func runOverrides(cfg *config.Config, args []string) error {
+	fs := newFlagSet("overrides")
+	method := fs.String("method", "", "transfer method for --pull: sftp or usb (default: transfer.method from config)")
+	pull := fs.Bool("pull", false, "first copy the device's overrides into the library's config folder")
+	orphans := fs.Bool("orphans", false, "list only overrides whose game or system is gone")
+	from := fs.String("copy", "", "copy this game override (path under config/) to the games matched by --to")
+	to := fs.String("to", "", "comma-separated globs of games under roms/, e.g. 'arcade_fbneo/*,*Raiden*'")
+	overwrite := fs.Bool("overwrite", false, "with --copy: replace overrides the target games already have")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want table or json)", *format)
+	}
+	if len(cfg.SourceDirs) == 0 {
+		return fmt.Errorf("no source_dirs configured")
+	}
+	configDir := filepath.Join(cfg.SourceDirs[0], overrides.ConfigFolder)
+
+	if *pull {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		backend, err := connectBackend(ctx, cfg, *method)
+		if err != nil {
+			return err
+		}
+		result, err := overrides.Pull(ctx, backend, configDir, nil)
+		backend.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Pulled %d overrides, %d unchanged\n", result.Downloaded, result.Unchanged)
+		for _, e := range result.Errors {
+			fmt.Fprintf(os.Stderr, "  error: %v\n", e)
+		}
+	}
+
+	list, err := overrides.List(configDir)
+	if err != nil {
+		return err
+	}
+	romDir := cfg.ROMDirs()[0]
+	scan := organizer.Scan([]string{romDir}, cfg.Aliases)
+	lib := overrides.NewLibrary(transfer.LibraryGames(scan, romDir))
+
+	if *from != "" {
+		return copyOverride(configDir, list, lib, *from, *to, *overwrite)
+	}
+
+	matches := overrides.Map(list, lib)
+	orphaned := overrides.Orphans(matches)
+	if *orphans {
+		matches = orphaned
+	}
+	if *format == "json" {
+		out := make([]overrideJSON, 0, len(matches))
+		for _, m := range matches {
+			out = append(out, overrideJSON{
+				Path:    m.Path,
+				Kind:    string(m.Kind),
+				Scope:   string(m.Scope),
+				Display: string(m.Display),
+				Name:    m.Name,
+				Games:   m.Games,
+				Orphan:  m.Orphan,
+			})
+		}
+		return writeJSON(os.Stdout, out)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "KIND\tSCOPE\tDISPLAY\tNAME\tMATCH\n")
+	for _, m := range matches {
+		match := strings.Join(m.Games, ", ")
+		switch {
+		case m.Orphan:
+			match = "ORPHAN"
+		case m.Scope == overrides.ScopeSystem:
+			match = "system"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.Kind, m.Scope, m.Display, m.Name, match)
+	}
+	tw.Flush()
+	fmt.Printf("\n%d overrides in %s, %d orphaned\n", len(list), configDir, len(orphaned))
+	return nil
+}
+
+// copyOverride copies the override at from to the games matching the globs
+// in to.
+func copyOverride(configDir string, list []overrides.Override, lib *overrides.Library, from, to string, overwrite bool) error {
+	from = strings.TrimPrefix(filepath.ToSlash(from), overrides.ConfigFolder+"/")
+	var src *overrides.Override
+	for i := range list {
+		if list[i].Path == from {
+			src = &list[i]
+			break
+		}
+	}
+	if src == nil {
+		return fmt.Errorf("no override %s in %s", from, configDir)
+	}
+	if to == "" {
+		return fmt.Errorf("--copy needs --to")
+	}
+	games, err := lib.Select(splitList(to))
+	if err != nil {
+		return err
+	}
+	if len(games) == 0 {
+		return fmt.Errorf("no games match %s", to)
+	}
+
+	result, err := overrides.Copy(configDir, *src, games, overwrite)
+	if err != nil {
+		return err
+	}
+	for _, p := range result.Written {
+		fmt.Printf("  wrote   %s\n", p)
+	}
+	for _, p := range result.Skipped {
+		fmt.Printf("  skipped %s (exists, use --overwrite)\n", p)
+	}
+	fmt.Printf("%d overrides written; send them with: romwrangler transfer --folders config\n", len(result.Written))
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/provision"
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+func runProvision(cfg *config.Config, args []string) error {
+	fs := newFlagSet("provision")
+	systemsFlag := fs.String("systems", "", "comma-separated ReplayOS system folders to copy (default: the whole library)")
+	preset := fs.String("preset", "", "replay.cfg preset to apply, e.g. 'LCD 1080p' (built-in or exported)")
+	force := fs.Bool("force", false, "provision a drive that holds other files")
+	dryRun := fs.Bool("dry-run", false, "check the drive and print the plan without writing anything")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: romwrangler provision [flags] <mount path>\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("provision needs the mount path of the SD card or USB drive")
+	}
+	if len(cfg.SourceDirs) == 0 {
+		return fmt.Errorf("no source_dirs configured")
+	}
+
+	opts := provision.Options{
+		Mount:       fs.Arg(0),
+		LibraryRoot: cfg.SourceDirs[0],
+		Systems:     splitList(*systemsFlag),
+		Transfer:    cfg.Transfer,
+		Force:       *force,
+	}
+	if *preset != "" {
+		p, err := findPreset(*preset)
+		if err != nil {
+			return err
+		}
+		opts.Preset = &p
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	p, err := provision.Prepare(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer p.Plan.Cleanup()
+
+	plan := p.Plan
+	fmt.Printf("Drive %s is %s\n", opts.Mount, p.State)
+	fmt.Printf("%d files to copy (%d bytes), %d already there\n", len(plan.Items)-plan.SkipCount, plan.TotalSize, plan.SkipCount)
+	if p.BIOS > 0 {
+		fmt.Printf("%d BIOS files found among the ROMs go to bios/\n", p.BIOS)
+	}
+	if len(plan.Omitted) > 0 {
+		fmt.Printf("%d files left out (%d bytes)\n", len(plan.Omitted), plan.OmittedSize)
+	}
+	if *dryRun {
+		for _, item := range plan.Items {
+			if !item.Skip {
+				fmt.Printf("  %s\n", item.RemotePath)
+			}
+		}
+		return nil
+	}
+
+	progressCh := make(chan transfer.TransferProgress, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for pr := range progressCh {
+			if pr.Done {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", pr.FileIndex+1, pr.TotalFiles, pr.Filename)
+			}
+		}
+	}()
+	report, err := p.Run(ctx, progressCh)
+	<-done
+	if err != nil {
+		return err
+	}
+
+	writeProvisionReport(os.Stdout, report)
+	if !report.Complete() {
+		return fmt.Errorf("provisioning incomplete")
+	}
+	return nil
+}
+
+// findPreset looks up a built-in or exported replay.cfg preset by name,
+// ignoring case.
+func findPreset(name string) (replaycfg.Preset, error) {
+	presets, err := replaycfg.LoadPresets(filepath.Join(filepath.Dir(config.DefaultPath()), "presets"))
+	if err != nil {
+		return replaycfg.Preset{}, err
+	}
+	var names []string
+	for _, p := range presets {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return replaycfg.Preset{}, fmt.Errorf("no preset %q (have: %s)", name, strings.Join(names, ", "))
+}
+
+func writeProvisionReport(w io.Writer, r *provision.Report) {
+	fmt.Fprintf(w, "\nFolders:    %d created", r.FoldersCreated)
+	if len(r.Missing) > 0 {
+		fmt.Fprintf(w, ", %d missing: %s", len(r.Missing), strings.Join(r.Missing, ", "))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Files:      %d copied (%d bytes)", r.FilesSent, r.BytesSent)
+	if len(r.Omitted) > 0 {
+		fmt.Fprintf(w, ", %d left out", len(r.Omitted))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "replay.cfg: %s", r.ReplayCfg)
+	if len(r.ReplayCfgErrs) > 0 {
+		fmt.Fprintf(w, ", %d invalid values (first: %v)", len(r.ReplayCfgErrs), r.ReplayCfgErrs[0])
+	}
+	fmt.Fprintln(w)
+
+	if st := r.Status; st != nil {
+		if st.Disk != nil {
+			fmt.Fprintf(w, "Storage:    %d of %d bytes free\n", st.Disk.Free, st.Disk.Total)
+		}
+		fmt.Fprintf(w, "BIOS:       %d of %d known files\n", st.BIOSPresent, st.BIOSTotal)
+		for _, g := range st.BIOS {
+			if len(g.Missing) > 0 {
+				fmt.Fprintf(w, "  %-24s missing %s\n", path.Join("bios", g.Folder)+"/", strings.Join(g.Missing, ", "))
+			}
+		}
+		fmt.Fprintf(w, "ROMs:       %d systems, %d bytes\n", len(st.Systems), st.ROMBytes)
+		for _, s := range st.Systems {
+			fmt.Fprintf(w, "  %-24s %5d files %12d bytes\n", s.Folder, s.Files, s.Bytes)
+		}
+	}
+	for _, err := range r.Errors {
+		fmt.Fprintf(w, "error: %v\n", err)
+	}
+	if r.Complete() {
+		fmt.Fprintf(w, "\nDrive is ready for ReplayOS.\n")
+	}
+}
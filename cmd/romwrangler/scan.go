@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+)
+
+// scanReport is the JSON form of an organizer.ScanResult.
+type scanReport struct {
+	SourceDirs  []string            `json:"source_dirs"`
+	Systems     map[string][]string `json:"systems"`
+	Convertible []string            `json:"convertible"`
+	Unresolved  []string            `json:"unresolved"`
+	Unsupported []string            `json:"unsupported"`
+	Errors      []string            `json:"errors"`
+}
+
+// scanRecord is a single NDJSON line. Kind is one of "file", "convertible",
+// "unresolved", "unsupported" or "error".
+type scanRecord struct {
+	Kind   string `json:"kind"`
+	Path   string `json:"path,omitempty"`
+	System string `json:"system,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func runScan(cfg *config.Config, args []string) error {
+	fs := newFlagSet("scan")
+	format := fs.String("format", "json", "output format: json or ndjson")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "ndjson" {
+		return fmt.Errorf("unknown format %q (want json or ndjson)", *format)
+	}
+
+	dirs := cfg.ROMDirs()
+	if len(dirs) == 0 {
+		return fmt.Errorf("no source_dirs configured")
+	}
+
+	result := organizer.Scan(dirs, cfg.Aliases)
+
+	if *format == "ndjson" {
+		return writeScanNDJSON(os.Stdout, result)
+	}
+	return writeJSON(os.Stdout, newScanReport(dirs, result))
+}
+
+func newScanReport(dirs []string, result *organizer.ScanResult) scanReport {
+	report := scanReport{
+		SourceDirs:  dirs,
+		Systems:     make(map[string][]string),
+		Convertible: scannedPaths(result.Convertible),
+		Unresolved:  nonNil(result.Unresolved),
+		Unsupported: nonNil(result.Unsupported),
+		Errors:      errorStrings(result.Errors),
+	}
+	for sys, files := range result.BySystem {
+		report.Systems[string(sys)] = scannedPaths(files)
+	}
+	return report
+}
+
+// writeScanNDJSON emits one JSON object per line so large libraries can be
+// streamed through line-oriented tools.
+func writeScanNDJSON(w io.Writer, result *organizer.ScanResult) error {
+	enc := json.NewEncoder(w)
+	for _, f := range result.Files {
+		if err := enc.Encode(scanRecord{Kind: "file", Path: f.Path, System: string(f.System)}); err != nil {
+			return err
+		}
+	}
+	for _, f := range result.Convertible {
+		if err := enc.Encode(scanRecord{Kind: "convertible", Path: f.Path, System: string(f.System)}); err != nil {
+			return err
+		}
+	}
+	for _, p := range result.Unresolved {
+		if err := enc.Encode(scanRecord{Kind: "unresolved", Path: p}); err != nil {
+			return err
+		}
+	}
+	for _, p := range result.Unsupported {
+		if err := enc.Encode(scanRecord{Kind: "unsupported", Path: p}); err != nil {
+			return err
+		}
+	}
+	for _, e := range result.Errors {
+		if err := enc.Encode(scanRecord{Kind: "error", Error: e.Error()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scannedPaths(files []organizer.ScannedFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// nonNil returns an empty slice for nil so JSON output uses [] not null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
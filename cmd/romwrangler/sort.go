@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/devices"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+)
+
+// sortPlanReport is the JSON form of an organizer.SortPlan.
+type sortPlanReport struct {
+	OutputDir    string            `json:"output_dir"`
+	Mode         string            `json:"mode"`
+	FileActions  []fileActionJSON  `json:"file_actions"`
+	M3UActions   []m3uActionJSON   `json:"m3u_actions"`
+	DirsToCreate []string          `json:"dirs_to_create"`
+	Result       *planResultReport `json:"result,omitempty"`
+}
+
+type fileActionJSON struct {
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+	System string `json:"system"`
+}
+
+type m3uActionJSON struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	System  string `json:"system"`
+}
+
+// planResultReport is the JSON form of an organizer.PlanResult.
+type planResultReport struct {
+	FilesCopied int      `json:"files_copied"`
+	FilesMoved  int      `json:"files_moved"`
+	M3UsWritten int      `json:"m3us_written"`
+	DirsCreated int      `json:"dirs_created"`
+	Errors      []string `json:"errors"`
+}
+
+func runSort(cfg *config.Config, args []string) error {
+	fs := newFlagSet("sort")
+	output := fs.String("output", "", "output directory (default: first source ROM directory)")
+	move := fs.Bool("move", false, "move files into place (default)")
+	copyFiles := fs.Bool("copy", false, "copy files, leaving the originals")
+	cleanNames := fs.Bool("clean-names", false, "strip dump tags from filenames")
+	dryRun := fs.Bool("dry-run", false, "print the plan without touching any files")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *move && *copyFiles {
+		return fmt.Errorf("--move and --copy are mutually exclusive")
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want table or json)", *format)
+	}
+
+	dirs := cfg.ROMDirs()
+	if len(dirs) == 0 {
+		return fmt.Errorf("no source_dirs configured")
+	}
+	outputDir := *output
+	if outputDir == "" {
+		outputDir = dirs[0]
+	}
+
+	result := organizer.Scan(dirs, cfg.Aliases)
+	for _, err := range result.Errors {
+		fmt.Fprintf(os.Stderr, "scan: %v\n", err)
+	}
+
+	plan := organizer.BuildSortPlan(result, devices.NewReplayOS(), outputDir, *cleanNames)
+	sortPlanForOutput(plan)
+
+	doMove := !*copyFiles
+	report := newSortPlanReport(plan, outputDir, doMove)
+
+	if !*dryRun {
+		progress := func(current, total int, filename string) {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", current, total, filename)
+		}
+		pr, err := organizer.ExecutePlan(plan, doMove, progress)
+		if err != nil {
+			return err
+		}
+		report.Result = &planResultReport{
+			FilesCopied: pr.FilesCopied,
+			FilesMoved:  pr.FilesMoved,
+			M3UsWritten: pr.M3UsWritten,
+			DirsCreated: pr.DirsCreated,
+			Errors:      errorStrings(pr.Errors),
+		}
+	}
+
+	if *format == "json" {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		writeSortPlanTable(os.Stdout, report)
+	}
+
+	if report.Result != nil && len(report.Result.Errors) > 0 {
+		return fmt.Errorf("%d errors while sorting", len(report.Result.Errors))
+	}
+	return nil
+}
+
+// sortPlanForOutput orders the plan by destination so that output is stable
+// between runs and diffs cleanly.
+func sortPlanForOutput(plan *organizer.SortPlan) {
+	sort.Slice(plan.Files, func(i, j int) bool {
+		return plan.Files[i].DestPath < plan.Files[j].DestPath
+	})
+	sort.Slice(plan.M3Us, func(i, j int) bool {
+		return plan.M3Us[i].Path < plan.M3Us[j].Path
+	})
+	sort.Strings(plan.DirsToCreate)
+}
+
+func newSortPlanReport(plan *organizer.SortPlan, outputDir string, move bool) sortPlanReport {
+	report := sortPlanReport{
+		OutputDir:    outputDir,
+		Mode:         "copy",
+		FileActions:  make([]fileActionJSON, 0, len(plan.Files)),
+		M3UActions:   make([]m3uActionJSON, 0, len(plan.M3Us)),
+		DirsToCreate: nonNil(plan.DirsToCreate),
+	}
+	if move {
+		report.Mode = "move"
+	}
+	for _, a := range plan.Files {
+		report.FileActions = append(report.FileActions, fileActionJSON{
+			Source: a.SourcePath,
+			Dest:   a.DestPath,
+			System: string(a.System),
+		})
+	}
+	for _, m := range plan.M3Us {
+		report.M3UActions = append(report.M3UActions, m3uActionJSON{
+			Path:    m.Path,
+			Content: m.Content,
+			System:  string(m.System),
+		})
+	}
+	return report
+}
+
+func writeSortPlanTable(w io.Writer, report sortPlanReport) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ACTION\tSYSTEM\tSOURCE\tDEST\n")
+	for _, a := range report.FileActions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", report.Mode, a.System, a.Source, a.Dest)
+	}
+	for _, m := range report.M3UActions {
+		fmt.Fprintf(tw, "m3u\t%s\t\t%s\n", m.System, m.Path)
+	}
+	for _, d := range report.DirsToCreate {
+		fmt.Fprintf(tw, "mkdir\t\t\t%s\n", d)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d files to %s, %d M3U playlists, %d directories\n",
+		len(report.FileActions), report.Mode, len(report.M3UActions), len(report.DirsToCreate))
+
+	if r := report.Result; r != nil {
+		fmt.Fprintf(w, "Moved: %d  Copied: %d  M3Us written: %d  Dirs created: %d\n",
+			r.FilesMoved, r.FilesCopied, r.M3UsWritten, r.DirsCreated)
+		for _, e := range r.Errors {
+			fmt.Fprintf(w, "error: %s\n", e)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+func runTransfer(cfg *config.Config, args []string) error {
+	fs := newFlagSet("transfer")
+	method := fs.String("method", "", "transfer method: sftp or usb (default: transfer.method from config)")
+	folders := fs.String("folders", "roms", "comma-separated library folders to send")
+	devices := fs.String("device", "", "comma-separated names of configured devices to send to (default: device)")
+	allDevices := fs.Bool("all-devices", false, "send to every configured device at once")
+	rebuild := fs.Bool("rebuild-manifest", false, "ignore the device manifest and rebuild it from a device listing")
+	dryRun := fs.Bool("dry-run", false, "print the plan without sending anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(cfg.SourceDirs) == 0 {
+		return fmt.Errorf("no source directories configured")
+	}
+
+	targets := []config.DeviceConfig{cfg.Device}
+	switch {
+	case *allDevices:
+		targets = cfg.AllDevices()
+	case *devices != "":
+		targets = nil
+		for _, name := range splitList(*devices) {
+			d, err := cfg.FindDevice(name)
+			if err != nil {
+				return err
+			}
+			targets = append(targets, d)
+		}
+	}
+	multi := len(targets) > 1
+	if multi && *method == "usb" {
+		return fmt.Errorf("USB transfers go to one mount; use sftp for several devices")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// One throttle for all devices: the bandwidth limit is for the network.
+	throttle, err := transfer.ThrottleFromConfig(cfg.Transfer)
+	if err != nil {
+		return err
+	}
+	// Outside the transfer window, wait before connecting; uploads that
+	// run into the end of the window pause until it opens again.
+	if throttle != nil && !*dryRun {
+		throttle.OnPause = func(until time.Time) {
+			fmt.Fprintf(os.Stderr, "Outside the transfer window %s, paused until %s\n", throttle.Window, until.Format("2006-01-02 15:04"))
+		}
+		if err := throttle.WaitWindow(ctx); err != nil {
+			return err
+		}
+	}
+
+	var jobs []transfer.DeviceTransfer
+	var kiosk []*bool // per job, from the device's lists
+	var failed []transfer.DeviceResult
+	defer func() {
+		for _, j := range jobs {
+			j.Plan.Cleanup()
+			j.Backend.Close()
+		}
+	}()
+	for _, dev := range targets {
+		job, err := planDevice(ctx, cfg, dev, *method, throttle, splitList(*folders), *rebuild)
+		if err != nil {
+			if !multi {
+				return err
+			}
+			failed = append(failed, transfer.DeviceResult{Device: dev.Label(), Err: err})
+			continue
+		}
+		jobs = append(jobs, job)
+		kiosk = append(kiosk, dev.Lists.Kiosk)
+
+		plan := job.Plan
+		prefix := ""
+		if multi {
+			prefix = job.Device + ": "
+		}
+		fmt.Printf("%s%d files to send (%d bytes), %d already on the device\n", prefix, len(plan.Items)-plan.SkipCount, plan.TotalSize, plan.SkipCount)
+		if len(plan.Omitted) > 0 {
+			fmt.Printf("%s%d files left out (%d bytes)\n", prefix, len(plan.Omitted), plan.OmittedSize)
+		}
+		if len(plan.Replaced) > 0 {
+			fmt.Printf("%s%d files removed from roms/%s and roms/%s\n", prefix, len(plan.Replaced), transfer.FavoritesFolder, transfer.AutostartFolder)
+		}
+		if *dryRun {
+			for _, item := range plan.Items {
+				if !item.Skip {
+					fmt.Printf("  %s\n", item.RemotePath)
+				}
+			}
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+
+	progressCh := make(chan transfer.DeviceProgress, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range progressCh {
+			if !p.Done {
+				continue
+			}
+			if multi {
+				fmt.Fprintf(os.Stderr, "%s [%d/%d] %s\n", p.Device, p.FileIndex+1, p.TotalFiles, p.Filename)
+			} else {
+				fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", p.FileIndex+1, p.TotalFiles, p.Filename)
+			}
+		}
+	}()
+	opts := transfer.ExecuteOptions{
+		Concurrency: cfg.Transfer.Concurrency,
+		Verify:      cfg.Transfer.Verify,
+	}
+	results := transfer.ExecuteDevices(ctx, jobs, opts, progressCh)
+	<-done
+
+	for i, j := range jobs {
+		if kiosk[i] == nil || results[i].Err != nil {
+			continue
+		}
+		if _, err := replaycfg.SetKiosk(ctx, j.Backend, *kiosk[i]); err != nil {
+			results[i].Err = fmt.Errorf("kiosk mode: %w", err)
+		}
+	}
+
+	results = append(results, failed...)
+	if !multi {
+		if err := results[0].Err; err != nil {
+			return err
+		}
+		fmt.Printf("Sent %d files\n", results[0].Files)
+		return nil
+	}
+
+	errs := 0
+	for _, r := range results {
+		if r.Err != nil {
+			errs++
+			fmt.Printf("  %-16s FAILED: %v\n", r.Device, r.Err)
+			continue
+		}
+		fmt.Printf("  %-16s %d files (%d bytes) in %s\n", r.Device, r.Files, r.Bytes, r.Duration.Round(time.Second))
+	}
+	if errs > 0 {
+		return fmt.Errorf("%d of %d devices failed", errs, len(results))
+	}
+	return nil
+}
+
+// planDevice connects to dev and builds its transfer plan.
+func planDevice(ctx context.Context, cfg *config.Config, dev config.DeviceConfig, method string, throttle *transfer.Throttle, folders []string, rebuild bool) (transfer.DeviceTransfer, error) {
+	backend, err := transfer.NewDeviceBackend(cfg, dev, method, throttle)
+	if err != nil {
+		return transfer.DeviceTransfer{}, err
+	}
+	if err := backend.Connect(ctx); err != nil {
+		return transfer.DeviceTransfer{}, fmt.Errorf("connect: %w", err)
+	}
+	sp, err := transfer.PlanSync(ctx, backend, transfer.SyncOptions{
+		LocalRoot:       cfg.SourceDirs[0],
+		OtherRoots:      cfg.SourceDirs[1:],
+		Folders:         folders,
+		Transfer:        cfg.Transfer,
+		Systems:         dev.Systems,
+		RebuildManifest: rebuild,
+		Lists:           dev.Lists,
+	})
+	if err != nil {
+		backend.Close()
+		return transfer.DeviceTransfer{}, err
+	}
+	return transfer.DeviceTransfer{Device: dev.Label(), Backend: backend, Plan: sp.Plan, Manifests: sp.Manifests}, nil
+}
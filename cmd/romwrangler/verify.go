@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/scraper"
+)
+
+// discReport is the JSON form of a scraper.DiscMatch.
+type discReport struct {
+	Sheet   string      `json:"sheet"`
+	Result  string      `json:"result"`
+	DAT     string      `json:"dat,omitempty"`
+	Game    string      `json:"game,omitempty"`
+	Tracks  []trackJSON `json:"tracks"`
+	Missing []string    `json:"missing,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type trackJSON struct {
+	Path    string `json:"path"`
+	SHA1    string `json:"sha1,omitempty"`
+	ROMName string `json:"rom_name,omitempty"`
+	Matched bool   `json:"matched"`
+	Absent  bool   `json:"absent,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+func runVerify(cfg *config.Config, args []string) error {
+	flags := newFlagSet("verify")
+	format := flags.String("format", "table", "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: romwrangler verify [flags] [cue, gdi or folder ...]\n\n")
+		fmt.Fprintf(os.Stderr, "Checks disc sets against the DATs in scraping.dat_dirs (default: every set in the library).\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want table or json)", *format)
+	}
+
+	indices, errs := scraper.LoadDATDirs(cfg.Scraping.DATDirs)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "dat: %v\n", err)
+	}
+	if len(indices) == 0 {
+		return fmt.Errorf("no DATs loaded; set scraping.dat_dirs to folders with Redump DATs")
+	}
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		roots = cfg.ROMDirs()
+	}
+	sheets, err := findDiscSheets(roots)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	id := scraper.NewIdentifier(indices, nil, nil)
+	reports := make([]discReport, 0, len(sheets))
+	for i, sheet := range sheets {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(sheets), filepath.Base(sheet))
+		m, err := id.MatchDisc(ctx, sheet)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			reports = append(reports, discReport{Sheet: sheet, Result: "error", Tracks: []trackJSON{}, Error: err.Error()})
+			continue
+		}
+		reports = append(reports, newDiscReport(sheet, m))
+	}
+
+	if *format == "json" {
+		return writeJSON(os.Stdout, reports)
+	}
+	writeVerifyTable(os.Stdout, reports)
+	return nil
+}
+
+// findDiscSheets returns the .cue and .gdi files among paths, searching
+// folders recursively.
+func findDiscSheets(paths []string) ([]string, error) {
+	var out []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".cue", ".gdi":
+				out = append(out, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func newDiscReport(sheet string, m *scraper.DiscMatch) discReport {
+	r := discReport{
+		Sheet:   sheet,
+		Result:  m.Result.String(),
+		DAT:     m.DAT,
+		Game:    m.Game,
+		Tracks:  make([]trackJSON, 0, len(m.Tracks)),
+		Missing: m.Missing,
+	}
+	for _, t := range m.Tracks {
+		r.Tracks = append(r.Tracks, trackJSON{
+			Path:    t.Path,
+			SHA1:    t.Hashes.SHA1,
+			ROMName: t.ROMName,
+			Matched: t.Matched,
+			Absent:  t.Absent,
+			Skipped: t.Skipped,
+		})
+	}
+	return r
+}
+
+func writeVerifyTable(w io.Writer, reports []discReport) {
+	counts := make(map[string]int)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "RESULT\tSHEET\tGAME\n")
+	for _, r := range reports {
+		counts[r.Result]++
+		game := r.Game
+		if r.Error != "" {
+			game = r.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Result, filepath.Base(r.Sheet), game)
+		if r.Result != "partial" {
+			continue
+		}
+		for _, t := range r.Tracks {
+			switch {
+			case t.Absent:
+				fmt.Fprintf(tw, "\t  absent: %s\t\n", filepath.Base(t.Path))
+			case !t.Matched && !t.Skipped && t.ROMName != "":
+				fmt.Fprintf(tw, "\t  differs: %s\t%s\n", filepath.Base(t.Path), t.ROMName)
+			case !t.Matched && !t.Skipped:
+				fmt.Fprintf(tw, "\t  not in DAT: %s\t\n", filepath.Base(t.Path))
+			}
+		}
+		for _, name := range r.Missing {
+			fmt.Fprintf(tw, "\t  missing: %s\t\n", name)
+		}
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d full, %d partial, %d miss", counts["full"], counts["partial"], counts["miss"])
+	if counts["error"] > 0 {
+		fmt.Fprintf(w, ", %d errors", counts["error"])
+	}
+	fmt.Fprintln(w)
+}
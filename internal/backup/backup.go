@@ -0,0 +1,228 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+// DefaultFolders are the device folders captured by a backup: save data,
+// screenshots and per-game/system configuration.
+var DefaultFolders = []string{"saves", "captures", "config"}
+
+// snapshotLayout is the time format used for snapshot directory names.
+// It sorts lexically in chronological order.
+const snapshotLayout = "20060102-150405"
+
+// inProgressPrefix marks a snapshot that is still being written. It is
+// renamed once the pull finishes so partial snapshots are never used as a
+// base for deduplication or offered for restore.
+const inProgressPrefix = ".inprogress-"
+
+// Snapshot is a completed backup under the backup root.
+type Snapshot struct {
+	Name string // directory name, e.g. "20260116-193000"
+	Path string
+	Time time.Time
+}
+
+// PullResult summarizes a pull.
+type PullResult struct {
+	Snapshot    Snapshot
+	Downloaded  int   // files fetched from the device
+	Linked      int   // unchanged files hard-linked from the previous snapshot
+	BytesCopied int64 // bytes fetched from the device
+	Errors      []error
+}
+
+// ListSnapshots returns the completed snapshots under root, oldest first.
+// A missing root yields no snapshots.
+func ListSnapshots(root string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snaps []Snapshot
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		t, err := time.ParseInLocation(snapshotLayout, e.Name(), time.Local)
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, Snapshot{Name: e.Name(), Path: filepath.Join(root, e.Name()), Time: t})
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Name < snaps[j].Name })
+	return snaps, nil
+}
+
+// FindSnapshot resolves a snapshot by name, or the newest one for "latest".
+func FindSnapshot(root, name string) (Snapshot, error) {
+	snaps, err := ListSnapshots(root)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(snaps) == 0 {
+		return Snapshot{}, fmt.Errorf("no snapshots in %s", root)
+	}
+	if name == "latest" {
+		return snaps[len(snaps)-1], nil
+	}
+	for _, s := range snaps {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Snapshot{}, fmt.Errorf("snapshot %q not found in %s", name, root)
+}
+
+// Pull copies folders from the device into a new timestamped snapshot under
+// root. Files whose size and modification time match the previous snapshot
+// are hard-linked from it instead of downloaded, so unchanged saves cost no
+// transfer time and no extra disk space. The backend must implement
+// transfer.Lister and transfer.Downloader.
+func Pull(ctx context.Context, backend transfer.TransferBackend, root string, folders []string, progressFn func(current, total int, filename string)) (*PullResult, error) {
+	lister, ok := backend.(transfer.Lister)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot list device files")
+	}
+	downloader, ok := backend.(transfer.Downloader)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot download device files")
+	}
+
+	var remote []transfer.RemoteFile
+	for _, folder := range folders {
+		files, err := lister.ListFiles(ctx, folder)
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", folder, err)
+		}
+		remote = append(remote, files...)
+	}
+
+	var prev *Snapshot
+	if snaps, err := ListSnapshots(root); err == nil && len(snaps) > 0 {
+		prev = &snaps[len(snaps)-1]
+	}
+
+	now := time.Now()
+	name := now.Format(snapshotLayout)
+	if prev != nil && prev.Name >= name {
+		// Two pulls within the same second: keep names unique and ordered.
+		t, _ := time.ParseInLocation(snapshotLayout, prev.Name, time.Local)
+		now = t.Add(time.Second)
+		name = now.Format(snapshotLayout)
+	}
+
+	workDir := filepath.Join(root, inProgressPrefix+name)
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	result := &PullResult{}
+	for i, rf := range remote {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if progressFn != nil {
+			progressFn(i+1, len(remote), path.Base(rf.Path))
+		}
+
+		rel := filepath.FromSlash(path.Clean("/" + rf.Path))[1:]
+		localPath := filepath.Join(workDir, rel)
+
+		if prev != nil && linkUnchanged(filepath.Join(prev.Path, rel), localPath, rf) {
+			result.Linked++
+			continue
+		}
+
+		if err := downloader.Download(ctx, rf.Path, localPath, nil); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Errors = append(result.Errors, fmt.Errorf("download %s: %w", rf.Path, err))
+			continue
+		}
+		result.Downloaded++
+		result.BytesCopied += rf.Size
+	}
+
+	finalDir := filepath.Join(root, name)
+	if err := os.Rename(workDir, finalDir); err != nil {
+		return nil, fmt.Errorf("finalize snapshot: %w", err)
+	}
+	result.Snapshot = Snapshot{Name: name, Path: finalDir, Time: now}
+	return result, nil
+}
+
+// linkUnchanged hard-links prevPath to localPath when it matches rf by size
+// and modification time (to the second, the precision SFTP reports). It
+// falls back to a copy on filesystems without hard links.
+func linkUnchanged(prevPath, localPath string, rf transfer.RemoteFile) bool {
+	info, err := os.Stat(prevPath)
+	if err != nil || info.Size() != rf.Size || info.ModTime().Unix() != rf.ModTime.Unix() {
+		return false
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return false
+	}
+	if err := os.Link(prevPath, localPath); err == nil {
+		return true
+	}
+	if err := copyFile(prevPath, localPath); err != nil {
+		return false
+	}
+	os.Chtimes(localPath, info.ModTime(), info.ModTime())
+	return true
+}
+
+// BuildRestorePlan builds a transfer plan that pushes the given folders of
+// a snapshot back to the device. Folders missing from the snapshot are
+// skipped. Every file is sent; sync mode would skip saves that changed
+// without changing size.
+func BuildRestorePlan(ctx context.Context, backend transfer.TransferBackend, snap Snapshot, folders []string) (*transfer.TransferPlan, error) {
+	var plans []*transfer.TransferPlan
+	for _, folder := range folders {
+		localDir := filepath.Join(snap.Path, folder)
+		if _, err := os.Stat(localDir); err != nil {
+			continue
+		}
+		plan, err := transfer.BuildTransferPlan(ctx, backend, localDir, folder, false)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return transfer.MergeTransferPlans(plans...), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
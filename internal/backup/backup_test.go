@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+func writeDeviceFile(t *testing.T, mount, rel, data string, mtime time.Time) {
+	t.Helper()
+	p := filepath.Join(mount, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+	ia, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(ia, ib)
+}
+
+func TestPull_DedupesUnchangedFiles(t *testing.T) {
+	mount := t.TempDir()
+	root := t.TempDir()
+	old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeDeviceFile(t, mount, "saves/snes/Mario.srm", "mario-1", old)
+	writeDeviceFile(t, mount, "saves/snes/Zelda.srm", "zelda-1", old)
+	writeDeviceFile(t, mount, "captures/shot.png", "png", old)
+	writeDeviceFile(t, mount, "roms/snes/Mario.sfc", "rom", old)
+
+	backend := transfer.NewUSBBackend(mount)
+	ctx := context.Background()
+
+	first, err := Pull(ctx, backend, root, DefaultFolders, nil)
+	if err != nil {
+		t.Fatalf("first Pull: %v", err)
+	}
+	if first.Downloaded != 3 || first.Linked != 0 {
+		t.Errorf("first pull downloaded %d linked %d, want 3/0", first.Downloaded, first.Linked)
+	}
+	if _, err := os.Stat(filepath.Join(first.Snapshot.Path, "roms")); !os.IsNotExist(err) {
+		t.Error("roms should not be backed up")
+	}
+
+	// Change one save; the other files are unchanged.
+	writeDeviceFile(t, mount, "saves/snes/Mario.srm", "mario-2", old.Add(time.Hour))
+
+	second, err := Pull(ctx, backend, root, DefaultFolders, nil)
+	if err != nil {
+		t.Fatalf("second Pull: %v", err)
+	}
+	if second.Downloaded != 1 || second.Linked != 2 {
+		t.Errorf("second pull downloaded %d linked %d, want 1/2", second.Downloaded, second.Linked)
+	}
+	if second.Snapshot.Name <= first.Snapshot.Name {
+		t.Errorf("snapshot names not ordered: %s then %s", first.Snapshot.Name, second.Snapshot.Name)
+	}
+
+	zelda := filepath.Join("saves", "snes", "Zelda.srm")
+	if !sameFile(t, filepath.Join(first.Snapshot.Path, zelda), filepath.Join(second.Snapshot.Path, zelda)) {
+		t.Error("unchanged save was not hard-linked between snapshots")
+	}
+	data, _ := os.ReadFile(filepath.Join(first.Snapshot.Path, "saves", "snes", "Mario.srm"))
+	if string(data) != "mario-1" {
+		t.Errorf("first snapshot was modified: %q", data)
+	}
+
+	snaps, err := ListSnapshots(root)
+	if err != nil || len(snaps) != 2 {
+		t.Fatalf("ListSnapshots = %v, %v; want 2", snaps, err)
+	}
+	latest, err := FindSnapshot(root, "latest")
+	if err != nil || latest.Name != second.Snapshot.Name {
+		t.Errorf("FindSnapshot(latest) = %v, %v", latest.Name, err)
+	}
+}
+
+func TestRestore_PushesSnapshotBack(t *testing.T) {
+	mount := t.TempDir()
+	root := t.TempDir()
+	mtime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeDeviceFile(t, mount, "saves/gba/Pokemon.sav", "good", mtime)
+
+	backend := transfer.NewUSBBackend(mount)
+	ctx := context.Background()
+	result, err := Pull(ctx, backend, root, DefaultFolders, nil)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+
+	// Same size, different content: restore must not skip it.
+	writeDeviceFile(t, mount, "saves/gba/Pokemon.sav", "lost", mtime)
+
+	plan, err := BuildRestorePlan(ctx, backend, result.Snapshot, DefaultFolders)
+	if err != nil {
+		t.Fatalf("BuildRestorePlan: %v", err)
+	}
+	if len(plan.Items) != 1 || plan.SkipCount != 0 {
+		t.Fatalf("plan has %d items, %d skipped; want 1/0", len(plan.Items), plan.SkipCount)
+	}
+	if err := transfer.Execute(ctx, backend, plan, 1, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(mount, "saves", "gba", "Pokemon.sav"))
+	if string(data) != "good" {
+		t.Errorf("restored content = %q, want %q", data, "good")
+	}
+}
+
+func TestFindSnapshot_Missing(t *testing.T) {
+	if _, err := FindSnapshot(filepath.Join(t.TempDir(), "none"), "latest"); err == nil {
+		t.Error("expected error for empty backup root")
+	}
+}
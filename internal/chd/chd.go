@@ -0,0 +1,138 @@
+// Package chd reads the header of MAME CHD (Compressed Hunks of Data)
+// files: the SHA1s of the data they hold and basic metadata, without
+// decompressing anything.
+package chd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// magic starts every CHD file.
+const magic = "MComprHD"
+
+// Header lengths by version.
+const (
+	v3Length = 120
+	v4Length = 108
+	v5Length = 124
+)
+
+// Header is the metadata stored at the start of a CHD file. SHA1s are
+// lowercase hex; empty when the version does not store them.
+type Header struct {
+	Version uint32
+	// Compressors are the codecs hunks may be compressed with, e.g. "cdlz",
+	// "cdzl" and "cdfl" for CD images; empty for an uncompressed CHD.
+	Compressors  []string
+	LogicalBytes uint64 // size of the uncompressed data
+	HunkBytes    uint32
+	UnitBytes    uint32 // v5 only
+	// RawSHA1 covers the uncompressed data only (v4 and later).
+	RawSHA1 string
+	// SHA1 covers the data and its metadata; it is the hash DATs list for
+	// disks.
+	SHA1       string
+	ParentSHA1 string // set when the CHD is a diff against a parent
+}
+
+// ReadHeader reads the header of the CHD file at path.
+func ReadHeader(path string) (*Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a CHD header from the start of r. Versions 3, 4 and 5 are
+// supported.
+func Parse(r io.Reader) (*Header, error) {
+	buf := make([]byte, v5Length)
+	if _, err := io.ReadFull(r, buf[:16]); err != nil {
+		return nil, fmt.Errorf("not a CHD file: %w", err)
+	}
+	if string(buf[:8]) != magic {
+		return nil, fmt.Errorf("not a CHD file")
+	}
+	be := binary.BigEndian
+	length := be.Uint32(buf[8:])
+	version := be.Uint32(buf[12:])
+
+	var want uint32
+	switch version {
+	case 3:
+		want = v3Length
+	case 4:
+		want = v4Length
+	case 5:
+		want = v5Length
+	default:
+		return nil, fmt.Errorf("unsupported CHD version %d", version)
+	}
+	if length != want {
+		return nil, fmt.Errorf("CHD v%d header length %d, want %d", version, length, want)
+	}
+	if _, err := io.ReadFull(r, buf[16:length]); err != nil {
+		return nil, fmt.Errorf("short CHD header: %w", err)
+	}
+
+	h := &Header{Version: version}
+	switch version {
+	case 5:
+		for i := 0; i < 4; i++ {
+			if c := buf[16+4*i : 20+4*i]; be.Uint32(c) != 0 {
+				h.Compressors = append(h.Compressors, string(c))
+			}
+		}
+		h.LogicalBytes = be.Uint64(buf[32:])
+		h.HunkBytes = be.Uint32(buf[56:])
+		h.UnitBytes = be.Uint32(buf[60:])
+		h.RawSHA1 = sha1Hex(buf[64:84])
+		h.SHA1 = sha1Hex(buf[84:104])
+		h.ParentSHA1 = sha1Hex(buf[104:124])
+	case 4:
+		h.Compressors = legacyCompressor(be.Uint32(buf[20:]))
+		h.LogicalBytes = be.Uint64(buf[28:])
+		h.HunkBytes = be.Uint32(buf[44:])
+		h.SHA1 = sha1Hex(buf[48:68])
+		h.ParentSHA1 = sha1Hex(buf[68:88])
+		h.RawSHA1 = sha1Hex(buf[88:108])
+	case 3:
+		h.Compressors = legacyCompressor(be.Uint32(buf[20:]))
+		h.LogicalBytes = be.Uint64(buf[28:])
+		h.HunkBytes = be.Uint32(buf[76:])
+		h.SHA1 = sha1Hex(buf[80:100])
+		h.ParentSHA1 = sha1Hex(buf[100:120])
+	}
+	return h, nil
+}
+
+// legacyCompressor names the v3/v4 compression type.
+func legacyCompressor(c uint32) []string {
+	switch c {
+	case 0:
+		return nil
+	case 1:
+		return []string{"zlib"}
+	case 2:
+		return []string{"zlib+"}
+	case 3:
+		return []string{"avhu"}
+	default:
+		return []string{fmt.Sprintf("type %d", c)}
+	}
+}
+
+// sha1Hex formats a stored SHA1; all zeroes means none.
+func sha1Hex(b []byte) string {
+	if bytes.Equal(b, make([]byte, len(b))) {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
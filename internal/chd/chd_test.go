@@ -0,0 +1,81 @@
+package chd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// v5Header builds a v5 header as chdman writes it for a CD image.
+func v5Header() []byte {
+	b := make([]byte, v5Length)
+	be := binary.BigEndian
+	copy(b, magic)
+	be.PutUint32(b[8:], v5Length)
+	be.PutUint32(b[12:], 5)
+	copy(b[16:], "cdlzcdzlcdfl")
+	be.PutUint64(b[32:], 705600000)
+	be.PutUint64(b[40:], 124)
+	be.PutUint32(b[56:], 19584)
+	be.PutUint32(b[60:], 2448)
+	for i := 0; i < 20; i++ {
+		b[64+i] = 0xAA
+		b[84+i] = 0xBB
+	}
+	return b
+}
+
+func TestParse_V5(t *testing.T) {
+	h, err := Parse(bytes.NewReader(append(v5Header(), "compressed hunks"...)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if h.Version != 5 || h.LogicalBytes != 705600000 || h.HunkBytes != 19584 || h.UnitBytes != 2448 {
+		t.Errorf("header = %+v", h)
+	}
+	if strings.Join(h.Compressors, ",") != "cdlz,cdzl,cdfl" {
+		t.Errorf("compressors = %v", h.Compressors)
+	}
+	if h.RawSHA1 != strings.Repeat("aa", 20) || h.SHA1 != strings.Repeat("bb", 20) || h.ParentSHA1 != "" {
+		t.Errorf("sha1s = %q %q %q", h.RawSHA1, h.SHA1, h.ParentSHA1)
+	}
+}
+
+func TestParse_V4(t *testing.T) {
+	b := make([]byte, v4Length)
+	be := binary.BigEndian
+	copy(b, magic)
+	be.PutUint32(b[8:], v4Length)
+	be.PutUint32(b[12:], 4)
+	be.PutUint32(b[20:], 2)
+	be.PutUint64(b[28:], 1<<20)
+	be.PutUint32(b[44:], 4096)
+	b[48] = 0x12
+	b[88] = 0x34
+
+	h, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if h.LogicalBytes != 1<<20 || h.HunkBytes != 4096 || len(h.Compressors) != 1 || h.Compressors[0] != "zlib+" {
+		t.Errorf("header = %+v", h)
+	}
+	if !strings.HasPrefix(h.SHA1, "12") || !strings.HasPrefix(h.RawSHA1, "34") {
+		t.Errorf("sha1s = %q %q", h.SHA1, h.RawSHA1)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	bad := v5Header()
+	binary.BigEndian.PutUint32(bad[12:], 9)
+	for name, data := range map[string][]byte{
+		"not chd":   []byte("PK\x03\x04 this is a zip file"),
+		"truncated": v5Header()[:60],
+		"version":   bad,
+	} {
+		if _, err := Parse(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: Parse succeeded", name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,18 +14,57 @@ type Config struct {
 	ChdmanPath    string            `yaml:"chdman_path,omitempty"`
 	DeleteArchive bool              `yaml:"delete_archive,omitempty"`
 	Device        DeviceConfig      `yaml:"device"`
+	Devices       []DeviceConfig    `yaml:"devices,omitempty"` // named devices for multi-device transfers
 	Scraping      ScrapingConfig    `yaml:"scraping"`
 	Transfer      TransferConfig    `yaml:"transfer"`
 	Aliases       map[string]string `yaml:"aliases,omitempty"`
 }
 
 type DeviceConfig struct {
+	Name     string `yaml:"name,omitempty"` // identifies an entry in Devices
 	Type     string `yaml:"type"`
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	RootPath string `yaml:"root_path"`
+
+	// Key-based authentication. Any combination may be set; keys are tried
+	// before the password. Leave Password empty to disable password login.
+	IdentityFile string `yaml:"identity_file,omitempty"`
+	UseAgent     bool   `yaml:"use_agent,omitempty"`
+
+	// KnownHostsFile is the OpenSSH known_hosts file host keys are
+	// verified against. When empty, ~/.ssh/known_hosts is used.
+	KnownHostsFile string `yaml:"known_hosts_file,omitempty"`
+	// InsecureHostKey skips host key verification when no known_hosts
+	// file is set. Connections log a warning.
+	InsecureHostKey bool `yaml:"insecure_host_key,omitempty"`
+
+	// Systems limits ROM transfers to these ReplayOS system folders, e.g.
+	// a handheld that only gets 8- and 16-bit systems. Empty sends all.
+	Systems []string `yaml:"systems,omitempty"`
+
+	// Lists curates the device's _favorites and _autostart folders.
+	Lists ListsConfig `yaml:"lists,omitempty"`
+}
+
+// ListsConfig curates ReplayOS's special roms folders from the library.
+// Games are paths under roms/, e.g. "nintendo_snes/Super Metroid (USA).sfc".
+type ListsConfig struct {
+	Favorites []string `yaml:"favorites,omitempty"`
+	Autostart string   `yaml:"autostart,omitempty"` // the one game in _autostart
+	// Kiosk sets system_kiosk_mode in the device's replay.cfg after a
+	// transfer. Unset leaves the device's setting alone.
+	Kiosk *bool `yaml:"kiosk,omitempty"`
+}
+
+// Label returns the device's name, or its host when it has none.
+func (d DeviceConfig) Label() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Host
 }
 
 type ScrapingConfig struct {
@@ -34,10 +74,40 @@ type ScrapingConfig struct {
 }
 
 type TransferConfig struct {
-	Method      string `yaml:"method"`
-	SyncMode    bool   `yaml:"sync_mode"`
-	USBPath     string `yaml:"usb_path,omitempty"`
-	Concurrency int    `yaml:"concurrency"`
+	Method   string `yaml:"method"`
+	SyncMode bool   `yaml:"sync_mode"`
+	USBPath  string `yaml:"usb_path,omitempty"`
+	// USBFilesystem overrides filesystem detection for USBPath: "fat32",
+	// "exfat" or "none". Empty detects it from the mount table.
+	USBFilesystem string `yaml:"usb_filesystem,omitempty"`
+	Concurrency   int    `yaml:"concurrency"`
+	Verify        bool   `yaml:"verify,omitempty"`     // checksum files on the destination after upload
+	BackupDir     string `yaml:"backup_dir,omitempty"` // where pulled saves/captures/config snapshots are kept
+	Mirror        bool   `yaml:"mirror,omitempty"`     // offer to delete device ROMs that no longer exist locally
+	Manifest      bool   `yaml:"manifest"`             // keep a manifest on the device for fast sync decisions
+	// BandwidthLimit caps upload speed in KiB/s across all workers (rsync
+	// --bwlimit); 0 is unlimited.
+	BandwidthLimit int `yaml:"bandwidth_limit,omitempty"`
+	// Window restricts uploads to a daily time range, "HH:MM-HH:MM"
+	// (may span midnight). Empty allows any time.
+	Window string `yaml:"window,omitempty"`
+
+	Capacity CapacityConfig `yaml:"capacity,omitempty"`
+}
+
+// CapacityConfig fits transfers to the space on the destination.
+type CapacityConfig struct {
+	FitToDevice bool    `yaml:"fit_to_device,omitempty"` // limit the plan to the destination's free space
+	BudgetGB    float64 `yaml:"budget_gb,omitempty"`     // fixed limit in GB; the smaller limit wins
+	ReserveMB   int     `yaml:"reserve_mb,omitempty"`    // space to leave free
+
+	// Priorities maps ReplayOS system folders to a priority; higher values
+	// are kept first when not everything fits. Unlisted systems are 0.
+	Priorities map[string]int `yaml:"priorities,omitempty"`
+	// Include and Exclude are glob patterns matched against folder and
+	// file names, e.g. "nintendo_n64" or "*(Beta)*".
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
 }
 
 func DefaultConfig() *Config {
@@ -54,6 +124,7 @@ func DefaultConfig() *Config {
 			Method:      "sftp",
 			SyncMode:    true,
 			Concurrency: 1,
+			Manifest:    true,
 		},
 	}
 }
@@ -87,10 +158,55 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, err
 	}
+	cfg.fillDeviceDefaults()
 	cfg.expandPaths()
 	return cfg, nil
 }
 
+// fillDeviceDefaults gives entries in Devices the default type, port, user
+// and root path when they leave them out.
+func (cfg *Config) fillDeviceDefaults() {
+	def := DefaultConfig().Device
+	for i := range cfg.Devices {
+		d := &cfg.Devices[i]
+		if d.Type == "" {
+			d.Type = def.Type
+		}
+		if d.Port == 0 {
+			d.Port = def.Port
+		}
+		if d.User == "" {
+			d.User = def.User
+		}
+		if d.RootPath == "" {
+			d.RootPath = def.RootPath
+		}
+	}
+}
+
+// AllDevices returns the named devices, or the single Device when none
+// are configured.
+func (cfg *Config) AllDevices() []DeviceConfig {
+	if len(cfg.Devices) > 0 {
+		return cfg.Devices
+	}
+	return []DeviceConfig{cfg.Device}
+}
+
+// FindDevice returns the device called name, matching Devices by name and
+// then the single Device by name or host.
+func (cfg *Config) FindDevice(name string) (DeviceConfig, error) {
+	for _, d := range cfg.AllDevices() {
+		if d.Label() == name {
+			return d, nil
+		}
+	}
+	if cfg.Device.Label() == name {
+		return cfg.Device, nil
+	}
+	return DeviceConfig{}, fmt.Errorf("no device named %q", name)
+}
+
 // expandPaths resolves ~ to the user's home directory in all path fields.
 func (cfg *Config) expandPaths() {
 	home, err := os.UserHomeDir()
@@ -102,7 +218,16 @@ func (cfg *Config) expandPaths() {
 	}
 	cfg.ChdmanPath = expandTilde(cfg.ChdmanPath, home)
 	cfg.Device.RootPath = expandTilde(cfg.Device.RootPath, home)
+	cfg.Device.IdentityFile = expandTilde(cfg.Device.IdentityFile, home)
+	cfg.Device.KnownHostsFile = expandTilde(cfg.Device.KnownHostsFile, home)
+	for i := range cfg.Devices {
+		d := &cfg.Devices[i]
+		d.RootPath = expandTilde(d.RootPath, home)
+		d.IdentityFile = expandTilde(d.IdentityFile, home)
+		d.KnownHostsFile = expandTilde(d.KnownHostsFile, home)
+	}
 	cfg.Transfer.USBPath = expandTilde(cfg.Transfer.USBPath, home)
+	cfg.Transfer.BackupDir = expandTilde(cfg.Transfer.BackupDir, home)
 	for i, d := range cfg.Scraping.DATDirs {
 		cfg.Scraping.DATDirs[i] = expandTilde(d, home)
 	}
@@ -127,6 +252,19 @@ func (cfg *Config) ROMDirs() []string {
 	return dirs
 }
 
+// BackupRoot returns the directory that holds device backup snapshots:
+// Transfer.BackupDir if set, otherwise _backups in the first source root,
+// falling back to the config directory when no source is configured.
+func (cfg *Config) BackupRoot() string {
+	if cfg.Transfer.BackupDir != "" {
+		return cfg.Transfer.BackupDir
+	}
+	if len(cfg.SourceDirs) > 0 {
+		return filepath.Join(cfg.SourceDirs[0], "_backups")
+	}
+	return filepath.Join(filepath.Dir(DefaultPath()), "backups")
+}
+
 func Save(cfg *Config, path string) error {
 	if path == "" {
 		path = DefaultPath()
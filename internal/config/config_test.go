@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_Devices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+device:
+  host: replayos.local
+devices:
+  - name: crt
+    host: 192.168.1.20
+  - name: handheld
+    host: 192.168.1.30
+    port: 2222
+    user: pi
+    root_path: /media/sd
+    systems: [nintendo_gb, nintendo_gba]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	all := cfg.AllDevices()
+	if len(all) != 2 {
+		t.Fatalf("AllDevices = %d, want 2", len(all))
+	}
+	crt := all[0]
+	if crt.Port != 22 || crt.User != "root" || crt.RootPath != "/" || crt.Type != "replayos" {
+		t.Errorf("crt defaults = %+v", crt)
+	}
+
+	hh, err := cfg.FindDevice("handheld")
+	if err != nil {
+		t.Fatalf("FindDevice: %v", err)
+	}
+	if hh.Port != 2222 || hh.User != "pi" || hh.RootPath != "/media/sd" || len(hh.Systems) != 2 {
+		t.Errorf("handheld = %+v", hh)
+	}
+
+	if _, err := cfg.FindDevice("replayos.local"); err != nil {
+		t.Errorf("FindDevice(single device host): %v", err)
+	}
+	if _, err := cfg.FindDevice("bartop"); err == nil {
+		t.Error("FindDevice(bartop) succeeded")
+	}
+}
+
+func TestAllDevices_Single(t *testing.T) {
+	cfg := DefaultConfig()
+	all := cfg.AllDevices()
+	if len(all) != 1 || all[0].Label() != "replayos.local" {
+		t.Errorf("AllDevices = %+v, want the single device", all)
+	}
+}
+
+func TestSaveLoad_EmptyPassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := DefaultConfig()
+	cfg.Device.Password = ""
+	cfg.Device.IdentityFile = "/keys/id_ed25519"
+	if err := Save(cfg, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Device.Password != "" {
+		t.Errorf("Password = %q after round trip, want empty", got.Device.Password)
+	}
+	if got.Device.IdentityFile != "/keys/id_ed25519" {
+		t.Errorf("IdentityFile = %q", got.Device.IdentityFile)
+	}
+}
@@ -29,6 +29,10 @@ func CompanionFiles(discImagePath string) ([]string, error) {
 	}
 }
 
+// gdiTrackRe matches a GDI track line: number, LBA, type and sector size,
+// then the filename, quoted when it contains spaces.
+var gdiTrackRe = regexp.MustCompile(`^\s*\d+\s+\d+\s+\d+\s+\d+\s+(?:"([^"]+)"|(\S+))`)
+
 // parseGDI parses a GDI file and returns all referenced track files plus the
 // GDI file itself. GDI format: first line is track count, subsequent lines
 // have track info where field index 4 is the filename.
@@ -51,12 +55,16 @@ func parseGDI(path string) ([]string, error) {
 			continue // skip track count line
 		}
 
-		fields := strings.Fields(scanner.Text())
-		if len(fields) < 5 {
+		m := gdiTrackRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
 			continue
 		}
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
 
-		trackFile := filepath.Join(dir, fields[4])
+		trackFile := filepath.Join(dir, name)
 		if !seen[trackFile] {
 			seen[trackFile] = true
 			files = append(files, trackFile)
@@ -137,3 +137,17 @@ func TestCompanionFiles_GDI_DuplicateTracks(t *testing.T) {
 		t.Fatalf("expected 2 files (deduplicated), got %d: %v", len(files), files)
 	}
 }
+
+func TestCompanionFiles_GDI_QuotedNames(t *testing.T) {
+	dir := t.TempDir()
+	gdiPath := filepath.Join(dir, "game.gdi")
+	os.WriteFile(gdiPath, []byte("2\n1 0 4 2352 \"Game (Track 1).bin\" 0\n2 600 0 2352 \"Game (Track 2).raw\" 0\n"), 0644)
+
+	files, err := CompanionFiles(gdiPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 3 || filepath.Base(files[1]) != "Game (Track 1).bin" || filepath.Base(files[2]) != "Game (Track 2).raw" {
+		t.Errorf("files = %v", files)
+	}
+}
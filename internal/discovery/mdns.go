@@ -0,0 +1,416 @@
+// Package discovery finds ReplayOS devices and other SSH hosts on the local
+// network with multicast DNS (mDNS / DNS-SD).
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// MDNSAddr is the IPv4 mDNS group and port.
+const MDNSAddr = "224.0.0.251:5353"
+
+// DefaultServices are the DNS-SD service types browsed for: plain SSH and
+// the SFTP advertisement Avahi publishes for SSH servers.
+var DefaultServices = []string{"_ssh._tcp.local", "_sftp-ssh._tcp.local"}
+
+// DefaultHosts are host names asked for directly, for devices that run an
+// mDNS responder without advertising services.
+var DefaultHosts = []string{"replayos.local"}
+
+// unicastResponse asks responders to answer the querier directly (the QU
+// bit of the question class, RFC 6762 section 5.4).
+const unicastResponse dnsmessage.Class = 0x8000
+
+// Device is a host found on the network.
+type Device struct {
+	Hostname  string   // e.g. "replayos.local"
+	Instance  string   // DNS-SD instance name, e.g. "replayos", when advertised
+	Addrs     []net.IP // IPv4 addresses first
+	Port      int      // SSH port
+	Services  []string // service types it advertised
+	ReplayOS  bool     // name suggests a ReplayOS device
+	Reachable bool     // a TCP connection to Port succeeded
+}
+
+// Host returns the address to put in DeviceConfig: the host name, or the
+// first address when the name is unknown.
+func (d Device) Host() string {
+	if d.Hostname != "" {
+		return d.Hostname
+	}
+	if len(d.Addrs) > 0 {
+		return d.Addrs[0].String()
+	}
+	return ""
+}
+
+// Options controls Browse. The zero value browses DefaultServices and
+// DefaultHosts on MDNSAddr for two seconds.
+type Options struct {
+	Addr     string        // where queries are sent; default MDNSAddr
+	Timeout  time.Duration // total time to wait for answers; default 2s
+	Services []string      // default DefaultServices
+	Hosts    []string      // default DefaultHosts
+
+	// DialTimeout bounds the reachability check per device; default 1s.
+	// Negative skips the check.
+	DialTimeout time.Duration
+}
+
+func (o *Options) defaults() {
+	if o.Addr == "" {
+		o.Addr = MDNSAddr
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 2 * time.Second
+	}
+	if o.Services == nil {
+		o.Services = DefaultServices
+	}
+	if o.Hosts == nil {
+		o.Hosts = DefaultHosts
+	}
+	if o.DialTimeout == 0 {
+		o.DialTimeout = time.Second
+	}
+}
+
+// Browse queries the network and returns the devices that answered,
+// ReplayOS devices first, each checked for reachability.
+func Browse(ctx context.Context, opts Options) ([]Device, error) {
+	opts.defaults()
+
+	dst, err := net.ResolveUDPAddr("udp", opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	// Queries from a port other than 5353 are answered by unicast to that
+	// port (RFC 6762 section 6.7), so no group membership is needed.
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var initial []question
+	for _, s := range opts.Services {
+		initial = append(initial, question{name: s, qtype: dnsmessage.TypePTR})
+	}
+	for _, h := range opts.Hosts {
+		initial = append(initial, question{name: h, qtype: dnsmessage.TypeA})
+	}
+	query, err := buildQuery(initial)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteToUDP(query, dst); err != nil {
+		return nil, fmt.Errorf("send mDNS query: %w", err)
+	}
+
+	c := newCollector(opts.Services)
+	deadline := time.Now().Add(opts.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	// Ask again every quarter of the timeout for SRV and address records
+	// that did not come with the answers so far.
+	step := opts.Timeout / 4
+	followUp := time.Now().Add(step)
+
+	buf := make([]byte, 9000)
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		readUntil := deadline
+		if followUp.Before(readUntil) {
+			readUntil = followUp
+		}
+		conn.SetReadDeadline(readUntil)
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var ne net.Error
+			if !errors.As(err, &ne) || !ne.Timeout() {
+				return nil, err
+			}
+			if time.Now().Before(deadline) {
+				followUp = time.Now().Add(step)
+				if qs := c.missing(); len(qs) > 0 {
+					if query, err := buildQuery(qs); err == nil {
+						conn.WriteToUDP(query, dst)
+					}
+				}
+				continue
+			}
+			break
+		}
+		if records, err := parseMessage(buf[:n]); err == nil {
+			c.add(records)
+		}
+	}
+
+	devices := c.devices()
+	if opts.DialTimeout > 0 {
+		checkReachable(ctx, devices, opts.DialTimeout)
+	}
+	return devices, nil
+}
+
+// checkReachable dials every device's SSH port concurrently.
+func checkReachable(ctx context.Context, devices []Device, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for i := range devices {
+		if len(devices[i].Addrs) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(d *Device) {
+			defer wg.Done()
+			dialer := net.Dialer{Timeout: timeout}
+			addr := net.JoinHostPort(d.Addrs[0].String(), fmt.Sprint(d.Port))
+			if conn, err := dialer.DialContext(ctx, "tcp", addr); err == nil {
+				conn.Close()
+				d.Reachable = true
+			}
+		}(&devices[i])
+	}
+	wg.Wait()
+}
+
+// collector accumulates records from all answers.
+type collector struct {
+	services  map[string]bool
+	instances map[string]string // instance -> service type
+	srv       map[string]srvData
+	addrs     map[string][]net.IP
+}
+
+type srvData struct {
+	target string
+	port   int
+}
+
+func newCollector(services []string) *collector {
+	c := &collector{
+		services:  make(map[string]bool),
+		instances: make(map[string]string),
+		srv:       make(map[string]srvData),
+		addrs:     make(map[string][]net.IP),
+	}
+	for _, s := range services {
+		c.services[normalize(s)] = true
+	}
+	return c
+}
+
+func (c *collector) add(records []record) {
+	for _, r := range records {
+		switch r.rtype {
+		case dnsmessage.TypePTR:
+			if c.services[r.name] {
+				c.instances[r.target] = r.name
+			}
+		case dnsmessage.TypeSRV:
+			c.srv[r.name] = srvData{target: r.target, port: r.port}
+		case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+			dup := false
+			for _, ip := range c.addrs[r.name] {
+				dup = dup || ip.Equal(r.ip)
+			}
+			if !dup {
+				c.addrs[r.name] = append(c.addrs[r.name], r.ip)
+			}
+		}
+	}
+}
+
+// missing returns queries for SRV records of known instances and
+// addresses of known targets that have not arrived yet.
+func (c *collector) missing() []question {
+	var qs []question
+	for inst := range c.instances {
+		s, ok := c.srv[inst]
+		if !ok {
+			qs = append(qs, question{name: inst, qtype: dnsmessage.TypeSRV})
+		} else if len(c.addrs[s.target]) == 0 {
+			qs = append(qs, question{name: s.target, qtype: dnsmessage.TypeA})
+		}
+	}
+	return qs
+}
+
+func (c *collector) devices() []Device {
+	byHost := make(map[string]*Device)
+	get := func(host string) *Device {
+		d, ok := byHost[host]
+		if !ok {
+			d = &Device{Hostname: host, Port: 22, Addrs: sortAddrs(c.addrs[host])}
+			byHost[host] = d
+		}
+		return d
+	}
+
+	for inst, service := range c.instances {
+		s, ok := c.srv[inst]
+		if !ok {
+			continue
+		}
+		d := get(s.target)
+		d.Port = s.port
+		if d.Instance == "" {
+			d.Instance = strings.TrimSuffix(inst, "."+service)
+		}
+		d.Services = append(d.Services, service)
+	}
+	// Hosts that only answered an address query.
+	for host := range c.addrs {
+		get(host)
+	}
+
+	out := make([]Device, 0, len(byHost))
+	for _, d := range byHost {
+		sort.Strings(d.Services)
+		name := strings.ToLower(d.Hostname + " " + d.Instance)
+		d.ReplayOS = strings.Contains(name, "replay")
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ReplayOS != out[j].ReplayOS {
+			return out[i].ReplayOS
+		}
+		return out[i].Hostname < out[j].Hostname
+	})
+	return out
+}
+
+// sortAddrs puts IPv4 addresses before IPv6 ones.
+func sortAddrs(ips []net.IP) []net.IP {
+	out := append([]net.IP(nil), ips...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].To4() != nil && out[j].To4() == nil
+	})
+	return out
+}
+
+// normalize lower-cases a DNS name and drops the trailing dot.
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+type question struct {
+	name  string
+	qtype dnsmessage.Type
+}
+
+// buildQuery encodes an mDNS query (ID 0, no flags) for qs.
+func buildQuery(qs []question) ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	for _, q := range qs {
+		name, err := dnsmessage.NewName(normalize(q.name) + ".")
+		if err != nil {
+			return nil, fmt.Errorf("mDNS name %q: %w", q.name, err)
+		}
+		err = b.Question(dnsmessage.Question{Name: name, Type: q.qtype, Class: dnsmessage.ClassINET | unicastResponse})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// record is a parsed resource record of a type this package uses.
+type record struct {
+	name   string
+	rtype  dnsmessage.Type
+	target string // PTR, SRV
+	port   int    // SRV
+	ip     net.IP // A, AAAA
+}
+
+// parseMessage returns the answer, authority and additional records of a
+// DNS response, skipping record types it does not use.
+func parseMessage(msg []byte) ([]record, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil {
+		return nil, err
+	}
+	if !h.Response {
+		return nil, nil // a query, not a response
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, err
+	}
+
+	var records []record
+	for _, next := range []func() (dnsmessage.ResourceHeader, error){p.AnswerHeader, p.AuthorityHeader, p.AdditionalHeader} {
+		for {
+			rh, err := next()
+			if err == dnsmessage.ErrSectionDone {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			r, ok, err := parseRecord(&p, rh)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				records = append(records, r)
+			}
+		}
+	}
+	return records, nil
+}
+
+// parseRecord reads the body of the record rh heads. ok is false for
+// record types this package does not use.
+func parseRecord(p *dnsmessage.Parser, rh dnsmessage.ResourceHeader) (r record, ok bool, err error) {
+	r = record{name: normalize(rh.Name.String()), rtype: rh.Type}
+	switch rh.Type {
+	case dnsmessage.TypePTR:
+		rr, err := p.PTRResource()
+		if err != nil {
+			return r, false, err
+		}
+		r.target = normalize(rr.PTR.String())
+	case dnsmessage.TypeSRV:
+		rr, err := p.SRVResource()
+		if err != nil {
+			return r, false, err
+		}
+		r.target = normalize(rr.Target.String())
+		r.port = int(rr.Port)
+	case dnsmessage.TypeA:
+		rr, err := p.AResource()
+		if err != nil {
+			return r, false, err
+		}
+		r.ip = net.IP(rr.A[:])
+	case dnsmessage.TypeAAAA:
+		rr, err := p.AAAAResource()
+		if err != nil {
+			return r, false, err
+		}
+		r.ip = net.IP(rr.AAAA[:])
+	default:
+		_, err := p.UnknownResource()
+		return r, false, err
+	}
+	return r, true, nil
+}
@@ -0,0 +1,260 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testRecord is an answer the test responder sends.
+type testRecord struct {
+	name  string
+	rtype dnsmessage.Type
+	body  dnsmessage.ResourceBody
+}
+
+func dnsName(name string) dnsmessage.Name {
+	return dnsmessage.MustNewName(name + ".")
+}
+
+func buildResponse(records []testRecord) []byte {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	b.EnableCompression()
+	b.StartAnswers()
+	for _, r := range records {
+		h := dnsmessage.ResourceHeader{Name: dnsName(r.name), Class: dnsmessage.ClassINET, TTL: 120}
+		switch body := r.body.(type) {
+		case *dnsmessage.PTRResource:
+			b.PTRResource(h, *body)
+		case *dnsmessage.SRVResource:
+			b.SRVResource(h, *body)
+		case *dnsmessage.AResource:
+			b.AResource(h, *body)
+		}
+	}
+	msg, _ := b.Finish()
+	return msg
+}
+
+// respond answers every query on conn with records, as a device's mDNS
+// responder would. It returns the first query it saw.
+func respond(t *testing.T, conn *net.UDPConn, records []testRecord) <-chan []record {
+	t.Helper()
+	queries := make(chan []record, 1)
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if buf[2]&0x80 != 0 {
+				continue // our own response looped back
+			}
+			select {
+			case queries <- parseQuestions(buf[:n]):
+			default:
+			}
+			conn.WriteToUDP(buildResponse(records), from)
+		}
+	}()
+	return queries
+}
+
+// parseQuestions decodes the question section of a query.
+func parseQuestions(msg []byte) []record {
+	var p dnsmessage.Parser
+	if _, err := p.Start(msg); err != nil {
+		return nil
+	}
+	questions, _ := p.AllQuestions()
+	var qs []record
+	for _, q := range questions {
+		qs = append(qs, record{name: normalize(q.Name.String()), rtype: q.Type})
+	}
+	return qs
+}
+
+// deviceRecords advertises replayos.local as an SSH host on sshPort.
+func deviceRecords(sshPort int) []testRecord {
+	return []testRecord{
+		{"_ssh._tcp.local", dnsmessage.TypePTR, &dnsmessage.PTRResource{PTR: dnsName("replayos._ssh._tcp.local")}},
+		{"replayos._ssh._tcp.local", dnsmessage.TypeSRV, &dnsmessage.SRVResource{Port: uint16(sshPort), Target: dnsName("replayos.local")}},
+		{"replayos.local", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}},
+		{"_ssh._tcp.local", dnsmessage.TypePTR, &dnsmessage.PTRResource{PTR: dnsName("nas._ssh._tcp.local")}},
+		{"nas._ssh._tcp.local", dnsmessage.TypeSRV, &dnsmessage.SRVResource{Port: 1, Target: dnsName("nas.local")}},
+		{"nas.local", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{127, 0, 0, 2}}},
+	}
+}
+
+func checkDevices(t *testing.T, devices []Device, sshPort int) {
+	t.Helper()
+	if len(devices) != 2 {
+		t.Fatalf("devices = %+v, want 2", devices)
+	}
+	d := devices[0]
+	if d.Hostname != "replayos.local" || !d.ReplayOS || d.Instance != "replayos" || d.Port != sshPort {
+		t.Errorf("first device = %+v, want replayos.local on port %d", d, sshPort)
+	}
+	if len(d.Addrs) != 1 || !d.Addrs[0].Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("addrs = %v", d.Addrs)
+	}
+	if !d.Reachable {
+		t.Error("replayos.local not reachable")
+	}
+	if nas := devices[1]; nas.Hostname != "nas.local" || nas.ReplayOS || nas.Reachable {
+		t.Errorf("second device = %+v", nas)
+	}
+}
+
+// sshListener stands in for the device's SSH server.
+func sshListener(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestBrowse_Unicast(t *testing.T) {
+	sshPort := sshListener(t)
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	queries := respond(t, conn, deviceRecords(sshPort))
+
+	devices, err := Browse(context.Background(), Options{
+		Addr:        conn.LocalAddr().String(),
+		Timeout:     300 * time.Millisecond,
+		DialTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Browse: %v", err)
+	}
+	checkDevices(t, devices, sshPort)
+
+	q := <-queries
+	if len(q) != 3 || q[0].name != "_ssh._tcp.local" || q[0].rtype != dnsmessage.TypePTR || q[2].name != "replayos.local" || q[2].rtype != dnsmessage.TypeA {
+		t.Errorf("query = %+v", q)
+	}
+}
+
+func TestBrowse_Multicast(t *testing.T) {
+	sshPort := sshListener(t)
+	group := &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 53530}
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		t.Skipf("multicast unavailable: %v", err)
+	}
+	defer conn.Close()
+	respond(t, conn, deviceRecords(sshPort))
+
+	devices, err := Browse(context.Background(), Options{
+		Addr:        group.String(),
+		Timeout:     500 * time.Millisecond,
+		DialTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Skipf("multicast send failed: %v", err)
+	}
+	if len(devices) == 0 {
+		t.Skip("no multicast route on this host")
+	}
+	checkDevices(t, devices, sshPort)
+}
+
+func TestBrowse_FollowUp(t *testing.T) {
+	// The responder only answers what it is asked, so the SRV and address
+	// records come from follow-up queries.
+	sshPort := sshListener(t)
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	all := deviceRecords(sshPort)[:3]
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var answer []testRecord
+			for _, q := range parseQuestions(buf[:n]) {
+				for _, r := range all {
+					if r.name == q.name && r.rtype == q.rtype {
+						answer = append(answer, r)
+					}
+				}
+			}
+			if len(answer) > 0 {
+				conn.WriteToUDP(buildResponse(answer), from)
+			}
+		}
+	}()
+
+	devices, err := Browse(context.Background(), Options{
+		Addr:        conn.LocalAddr().String(),
+		Timeout:     400 * time.Millisecond,
+		Hosts:       []string{},
+		DialTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Browse: %v", err)
+	}
+	if len(devices) != 1 || devices[0].Hostname != "replayos.local" || devices[0].Port != sshPort || len(devices[0].Addrs) != 1 {
+		t.Errorf("devices = %+v", devices)
+	}
+}
+
+func TestParseMessage_Compression(t *testing.T) {
+	msg := buildResponse([]testRecord{
+		{"replayos.local", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 5}}},
+		{"replayos.local", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 6}}},
+	})
+	// The second record's name points back at the first one.
+	if n := bytes.Count(msg, []byte("replayos")); n != 1 {
+		t.Fatalf("name written %d times, want a compressed second record", n)
+	}
+
+	records, err := parseMessage(msg)
+	if err != nil {
+		t.Fatalf("parseMessage: %v", err)
+	}
+	if len(records) != 2 || records[1].name != "replayos.local" || !records[1].ip.Equal(net.IPv4(10, 0, 0, 6)) {
+		t.Errorf("records = %+v", records)
+	}
+
+	if _, err := parseMessage(msg[:len(msg)-3]); err == nil {
+		t.Error("truncated message parsed")
+	}
+}
+
+func TestParseMessage_Query(t *testing.T) {
+	query, err := buildQuery([]question{{name: "_ssh._tcp.local", qtype: dnsmessage.TypePTR}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := parseMessage(query)
+	if err != nil || records != nil {
+		t.Errorf("parseMessage(query) = %+v, %v, want nothing", records, err)
+	}
+}
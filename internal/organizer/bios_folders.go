@@ -365,6 +365,22 @@ var biosFileMap = map[string]string{
 	"cdimono2.zip": "same_cdi/bios",
 }
 
+// KnownBIOSFiles returns every BIOS file ScanBIOSFiles recognizes with its
+// target folder, sorted by folder and then filename. SourcePath is empty.
+func KnownBIOSFiles() []BIOSFileMatch {
+	files := make([]BIOSFileMatch, 0, len(biosFileMap))
+	for name, dir := range biosFileMap {
+		files = append(files, BIOSFileMatch{Filename: name, TargetDir: dir})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].TargetDir != files[j].TargetDir {
+			return files[i].TargetDir < files[j].TargetDir
+		}
+		return files[i].Filename < files[j].Filename
+	})
+	return files
+}
+
 // ScanBIOSFiles scans sourceDir for known BIOS files and returns matches
 // with their target directories.
 func ScanBIOSFiles(sourceDir string) []BIOSFileMatch {
@@ -0,0 +1,76 @@
+package overrides
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// CopyResult summarizes a Copy.
+type CopyResult struct {
+	Written []string // override paths created or replaced
+	Skipped []string // override paths that already existed
+}
+
+// Copy gives each game in games a copy of the game override src, named
+// after the game, in the same kind/scope/display folder under configDir.
+// Existing overrides are left alone unless overwrite is set. The next
+// transfer of the Config folder sends the copies to the device.
+func Copy(configDir string, src Override, games []string, overwrite bool) (*CopyResult, error) {
+	if src.Scope != ScopeGame {
+		return nil, fmt.Errorf("%s is a system override; only game overrides can be copied to games", src.Path)
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, filepath.FromSlash(src.Path)))
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Dir(src.Path)
+	ext := path.Ext(src.Path)
+
+	result := &CopyResult{}
+	seen := make(map[string]bool)
+	for _, g := range games {
+		rel := path.Join(dir, GameName(g)+ext)
+		if rel == src.Path || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+
+		dst := filepath.Join(configDir, filepath.FromSlash(rel))
+		if _, err := os.Stat(dst); err == nil && !overwrite {
+			result.Skipped = append(result.Skipped, rel)
+			continue
+		}
+		if err := writeFile(dst, data); err != nil {
+			return result, fmt.Errorf("write %s: %w", rel, err)
+		}
+		result.Written = append(result.Written, rel)
+	}
+	return result, nil
+}
+
+// writeFile writes data to p via a temporary file in the same directory.
+func writeFile(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".override-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
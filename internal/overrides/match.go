@@ -0,0 +1,98 @@
+package overrides
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/systems"
+)
+
+// Library indexes the library's games for matching overrides. Games are
+// paths under roms/, as returned by transfer.LibraryGames.
+type Library struct {
+	Games  []string
+	byName map[string][]string // ROM name without extension -> games
+}
+
+// NewLibrary indexes games by ROM name.
+func NewLibrary(games []string) *Library {
+	lib := &Library{Games: games, byName: make(map[string][]string)}
+	for _, g := range games {
+		n := GameName(g)
+		lib.byName[n] = append(lib.byName[n], g)
+	}
+	return lib
+}
+
+// GameName returns the name ReplayOS gives a game's overrides: its file
+// name without the extension.
+func GameName(game string) string {
+	base := path.Base(game)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// Match is an override with the games or system it applies to.
+type Match struct {
+	Override
+	Games  []string // game overrides: library games with the override's name
+	Orphan bool     // no such game in the library, or no such system folder
+}
+
+// Map matches each override to the library. Game overrides match games by
+// ROM name in any system folder; system overrides match ReplayOS system
+// folders.
+func Map(list []Override, lib *Library) []Match {
+	folders := make(map[string]bool, len(systems.ReplayOSFolders))
+	for _, f := range systems.ReplayOSFolders {
+		folders[f] = true
+	}
+
+	out := make([]Match, 0, len(list))
+	for _, o := range list {
+		m := Match{Override: o}
+		switch o.Scope {
+		case ScopeGame:
+			m.Games = lib.byName[o.Name]
+			m.Orphan = len(m.Games) == 0
+		case ScopeSystem:
+			m.Orphan = !folders[o.Name]
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// Orphans returns the matches whose game or system is gone.
+func Orphans(matches []Match) []Match {
+	var out []Match
+	for _, m := range matches {
+		if m.Orphan {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Select returns the library games matching any of patterns, sorted.
+// A pattern is a path.Match glob tried against the game's path under
+// roms/ and against its file name, so "arcade_fbneo/*" selects a system
+// and "*Raiden*" a title in any system.
+func (lib *Library) Select(patterns []string) ([]string, error) {
+	var out []string
+	for _, g := range lib.Games {
+		for _, p := range patterns {
+			full, err := path.Match(p, g)
+			if err != nil {
+				return nil, err
+			}
+			base, _ := path.Match(p, path.Base(g))
+			if full || base {
+				out = append(out, g)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,232 @@
+// Package overrides manages ReplayOS per-game and per-system overrides:
+// the files under config/settings and config/input, split by game/system
+// and CRT/LCD. They are kept in the library's config folder, the one the
+// Config transfer folder sends to the device.
+package overrides
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+// ConfigFolder is the device folder that holds the overrides, and the
+// library folder the Config transfer option sends.
+const ConfigFolder = "config"
+
+// Kind is what an override changes: core settings or input mapping.
+type Kind string
+
+const (
+	KindSettings Kind = "settings"
+	KindInput    Kind = "input"
+)
+
+// Scope says whether an override applies to one game or a whole system.
+type Scope string
+
+const (
+	ScopeGame   Scope = "game"
+	ScopeSystem Scope = "system"
+)
+
+// Display is the video output an override is for.
+type Display string
+
+const (
+	DisplayCRT Display = "crt"
+	DisplayLCD Display = "lcd"
+)
+
+var (
+	kinds    = []Kind{KindSettings, KindInput}
+	scopes   = []Scope{ScopeGame, ScopeSystem}
+	displays = []Display{DisplayCRT, DisplayLCD}
+)
+
+// Override is one override file. Name is the file name without its
+// extension: the game's ROM name for game overrides, the ReplayOS system
+// folder for system overrides.
+type Override struct {
+	Path    string // relative to the config folder, e.g. "settings/game/crt/Contra (USA).cfg"
+	Kind    Kind
+	Scope   Scope
+	Display Display
+	Name    string
+	Size    int64
+}
+
+// Dir returns the directory an override of this kind, scope and display
+// lives in, relative to the config folder.
+func Dir(kind Kind, scope Scope, display Display) string {
+	return path.Join(string(kind), string(scope), string(display))
+}
+
+// Dirs returns every override directory relative to the config folder,
+// the skeleton a fresh device needs.
+func Dirs() []string {
+	var out []string
+	for _, k := range kinds {
+		for _, s := range scopes {
+			for _, d := range displays {
+				out = append(out, Dir(k, s, d))
+			}
+		}
+	}
+	return out
+}
+
+// parsePath splits a path relative to the config folder into an Override.
+// Paths outside the {settings,input}/{game,system}/{crt,lcd} layout are
+// not overrides.
+func parsePath(rel string) (Override, bool) {
+	parts := strings.Split(rel, "/")
+	if len(parts) < 4 {
+		return Override{}, false
+	}
+	o := Override{Path: rel, Kind: Kind(parts[0]), Scope: Scope(parts[1]), Display: Display(parts[2])}
+	if !hasKind(o.Kind) || !hasScope(o.Scope) || !hasDisplay(o.Display) {
+		return Override{}, false
+	}
+	base := parts[len(parts)-1]
+	if strings.HasPrefix(base, ".") {
+		return Override{}, false
+	}
+	o.Name = strings.TrimSuffix(base, path.Ext(base))
+	return o, true
+}
+
+func hasKind(k Kind) bool {
+	for _, v := range kinds {
+		if v == k {
+			return true
+		}
+	}
+	return false
+}
+
+func hasScope(s Scope) bool {
+	for _, v := range scopes {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDisplay(d Display) bool {
+	for _, v := range displays {
+		if v == d {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the overrides in the local config folder, sorted by path.
+// A missing folder has none.
+func List(configDir string) ([]Override, error) {
+	var out []Override
+	for _, k := range kinds {
+		root := filepath.Join(configDir, string(k))
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && p == root {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(configDir, p)
+			if err != nil {
+				return err
+			}
+			o, ok := parsePath(filepath.ToSlash(rel))
+			if !ok {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			o.Size = info.Size()
+			out = append(out, o)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// PullResult summarizes a Pull.
+type PullResult struct {
+	Downloaded int
+	Unchanged  int // the local copy is as new as the device's
+	Errors     []error
+}
+
+// Pull copies the device's overrides into configDir. A file is only
+// downloaded when it is missing locally or the device copy is newer, so
+// overrides edited or copied locally and not yet sent are kept. The
+// backend must implement transfer.Lister and transfer.Downloader.
+func Pull(ctx context.Context, backend transfer.TransferBackend, configDir string, progressFn func(current, total int, filename string)) (*PullResult, error) {
+	lister, ok := backend.(transfer.Lister)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot list device files")
+	}
+	downloader, ok := backend.(transfer.Downloader)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot download device files")
+	}
+
+	var remote []transfer.RemoteFile
+	for _, k := range kinds {
+		dir := path.Join(ConfigFolder, string(k))
+		files, err := lister.ListFiles(ctx, dir)
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", dir, err)
+		}
+		for _, rf := range files {
+			if _, ok := parsePath(strings.TrimPrefix(rf.Path, ConfigFolder+"/")); ok {
+				remote = append(remote, rf)
+			}
+		}
+	}
+
+	result := &PullResult{}
+	for i, rf := range remote {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if progressFn != nil {
+			progressFn(i+1, len(remote), path.Base(rf.Path))
+		}
+		rel := strings.TrimPrefix(rf.Path, ConfigFolder+"/")
+		local := filepath.Join(configDir, filepath.FromSlash(rel))
+		if info, err := os.Stat(local); err == nil && !rf.ModTime.After(info.ModTime()) {
+			result.Unchanged++
+			continue
+		}
+		if err := downloader.Download(ctx, rf.Path, local, nil); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Errors = append(result.Errors, fmt.Errorf("download %s: %w", rf.Path, err))
+			continue
+		}
+		result.Downloaded++
+	}
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package overrides
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+func writeOverride(t *testing.T, p, data string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeOverride(t, filepath.Join(dir, "settings", "game", "crt", "Raiden (World).cfg"), "a", now)
+	writeOverride(t, filepath.Join(dir, "input", "system", "lcd", "arcade_fbneo.cfg"), "b", now)
+	writeOverride(t, filepath.Join(dir, "settings", "other", "crt", "x.cfg"), "c", now)
+	writeOverride(t, filepath.Join(dir, "replay.cfg"), "d", now)
+
+	list, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("List = %+v, want 2 overrides", list)
+	}
+	o := list[1]
+	if o.Kind != KindSettings || o.Scope != ScopeGame || o.Display != DisplayCRT || o.Name != "Raiden (World)" {
+		t.Errorf("override = %+v", o)
+	}
+
+	if list, err := List(filepath.Join(dir, "missing")); err != nil || len(list) != 0 {
+		t.Errorf("List of missing dir = %v, %v", list, err)
+	}
+}
+
+func TestPull_KeepsNewerLocal(t *testing.T) {
+	mount := t.TempDir()
+	local := t.TempDir()
+	old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := old.Add(time.Hour)
+
+	writeOverride(t, filepath.Join(mount, "config", "settings", "game", "crt", "A.cfg"), "device-a", newer)
+	writeOverride(t, filepath.Join(mount, "config", "settings", "game", "crt", "B.cfg"), "device-b", old)
+	writeOverride(t, filepath.Join(mount, "config", "replay.cfg"), "cfg", old)
+	writeOverride(t, filepath.Join(local, "settings", "game", "crt", "A.cfg"), "local-a", old)
+	writeOverride(t, filepath.Join(local, "settings", "game", "crt", "B.cfg"), "local-b", newer)
+
+	backend := transfer.NewUSBBackend(mount)
+	result, err := Pull(context.Background(), backend, local, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Downloaded != 1 || result.Unchanged != 1 || len(result.Errors) != 0 {
+		t.Errorf("Pull = %+v", result)
+	}
+	for name, want := range map[string]string{"A.cfg": "device-a", "B.cfg": "local-b"} {
+		data, _ := os.ReadFile(filepath.Join(local, "settings", "game", "crt", name))
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(local, "replay.cfg")); !os.IsNotExist(err) {
+		t.Error("replay.cfg pulled as an override")
+	}
+}
+
+func TestMapAndCopy(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeOverride(t, filepath.Join(dir, "settings", "game", "crt", "Raiden (World).cfg"), "vertical", now)
+	writeOverride(t, filepath.Join(dir, "settings", "game", "crt", "1942 (Japan).cfg"), "existing", now)
+	writeOverride(t, filepath.Join(dir, "input", "game", "lcd", "Gone.cfg"), "x", now)
+	writeOverride(t, filepath.Join(dir, "settings", "system", "crt", "arcade_fbneo.cfg"), "x", now)
+	writeOverride(t, filepath.Join(dir, "settings", "system", "crt", "not_a_system.cfg"), "x", now)
+
+	lib := NewLibrary([]string{
+		"arcade_fbneo/1942 (Japan).zip",
+		"arcade_fbneo/Raiden (World).zip",
+		"arcade_mame/Dodonpachi (Japan).zip",
+		"nintendo_snes/Super Metroid (USA).sfc",
+	})
+	list, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := Map(list, lib)
+	orphans := Orphans(matches)
+	if len(orphans) != 2 || orphans[0].Name != "Gone" || orphans[1].Name != "not_a_system" {
+		t.Errorf("Orphans = %+v", orphans)
+	}
+	var raiden Override
+	for _, m := range matches {
+		if m.Name == "Raiden (World)" {
+			raiden = m.Override
+			if len(m.Games) != 1 || m.Games[0] != "arcade_fbneo/Raiden (World).zip" {
+				t.Errorf("Raiden games = %v", m.Games)
+			}
+		}
+	}
+
+	targets, err := lib.Select([]string{"arcade_*/*", "*Metroid*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 4 {
+		t.Fatalf("Select = %v", targets)
+	}
+	result, err := Copy(dir, raiden, targets, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Written) != 2 || len(result.Skipped) != 1 {
+		t.Errorf("Copy = %+v, want 2 written and 1942 skipped", result)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "settings", "game", "crt", "Dodonpachi (Japan).cfg"))
+	if string(data) != "vertical" {
+		t.Errorf("copied override = %q", data)
+	}
+
+	if _, err := Copy(dir, orphans[1].Override, targets, false); err == nil {
+		t.Error("copied a system override to games")
+	}
+}
@@ -0,0 +1,294 @@
+// Package provision prepares a fresh ReplayOS SD card or USB drive in one
+// pass: folder skeleton, BIOS set, a chosen part of the library and a
+// default replay.cfg, followed by a completeness report.
+package provision
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+	"github.com/kurlmarx/romwrangler/internal/overrides"
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
+	"github.com/kurlmarx/romwrangler/internal/systems"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+// MountState describes what is already on the target mount.
+type MountState int
+
+const (
+	MountEmpty    MountState = iota // nothing but filesystem housekeeping
+	MountReplayOS                   // only ReplayOS top-level folders
+	MountForeign                    // anything else; provisioning needs Force
+)
+
+func (s MountState) String() string {
+	switch s {
+	case MountEmpty:
+		return "empty"
+	case MountReplayOS:
+		return "ReplayOS"
+	default:
+		return "not a ReplayOS drive"
+	}
+}
+
+// listFolders are the special roms/ folders ReplayOS manages itself.
+var listFolders = []string{"_autostart", "_extra", "_favorites", "_recent"}
+
+// ignoredEntry reports whether a top-level entry is filesystem or OS
+// housekeeping rather than user data.
+func ignoredEntry(name string) bool {
+	switch name {
+	case "lost+found", "System Volume Information", "$RECYCLE.BIN":
+		return true
+	}
+	return strings.HasPrefix(name, ".")
+}
+
+// CheckMount inspects the top level of mount. Foreign lists the entries
+// that are neither ReplayOS folders nor housekeeping.
+func CheckMount(mount string) (state MountState, foreign []string, err error) {
+	entries, err := os.ReadDir(mount)
+	if err != nil {
+		return 0, nil, err
+	}
+	known := make(map[string]bool, len(organizer.RootDeviceFolders))
+	for _, f := range organizer.RootDeviceFolders {
+		known[f] = true
+	}
+
+	state = MountEmpty
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case ignoredEntry(name):
+		case e.IsDir() && known[name]:
+			state = MountReplayOS
+		default:
+			foreign = append(foreign, name)
+		}
+	}
+	if len(foreign) > 0 {
+		return MountForeign, foreign, nil
+	}
+	return state, nil, nil
+}
+
+// Skeleton returns every directory a ReplayOS drive has, relative to its
+// root: the top-level folders, each system and list folder under roms/,
+// the BIOS folders and the override folders under config/.
+func Skeleton() []string {
+	dirs := append([]string(nil), organizer.RootDeviceFolders...)
+	seen := make(map[string]bool)
+	for _, f := range systems.ReplayOSFolders {
+		if !seen[f] {
+			seen[f] = true
+			dirs = append(dirs, path.Join("roms", f))
+		}
+	}
+	for _, f := range listFolders {
+		dirs = append(dirs, path.Join("roms", f))
+	}
+	for _, f := range organizer.BIOSFolders {
+		dirs = append(dirs, path.Join("bios", f))
+	}
+	for _, d := range overrides.Dirs() {
+		dirs = append(dirs, path.Join(overrides.ConfigFolder, d))
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// Options controls a provisioning run.
+type Options struct {
+	Mount       string
+	LibraryRoot string // the library's source root, holding roms/ and bios/
+	// Systems limits the ROMs copied to these system folders; empty copies
+	// the whole library.
+	Systems  []string
+	Transfer config.TransferConfig // capacity, include/exclude, concurrency, verify
+	// Preset is applied to replay.cfg, when set.
+	Preset *replaycfg.Preset
+	// Force provisions a mount that holds other files.
+	Force bool
+}
+
+// Provision is a checked mount and the plan for filling it.
+type Provision struct {
+	Options
+	State     MountState
+	Plan      *transfer.TransferPlan
+	BIOS      int // BIOS files found outside the library's bios/ folder
+	backend   *transfer.USBBackend
+	manifests transfer.Manifests
+}
+
+// Prepare checks the mount and plans the copy without writing anything.
+// A mount holding other files is refused unless opts.Force is set. Call
+// Plan.Cleanup when done with the result.
+func Prepare(ctx context.Context, opts Options) (*Provision, error) {
+	state, foreign, err := CheckMount(opts.Mount)
+	if err != nil {
+		return nil, err
+	}
+	if state == MountForeign && !opts.Force {
+		return nil, fmt.Errorf("%s is not empty and not a ReplayOS drive (found %s)", opts.Mount, strings.Join(foreign, ", "))
+	}
+
+	backend := transfer.NewUSBBackend(opts.Mount)
+	if err := backend.Connect(ctx); err != nil {
+		return nil, err
+	}
+	sp, err := transfer.PlanSync(ctx, backend, transfer.SyncOptions{
+		LocalRoot: opts.LibraryRoot,
+		Folders:   []string{"bios", "roms"},
+		Transfer:  opts.Transfer,
+		Systems:   opts.Systems,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := &Provision{Options: opts, State: state, Plan: sp.Plan, backend: backend, manifests: sp.Manifests}
+	p.BIOS = addLooseBIOS(p.Plan, filepath.Join(opts.LibraryRoot, "roms"))
+	return p, nil
+}
+
+// addLooseBIOS adds known BIOS files found among the ROMs to plan, sent to
+// their folder under bios/ unless the plan already has one there. Returns
+// the number added.
+func addLooseBIOS(plan *transfer.TransferPlan, romDir string) int {
+	have := make(map[string]bool, len(plan.Items))
+	for _, item := range plan.Items {
+		have[item.RemotePath] = true
+	}
+	added := 0
+	for _, m := range organizer.ScanBIOSFiles(romDir) {
+		remote := path.Join("bios", filepath.ToSlash(m.TargetDir), m.Filename)
+		if have[remote] {
+			continue
+		}
+		info, err := os.Stat(m.SourcePath)
+		if err != nil {
+			continue
+		}
+		have[remote] = true
+		plan.Items = append(plan.Items, transfer.TransferItem{
+			LocalPath:  m.SourcePath,
+			RemotePath: remote,
+			Size:       info.Size(),
+			ModTime:    info.ModTime(),
+		})
+		plan.TotalSize += info.Size()
+		added++
+	}
+	return added
+}
+
+// Report is the outcome of a provisioning run.
+type Report struct {
+	FoldersCreated int
+	Missing        []string // skeleton folders still missing afterwards
+	FilesSent      int
+	BytesSent      int64
+	Omitted        []transfer.OmittedItem
+	ReplayCfg      string  // "written", "updated" or "kept"
+	ReplayCfgErrs  []error // values in replay.cfg that do not validate
+	Status         *transfer.DeviceStatus
+	Errors         []error
+}
+
+// Complete reports whether the drive is fully provisioned: skeleton in
+// place, every planned file copied, a valid replay.cfg and no errors.
+// Missing BIOS files are reported but do not count against it; only the
+// ones in the library can be installed.
+func (r *Report) Complete() bool {
+	return len(r.Missing) == 0 && len(r.ReplayCfgErrs) == 0 && len(r.Errors) == 0
+}
+
+// Run creates the skeleton, copies the planned files, writes replay.cfg
+// and reads back the drive's status. A failed copy is reported and does
+// not stop the remaining steps.
+func (p *Provision) Run(ctx context.Context, progressCh chan<- transfer.TransferProgress) (*Report, error) {
+	r := &Report{Omitted: p.Plan.Omitted}
+
+	for _, d := range Skeleton() {
+		full := filepath.Join(p.Mount, filepath.FromSlash(d))
+		if _, err := os.Stat(full); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(full, 0755); err != nil {
+			r.Errors = append(r.Errors, fmt.Errorf("create %s: %w", d, err))
+			continue
+		}
+		r.FoldersCreated++
+	}
+
+	opts := transfer.ExecuteOptions{
+		Concurrency: p.Transfer.Concurrency,
+		Verify:      p.Transfer.Verify,
+		Manifests:   p.manifests,
+	}
+	if err := transfer.ExecuteWithOptions(ctx, p.backend, p.Plan, opts, progressCh); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		r.Errors = append(r.Errors, err)
+	} else {
+		r.FilesSent = len(p.Plan.Items) - p.Plan.SkipCount
+		r.BytesSent = p.Plan.TotalSize
+	}
+	if err := p.manifests.Save(context.Background(), p.backend); err != nil {
+		r.Errors = append(r.Errors, fmt.Errorf("save manifest: %w", err))
+	}
+
+	if err := p.writeReplayCfg(ctx, r); err != nil {
+		r.Errors = append(r.Errors, err)
+	}
+
+	for _, d := range Skeleton() {
+		if info, err := os.Stat(filepath.Join(p.Mount, filepath.FromSlash(d))); err != nil || !info.IsDir() {
+			r.Missing = append(r.Missing, d)
+		}
+	}
+	st, err := transfer.ReadDeviceStatus(ctx, p.backend)
+	if err != nil {
+		return nil, err
+	}
+	r.Status = st
+	r.Errors = append(r.Errors, st.Errors...)
+	return r, nil
+}
+
+// writeReplayCfg writes the documented defaults when the drive has no
+// replay.cfg and applies the preset, if any. An existing file is kept.
+func (p *Provision) writeReplayCfg(ctx context.Context, r *Report) error {
+	local := filepath.Join(p.Mount, filepath.FromSlash(replaycfg.DevicePath))
+	f, err := replaycfg.ParseFile(local)
+	switch {
+	case err == nil:
+		r.ReplayCfg = "kept"
+	case os.IsNotExist(err):
+		f = replaycfg.Parse([]byte(replaycfg.Documented))
+		r.ReplayCfg = "written"
+	default:
+		return err
+	}
+	if p.Preset != nil && len(p.Preset.Apply(f)) > 0 && r.ReplayCfg == "kept" {
+		r.ReplayCfg = "updated"
+	}
+	if r.ReplayCfgErrs = replaycfg.Validate(f); len(r.ReplayCfgErrs) > 0 {
+		return nil
+	}
+	if r.ReplayCfg == "kept" {
+		return nil
+	}
+	return replaycfg.Push(ctx, p.backend, f)
+}
@@ -0,0 +1,107 @@
+package provision
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
+)
+
+func writeFile(t *testing.T, p, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckMount(t *testing.T) {
+	mount := t.TempDir()
+	os.Mkdir(filepath.Join(mount, "lost+found"), 0755)
+	os.Mkdir(filepath.Join(mount, ".Trash-1000"), 0755)
+	if state, _, err := CheckMount(mount); err != nil || state != MountEmpty {
+		t.Errorf("CheckMount = %v, %v, want empty", state, err)
+	}
+
+	os.Mkdir(filepath.Join(mount, "roms"), 0755)
+	if state, _, _ := CheckMount(mount); state != MountReplayOS {
+		t.Errorf("CheckMount = %v, want ReplayOS", state)
+	}
+
+	writeFile(t, filepath.Join(mount, "holiday.jpg"), "jpg")
+	state, foreign, _ := CheckMount(mount)
+	if state != MountForeign || len(foreign) != 1 || foreign[0] != "holiday.jpg" {
+		t.Errorf("CheckMount = %v %v, want foreign holiday.jpg", state, foreign)
+	}
+	if _, err := Prepare(context.Background(), Options{Mount: mount, LibraryRoot: t.TempDir()}); err == nil {
+		t.Error("Prepare accepted a foreign mount without Force")
+	}
+}
+
+func TestProvision(t *testing.T) {
+	lib := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(lib, "roms", "nintendo_snes", "Mario.sfc"), "mario")
+	writeFile(t, filepath.Join(lib, "roms", "sega_smd", "Sonic.md"), "sonic")
+	writeFile(t, filepath.Join(lib, "roms", "sega_smd", "bios_MD.bin"), "md-bios")
+	writeFile(t, filepath.Join(lib, "bios", "scph5501.bin"), "psx-bios")
+
+	ctx := context.Background()
+	p, err := Prepare(ctx, Options{
+		Mount:       mount,
+		LibraryRoot: lib,
+		Systems:     []string{"nintendo_snes"},
+		Preset:      &replaycfg.BuiltinPresets[1], // LCD 1080p
+	})
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	defer p.Plan.Cleanup()
+	if p.State != MountEmpty || p.BIOS != 1 {
+		t.Errorf("State = %v, loose BIOS = %d", p.State, p.BIOS)
+	}
+
+	r, err := p.Run(ctx, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !r.Complete() {
+		t.Errorf("report not complete: missing %v, cfg errors %v, errors %v", r.Missing, r.ReplayCfgErrs, r.Errors)
+	}
+	if r.FilesSent != 3 || r.ReplayCfg != "written" {
+		t.Errorf("FilesSent = %d, ReplayCfg = %s", r.FilesSent, r.ReplayCfg)
+	}
+	if r.FoldersCreated != len(Skeleton()) {
+		t.Errorf("FoldersCreated = %d, want %d", r.FoldersCreated, len(Skeleton()))
+	}
+
+	for _, p := range []string{
+		"roms/nintendo_snes/Mario.sfc",
+		"bios/scph5501.bin",
+		"bios/bios_MD.bin",
+		"config/settings/game/crt",
+		"bios/dc",
+	} {
+		if _, err := os.Stat(filepath.Join(mount, filepath.FromSlash(p))); err != nil {
+			t.Errorf("%s missing: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(mount, "roms", "sega_smd", "Sonic.md")); !os.IsNotExist(err) {
+		t.Error("unselected system copied")
+	}
+
+	f, err := replaycfg.ParseFile(filepath.Join(mount, "config", "replay.cfg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Get("video_mode"); v != "4" {
+		t.Errorf("video_mode = %q, preset not applied", v)
+	}
+	if r.Status == nil || r.Status.BIOSPresent < 2 {
+		t.Errorf("status = %+v", r.Status)
+	}
+}
@@ -0,0 +1,72 @@
+package replaycfg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+// Fetch downloads replay.cfg from a connected backend, which must
+// implement transfer.Downloader.
+func Fetch(ctx context.Context, backend transfer.TransferBackend) (*File, error) {
+	dl, ok := backend.(transfer.Downloader)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot download files")
+	}
+	tmp, err := os.MkdirTemp("", "romwrangler-replaycfg-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	local := filepath.Join(tmp, "replay.cfg")
+	if err := dl.Download(ctx, DevicePath, local, nil); err != nil {
+		return nil, fmt.Errorf("download %s: %w", DevicePath, err)
+	}
+	return ParseFile(local)
+}
+
+// Push validates f and uploads it over the device's replay.cfg. Uploads
+// are written to a part file and renamed, so the device never sees a
+// half-written file.
+func Push(ctx context.Context, backend transfer.TransferBackend, f *File) error {
+	if errs := Validate(f); len(errs) > 0 {
+		return fmt.Errorf("replay.cfg has %d invalid values, first: %w", len(errs), errs[0])
+	}
+	tmp, err := os.MkdirTemp("", "romwrangler-replaycfg-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	local := filepath.Join(tmp, "replay.cfg")
+	if err := f.WriteFile(local); err != nil {
+		return err
+	}
+	if err := backend.MkdirAll(path.Dir(DevicePath)); err != nil {
+		return err
+	}
+	return backend.Upload(ctx, local, DevicePath, nil)
+}
+
+// KioskKey is the replay.cfg option that locks the ReplayOS frontend.
+const KioskKey = "system_kiosk_mode"
+
+// SetKiosk turns kiosk mode on or off in the device's replay.cfg. The file
+// is only pushed back when the value changes; changed reports whether it
+// did.
+func SetKiosk(ctx context.Context, backend transfer.TransferBackend, on bool) (changed bool, err error) {
+	f, err := Fetch(ctx, backend)
+	if err != nil {
+		return false, err
+	}
+	if !f.Set(KioskKey, strconv.FormatBool(on)) {
+		return false, nil
+	}
+	return true, Push(ctx, backend, f)
+}
@@ -0,0 +1,184 @@
+// Package replaycfg reads, validates and writes ReplayOS's global options
+// file, replay.cfg, keeping its comments and layout intact.
+package replaycfg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DevicePath is where replay.cfg lives relative to the device root
+// (/media/sd/config/replay.cfg on the device).
+const DevicePath = "config/replay.cfg"
+
+// keyColumn is the width keys are padded to in the documented file.
+const keyColumn = 28
+
+// line is one line of the file. Lines that are not key = value pairs are
+// kept verbatim in raw.
+type line struct {
+	raw    string
+	key    string
+	value  string
+	prefix string // everything up to the value, e.g. `video_mode    = `
+	quoted bool
+}
+
+func (l *line) render() string {
+	if l.key == "" {
+		return l.raw
+	}
+	if l.quoted {
+		return l.prefix + `"` + l.value + `"`
+	}
+	return l.prefix + l.value
+}
+
+// File is a parsed replay.cfg. Comments, blank lines, spacing and unknown
+// lines survive a round trip unchanged; only values that are set are
+// rewritten.
+type File struct {
+	lines   []line
+	crlf    bool
+	trailNL bool
+}
+
+// Parse reads replay.cfg content. Lines it cannot read as key = "value" are
+// kept as they are.
+func Parse(data []byte) *File {
+	text := string(data)
+	f := &File{
+		crlf:    strings.Contains(text, "\r\n"),
+		trailNL: strings.HasSuffix(text, "\n"),
+	}
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" && !f.trailNL {
+		return f
+	}
+	for _, raw := range strings.Split(text, "\n") {
+		f.lines = append(f.lines, parseLine(raw))
+	}
+	return f
+}
+
+func parseLine(raw string) line {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return line{raw: raw}
+	}
+	eq := strings.Index(raw, "=")
+	if eq < 0 {
+		return line{raw: raw}
+	}
+	key := strings.TrimSpace(raw[:eq])
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return line{raw: raw}
+	}
+
+	rest := raw[eq+1:]
+	valueStart := eq + 1 + len(rest) - len(strings.TrimLeft(rest, " \t"))
+	value := strings.TrimSpace(rest)
+	l := line{raw: raw, key: key, prefix: raw[:valueStart]}
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		l.value = value[1 : len(value)-1]
+		l.quoted = true
+	} else {
+		l.value = value
+	}
+	return l
+}
+
+// ParseFile reads and parses a replay.cfg from disk.
+func ParseFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// Bytes returns the file content with the current values.
+func (f *File) Bytes() []byte {
+	var b strings.Builder
+	nl := "\n"
+	if f.crlf {
+		nl = "\r\n"
+	}
+	for i := range f.lines {
+		b.WriteString(f.lines[i].render())
+		if i < len(f.lines)-1 || f.trailNL {
+			b.WriteString(nl)
+		}
+	}
+	return []byte(b.String())
+}
+
+// WriteFile writes the file to path.
+func (f *File) WriteFile(path string) error {
+	return os.WriteFile(path, f.Bytes(), 0644)
+}
+
+// Get returns the value of key. When a key appears more than once the last
+// one wins, as it does on the device.
+func (f *File) Get(key string) (string, bool) {
+	for i := len(f.lines) - 1; i >= 0; i-- {
+		if f.lines[i].key == key {
+			return f.lines[i].value, true
+		}
+	}
+	return "", false
+}
+
+// Set changes every occurrence of key to value, or appends the key at the
+// end of the file in the documented layout. It reports whether anything
+// changed.
+func (f *File) Set(key, value string) bool {
+	found, changed := false, false
+	for i := range f.lines {
+		if f.lines[i].key != key {
+			continue
+		}
+		found = true
+		if f.lines[i].value != value {
+			f.lines[i].value = value
+			f.lines[i].quoted = true
+			changed = true
+		}
+	}
+	if !found {
+		f.lines = append(f.lines, line{
+			key:    key,
+			value:  value,
+			prefix: fmt.Sprintf("%-*s= ", keyColumn, key),
+			quoted: true,
+		})
+		f.trailNL = true
+		changed = true
+	}
+	return changed
+}
+
+// Keys returns the keys in file order, each once.
+func (f *File) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, l := range f.lines {
+		if l.key != "" && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Values returns every key with its value.
+func (f *File) Values() map[string]string {
+	values := make(map[string]string)
+	for _, l := range f.lines {
+		if l.key != "" {
+			values[l.key] = l.value
+		}
+	}
+	return values
+}
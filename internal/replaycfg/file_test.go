@@ -0,0 +1,111 @@
+package replaycfg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	for _, in := range []string{
+		Documented,
+		"# comment\r\nvideo_mode = \"4\"\r\n",
+		"video_mode=0\n\n   # indented comment\nnot a setting\n",
+		"no_trailing_newline = \"1\"",
+	} {
+		if out := string(Parse([]byte(in)).Bytes()); out != in {
+			t.Errorf("round trip changed file:\n got %q\nwant %q", out, in)
+		}
+	}
+}
+
+func TestFile_Set(t *testing.T) {
+	f := Parse([]byte("# video_mode\n## 4 = lcd 1920x1080@60\nvideo_mode                  = \"0\"\nvideo_filter=2\n"))
+
+	if !f.Set("video_mode", "4") {
+		t.Error("Set reported no change")
+	}
+	if f.Set("video_mode", "4") {
+		t.Error("Set of the same value reported a change")
+	}
+	f.Set("video_filter", "1")
+	f.Set("audio_card", "1")
+
+	want := "# video_mode\n## 4 = lcd 1920x1080@60\n" +
+		"video_mode                  = \"4\"\n" +
+		"video_filter=\"1\"\n" +
+		"audio_card                  = \"1\"\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("Bytes =\n%s\nwant\n%s", got, want)
+	}
+	if v, _ := f.Get("audio_card"); v != "1" {
+		t.Errorf("Get(audio_card) = %q", v)
+	}
+}
+
+func TestFile_GetLastWins(t *testing.T) {
+	f := Parse([]byte("video_mode = \"1\"\nvideo_mode = \"3\"\n"))
+	if v, _ := f.Get("video_mode"); v != "3" {
+		t.Errorf("Get = %q, want 3", v)
+	}
+	if keys := f.Keys(); len(keys) != 1 {
+		t.Errorf("Keys = %v", keys)
+	}
+}
+
+func TestFetchPush(t *testing.T) {
+	root := t.TempDir()
+	backend := transfer.NewUSBBackend(root)
+	if err := backend.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Push(context.Background(), backend, Parse([]byte(Documented))); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	f, err := Fetch(context.Background(), backend)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if string(f.Bytes()) != Documented {
+		t.Error("fetched file differs from pushed one")
+	}
+
+	f.Set("video_mode", "42")
+	if err := Push(context.Background(), backend, f); err == nil || !strings.Contains(err.Error(), "video_mode") {
+		t.Errorf("Push of invalid file: err = %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "config", "replay.cfg"))
+	if string(data) != Documented {
+		t.Error("invalid file reached the device")
+	}
+}
+
+func TestSetKiosk(t *testing.T) {
+	root := t.TempDir()
+	backend := transfer.NewUSBBackend(root)
+	if err := backend.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Push(context.Background(), backend, Parse([]byte(Documented))); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := SetKiosk(context.Background(), backend, true); err != nil || !changed {
+		t.Fatalf("SetKiosk(true) = %v, %v", changed, err)
+	}
+	if changed, err := SetKiosk(context.Background(), backend, true); err != nil || changed {
+		t.Errorf("second SetKiosk(true) = %v, %v", changed, err)
+	}
+	f, err := Fetch(context.Background(), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Get(KioskKey); v != "true" {
+		t.Errorf("%s = %q", KioskKey, v)
+	}
+}
@@ -0,0 +1,212 @@
+package replaycfg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Preset is a named set of option values that can be applied to a file.
+type Preset struct {
+	Name        string
+	Description string
+	Values      map[string]string
+	Builtin     bool
+}
+
+// BuiltinPresets are always offered alongside the user's own presets.
+var BuiltinPresets = []Preset{
+	{
+		Name:        "CRT 15kHz arcade",
+		Description: "320x240 at native refresh on a 15kHz arcade monitor, full-screen 4:3",
+		Values: map[string]string{
+			"video_mode":               "1",
+			"video_crt_type":           "arcade_15",
+			"video_monitor_multi_mode": "0",
+			"video_aspect_ratio":       "0",
+			"video_filter":             "0",
+		},
+		Builtin: true,
+	},
+	{
+		Name:        "LCD 1080p",
+		Description: "1920x1080 at 60Hz with vertical integer scaling and light scanlines",
+		Values: map[string]string{
+			"video_mode":               "4",
+			"video_lcd_type":           "generic_60",
+			"video_monitor_multi_mode": "0",
+			"video_aspect_ratio":       "2",
+			"video_filter":             "1",
+		},
+		Builtin: true,
+	},
+}
+
+// presetSkipped are option prefixes that describe one device's network
+// rather than how it plays, and are never exported into a preset.
+var presetSkipped = []string{"wifi_", "nfs_", "timezone_srv"}
+
+// presetExt is the extension of preset files in a preset directory.
+const presetExt = ".cfg"
+
+// ExportPreset captures f's options as a preset, leaving out network
+// settings such as Wi-Fi credentials.
+func ExportPreset(name string, f *File) Preset {
+	p := Preset{Name: name, Values: make(map[string]string)}
+	for key, value := range f.Values() {
+		if !skippedInPreset(key) {
+			p.Values[key] = value
+		}
+	}
+	return p
+}
+
+func skippedInPreset(key string) bool {
+	for _, prefix := range presetSkipped {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply sets the preset's values in f and returns the keys that changed,
+// in schema order.
+func (p Preset) Apply(f *File) []string {
+	var changed []string
+	for _, key := range p.keys() {
+		if f.Set(key, p.Values[key]) {
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
+
+// Validate checks the preset's values against the schema.
+func (p Preset) Validate() []error {
+	var errs []error
+	for _, key := range p.keys() {
+		if o, ok := Lookup(key); ok {
+			if err := o.Validate(p.Values[key]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// keys returns the preset's keys in schema order, unknown keys last.
+func (p Preset) keys() []string {
+	order := make(map[string]int, len(Schema))
+	for i, o := range Schema {
+		order[o.Key] = i
+	}
+	keys := make([]string, 0, len(p.Values))
+	for k := range p.Values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, iok := order[keys[i]]
+		oj, jok := order[keys[j]]
+		if iok != jok {
+			return iok
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// Bytes renders the preset in replay.cfg syntax, with its name and
+// description as comments, so it can also be copied onto a device by hand.
+func (p Preset) Bytes() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# preset: %s\n", p.Name)
+	if p.Description != "" {
+		fmt.Fprintf(&b, "# %s\n", p.Description)
+	}
+	for _, key := range p.keys() {
+		fmt.Fprintf(&b, "%-*s= \"%s\"\n", keyColumn, key, p.Values[key])
+	}
+	return []byte(b.String())
+}
+
+// ParsePreset reads a preset written by Bytes. Without a "# preset:"
+// comment the name falls back to fallbackName.
+func ParsePreset(data []byte, fallbackName string) Preset {
+	p := Preset{Name: fallbackName, Values: Parse(data).Values()}
+	for _, raw := range strings.Split(string(data), "\n") {
+		text := strings.TrimSpace(raw)
+		if !strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "#"))
+		if name, ok := strings.CutPrefix(text, "preset:"); ok {
+			p.Name = strings.TrimSpace(name)
+		} else if p.Description == "" {
+			p.Description = text
+		}
+	}
+	return p
+}
+
+// LoadPresets returns the built-in presets followed by the *.cfg presets in
+// dir, sorted by name. A missing dir only yields the built-ins.
+func LoadPresets(dir string) ([]Preset, error) {
+	presets := append([]Preset(nil), BuiltinPresets...)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return presets, nil
+		}
+		return presets, err
+	}
+
+	var user []Preset
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != presetExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return presets, err
+		}
+		user = append(user, ParsePreset(data, strings.TrimSuffix(e.Name(), presetExt)))
+	}
+	sort.Slice(user, func(i, j int) bool { return user[i].Name < user[j].Name })
+	return append(presets, user...), nil
+}
+
+// SavePreset writes p to dir as <name>.cfg and returns the path.
+func SavePreset(dir string, p Preset) (string, error) {
+	name := presetFileName(p.Name)
+	if name == "" {
+		return "", fmt.Errorf("preset needs a name with letters or digits")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name+presetExt)
+	return path, os.WriteFile(path, p.Bytes(), 0644)
+}
+
+// presetFileName turns a preset name into a safe file name:
+// "CRT 15kHz arcade" becomes "crt-15khz-arcade".
+func presetFileName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,71 @@
+package replaycfg
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinPresetsValidate(t *testing.T) {
+	for _, p := range BuiltinPresets {
+		if errs := p.Validate(); len(errs) != 0 {
+			t.Errorf("%s: %v", p.Name, errs)
+		}
+	}
+}
+
+func TestPreset_Apply(t *testing.T) {
+	f := Parse([]byte(Documented))
+	changed := BuiltinPresets[1].Apply(f) // LCD 1080p
+
+	want := []string{"video_mode", "video_aspect_ratio", "video_filter"}
+	if len(changed) != len(want) {
+		t.Fatalf("changed = %v, want %v", changed, want)
+	}
+	for i := range want {
+		if changed[i] != want[i] {
+			t.Errorf("changed = %v, want %v", changed, want)
+		}
+	}
+	if v, _ := f.Get("video_mode"); v != "4" {
+		t.Errorf("video_mode = %q", v)
+	}
+}
+
+func TestPreset_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	f := Parse([]byte(Documented))
+	f.Set("video_gamma", "1.2")
+	f.Set("wifi_pwd", "secret")
+
+	p := ExportPreset("My CRT / living room", f)
+	if _, ok := p.Values["wifi_pwd"]; ok {
+		t.Error("Wi-Fi password exported into preset")
+	}
+	p.Description = "Sony Trinitron"
+	path, err := SavePreset(dir, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "my-crt-living-room.cfg" {
+		t.Errorf("saved as %s", path)
+	}
+
+	presets, err := LoadPresets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) != len(BuiltinPresets)+1 {
+		t.Fatalf("loaded %d presets", len(presets))
+	}
+	got := presets[len(presets)-1]
+	if got.Name != "My CRT / living room" || got.Description != "Sony Trinitron" || got.Builtin {
+		t.Errorf("loaded preset = %q %q builtin=%v", got.Name, got.Description, got.Builtin)
+	}
+	if got.Values["video_gamma"] != "1.2" || len(got.Values) != len(p.Values) {
+		t.Errorf("values = %v", got.Values)
+	}
+
+	if _, err := SavePreset(dir, Preset{Name: "!!"}); err == nil {
+		t.Error("saved a preset without a usable name")
+	}
+}
@@ -0,0 +1,265 @@
+package replaycfg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of value an option takes.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt         // any integer
+	KindBool        // "true" or "false"
+	KindEnum        // one of Choices, or an integer in one of Ranges
+	KindRange       // a number between Min and Max
+)
+
+// Choice is one documented value of an enum option.
+type Choice struct {
+	Value string
+	Label string
+}
+
+// IntRange is a documented span of integer values, e.g. "11-36 = for
+// custom user skins".
+type IntRange struct {
+	Min, Max int
+	Label    string
+}
+
+// Option describes one replay.cfg key.
+type Option struct {
+	Key         string
+	Kind        Kind
+	Default     string
+	Description string // free-text "##" lines and the note after the "#" header
+	Choices     []Choice
+	Ranges      []IntRange
+	Min, Max    float64 // KindRange
+	Float       bool    // KindRange accepts fractions
+}
+
+// Group is the option's prefix ("video", "audio", "input", ...).
+func (o Option) Group() string {
+	g, _, _ := strings.Cut(o.Key, "_")
+	return g
+}
+
+// Label returns the documented meaning of value, if any.
+func (o Option) Label(value string) string {
+	for _, c := range o.Choices {
+		if c.Value == value {
+			return c.Label
+		}
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		for _, r := range o.Ranges {
+			if n >= r.Min && n <= r.Max {
+				return r.Label
+			}
+		}
+	}
+	return ""
+}
+
+// Allowed lists the values to offer for enum and bool options.
+func (o Option) Allowed() []string {
+	if o.Kind == KindBool {
+		return []string{"false", "true"}
+	}
+	var values []string
+	for _, c := range o.Choices {
+		values = append(values, c.Value)
+	}
+	for _, r := range o.Ranges {
+		for n := r.Min; n <= r.Max; n++ {
+			values = append(values, strconv.Itoa(n))
+		}
+	}
+	return values
+}
+
+// Validate checks value against the documented values or range.
+func (o Option) Validate(value string) error {
+	switch o.Kind {
+	case KindBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s: %q is not true or false", o.Key, value)
+		}
+	case KindEnum:
+		if !o.hasChoice(value) && !o.inRanges(value) {
+			return fmt.Errorf("%s: %q is not one of %s", o.Key, value, strings.Join(o.Allowed(), ", "))
+		}
+	case KindRange:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || (!o.Float && strings.ContainsAny(value, ".eE")) {
+			return fmt.Errorf("%s: %q is not a number", o.Key, value)
+		}
+		if n < o.Min || n > o.Max {
+			return fmt.Errorf("%s: %s is outside %s to %s", o.Key, value, formatNum(o.Min), formatNum(o.Max))
+		}
+	case KindInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s: %q is not a whole number", o.Key, value)
+		}
+	case KindString:
+		if strings.ContainsAny(value, "\"\n") {
+			return fmt.Errorf("%s: value may not contain quotes or line breaks", o.Key)
+		}
+	}
+	return nil
+}
+
+func (o Option) hasChoice(value string) bool {
+	for _, c := range o.Choices {
+		if c.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Option) inRanges(value string) bool {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	for _, r := range o.Ranges {
+		if n >= r.Min && n <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNum(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Schema lists every documented option in file order.
+var Schema = buildSchema(Documented)
+
+// Lookup returns the option for key.
+func Lookup(key string) (Option, bool) {
+	for _, o := range Schema {
+		if o.Key == key {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
+
+// Validate checks every documented key in f. Unknown keys are left alone:
+// newer ReplayOS releases may add options.
+func Validate(f *File) []error {
+	var errs []error
+	for _, key := range f.Keys() {
+		o, ok := Lookup(key)
+		if !ok {
+			continue
+		}
+		v, _ := f.Get(key)
+		if err := o.Validate(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+var (
+	rangeRe    = regexp.MustCompile(`^values\s*=\s*(-?[\d.]+)\s*<-->\s*(-?[\d.]+)$`)
+	intRangeRe = regexp.MustCompile(`^(\d+)-(\d+)\s*=\s*(.*)$`)
+	// A bare value, optionally followed by a note in parentheses:
+	// "arcade_31 (also used for PC)". Capitalized words such as "NRR (Native
+	// Refresh Rate)" are legends, not values.
+	bareRe = regexp.MustCompile(`^([a-z0-9_.]+)(?:\s+\((.*)\))?$`)
+)
+
+// buildSchema derives options from the documented file: a "# key" header
+// followed by "##" lines of values, ranges or description, then the key's
+// default. Headers are matched by name, since one (audio_card) is not
+// directly above its key.
+func buildSchema(doc string) []Option {
+	headers := make(map[string]*Option)
+	var current *Option
+	var schema []Option
+
+	for _, raw := range strings.Split(doc, "\n") {
+		text := strings.TrimSpace(raw)
+		switch {
+		case strings.HasPrefix(text, "##"):
+			if current != nil {
+				addDocLine(current, strings.TrimSpace(strings.TrimPrefix(text, "##")))
+			}
+		case strings.HasPrefix(text, "#"):
+			key, note, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(text, "#")), " ")
+			current = &Option{Key: key, Description: strings.Trim(note, " ()")}
+			headers[key] = current
+		default:
+			l := parseLine(raw)
+			if l.key == "" {
+				continue
+			}
+			o := Option{Key: l.key}
+			if h, ok := headers[l.key]; ok {
+				o = *h
+			}
+			o.Default = l.value
+			o.Kind = kindOf(o)
+			schema = append(schema, o)
+		}
+	}
+	return schema
+}
+
+func addDocLine(o *Option, text string) {
+	if m := rangeRe.FindStringSubmatch(text); m != nil {
+		o.Min, _ = strconv.ParseFloat(m[1], 64)
+		o.Max, _ = strconv.ParseFloat(m[2], 64)
+		o.Float = strings.Contains(m[1]+m[2], ".")
+		o.Kind = KindRange
+		return
+	}
+	if m := intRangeRe.FindStringSubmatch(text); m != nil {
+		lo, _ := strconv.Atoi(m[1])
+		hi, _ := strconv.Atoi(m[2])
+		o.Ranges = append(o.Ranges, IntRange{Min: lo, Max: hi, Label: m[3]})
+		return
+	}
+	if value, label, ok := strings.Cut(text, "="); ok && !strings.ContainsAny(strings.TrimSpace(value), " ") {
+		o.Choices = append(o.Choices, Choice{Value: strings.TrimSpace(value), Label: strings.TrimSpace(label)})
+		return
+	}
+	if m := bareRe.FindStringSubmatch(text); m != nil {
+		o.Choices = append(o.Choices, Choice{Value: m[1], Label: m[2]})
+		return
+	}
+	if o.Description != "" {
+		o.Description += "; "
+	}
+	o.Description += text
+}
+
+// kindOf settles an option's kind once its doc lines and default are known.
+func kindOf(o Option) Kind {
+	if o.Kind == KindRange {
+		return KindRange
+	}
+	if len(o.Choices) > 0 || len(o.Ranges) > 0 {
+		if len(o.Choices) == 2 && o.hasChoice("true") && o.hasChoice("false") {
+			return KindBool
+		}
+		return KindEnum
+	}
+	if o.Default == "true" || o.Default == "false" {
+		return KindBool
+	}
+	if _, err := strconv.Atoi(o.Default); err == nil {
+		return KindInt
+	}
+	return KindString
+}
@@ -0,0 +1,78 @@
+package replaycfg
+
+import "testing"
+
+func TestSchema(t *testing.T) {
+	if len(Schema) != 68 {
+		t.Errorf("Schema has %d options, want 68", len(Schema))
+	}
+	if errs := Validate(Parse([]byte(Documented))); len(errs) != 0 {
+		t.Errorf("documented defaults do not validate: %v", errs)
+	}
+
+	tests := []struct {
+		key  string
+		kind Kind
+	}{
+		{"video_mode", KindEnum},
+		{"video_crt_type", KindEnum},
+		{"video_crt_h_shift", KindRange},
+		{"video_gamma", KindRange},
+		{"video_show_fps", KindBool},
+		{"input_kbd_real_mode", KindBool},
+		{"audio_card", KindEnum}, // header is not directly above the key
+		{"system_coinop_time", KindInt},
+		{"timezone_srv", KindString},
+	}
+	for _, tt := range tests {
+		o, ok := Lookup(tt.key)
+		if !ok {
+			t.Errorf("%s missing from schema", tt.key)
+			continue
+		}
+		if o.Kind != tt.kind {
+			t.Errorf("%s kind = %d, want %d", tt.key, o.Kind, tt.kind)
+		}
+	}
+
+	if o, _ := Lookup("video_mode"); o.Label("4") != "lcd 1920x1080@60" || o.hasChoice("NRR") {
+		t.Errorf("video_mode choices = %+v", o.Choices)
+	}
+	if o, _ := Lookup("video_crt_type"); o.Label("arcade_31") != "also used for PC" {
+		t.Errorf("video_crt_type choices = %+v", o.Choices)
+	}
+}
+
+func TestOption_Validate(t *testing.T) {
+	tests := []struct {
+		key, value string
+		ok         bool
+	}{
+		{"video_mode", "9", true},
+		{"video_mode", "10", false},
+		{"video_crt_type", "arcade_15_25", true},
+		{"video_crt_type", "arcade_25", false},
+		{"system_skin", "10", true},
+		{"system_skin", "24", true}, // custom skins 11-36
+		{"system_skin", "37", false},
+		{"view_players", "6", true},
+		{"view_players", "7", false},
+		{"video_crt_h_shift", "-16", true},
+		{"video_crt_h_shift", "17", false},
+		{"video_crt_h_shift", "1.5", false},
+		{"video_gamma", "0.75", true},
+		{"video_gamma", "1.6", false},
+		{"audio_system_volume", "abc", false},
+		{"video_show_fps", "true", true},
+		{"video_show_fps", "yes", false},
+		{"system_coinop_time", "300", true},
+		{"system_coinop_time", "3m", false},
+		{"wifi_name", `my "wifi"`, false},
+	}
+	for _, tt := range tests {
+		o, _ := Lookup(tt.key)
+		if err := o.Validate(tt.value); (err == nil) != tt.ok {
+			t.Errorf("Validate(%s=%q) = %v, want ok=%v", tt.key, tt.value, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package scraper
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ClrMamePro text DAT format:
+//
+//	clrmamepro (
+//		name "Nintendo - Nintendo Entertainment System"
+//	)
+//	game (
+//		name "Super Mario Bros. (World)"
+//		rom ( name "Super Mario Bros. (World).nes" size 40976 crc 3337EC46 sha1 ... )
+//	)
+//
+// Each block is a keyword followed by parenthesized key/value pairs, which
+// may nest further blocks. MAME and FBNeo exports use "machine" or
+// "resource" in place of "game".
+
+// cmpBlock is one parenthesized block. Repeated keys keep the first value.
+type cmpBlock struct {
+	fields   map[string]string
+	children []cmpChild
+}
+
+type cmpChild struct {
+	key   string
+	block *cmpBlock
+}
+
+// cmpTokenizer splits a ClrMamePro DAT into parentheses, quoted strings
+// and bare words.
+type cmpTokenizer struct {
+	r    *bufio.Reader
+	line int
+}
+
+type cmpToken struct {
+	text   string
+	quoted bool
+}
+
+func (t *cmpToken) is(s string) bool { return !t.quoted && t.text == s }
+
+// next returns the next token, or io.EOF at the end of input.
+func (t *cmpTokenizer) next() (cmpToken, error) {
+	for {
+		c, _, err := t.r.ReadRune()
+		if err != nil {
+			return cmpToken{}, err
+		}
+		switch {
+		case c == '\n':
+			t.line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '(' || c == ')':
+			return cmpToken{text: string(c)}, nil
+		case c == '"':
+			return t.quoted()
+		default:
+			t.r.UnreadRune()
+			return t.word()
+		}
+	}
+}
+
+func (t *cmpTokenizer) quoted() (cmpToken, error) {
+	var sb strings.Builder
+	for {
+		c, _, err := t.r.ReadRune()
+		if err == io.EOF {
+			return cmpToken{}, fmt.Errorf("line %d: unterminated string", t.line+1)
+		}
+		if err != nil {
+			return cmpToken{}, err
+		}
+		switch c {
+		case '"':
+			return cmpToken{text: sb.String(), quoted: true}, nil
+		case '\\':
+			// Only \" and \\ are escapes; a lone backslash is kept, as in
+			// Windows paths.
+			if n, _, err := t.r.ReadRune(); err == nil {
+				if n != '"' && n != '\\' {
+					sb.WriteRune(c)
+				}
+				if n == '\n' {
+					t.line++
+				}
+				sb.WriteRune(n)
+			}
+		case '\n':
+			t.line++
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+}
+
+func (t *cmpTokenizer) word() (cmpToken, error) {
+	var sb strings.Builder
+	for {
+		c, _, err := t.r.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return cmpToken{}, err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '(' || c == ')' || c == '"' {
+			t.r.UnreadRune()
+			break
+		}
+		sb.WriteRune(c)
+	}
+	return cmpToken{text: sb.String()}, nil
+}
+
+// block reads the contents of a block up to and including its closing
+// parenthesis; the opening one has already been read.
+func (t *cmpTokenizer) block() (*cmpBlock, error) {
+	b := &cmpBlock{fields: make(map[string]string)}
+	for {
+		tok, err := t.next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("line %d: unexpected end of file, missing )", t.line+1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tok.is(")") {
+			return b, nil
+		}
+		if tok.is("(") {
+			return nil, fmt.Errorf("line %d: unexpected (", t.line+1)
+		}
+
+		key := strings.ToLower(tok.text)
+		val, err := t.next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("line %d: unexpected end of file after %s", t.line+1, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case val.is("("):
+			child, err := t.block()
+			if err != nil {
+				return nil, err
+			}
+			b.children = append(b.children, cmpChild{key: key, block: child})
+		case val.is(")"):
+			// A key with no value closes the block.
+			return b, nil
+		default:
+			if _, ok := b.fields[key]; !ok {
+				b.fields[key] = val.text
+			}
+		}
+	}
+}
+
+// parseClrMamePro parses a ClrMamePro text DAT into an index.
+func parseClrMamePro(r io.Reader) (*DATIndex, error) {
+	t := &cmpTokenizer{r: bufio.NewReader(r)}
+	idx := newDATIndex("")
+	games := 0
+	for {
+		tok, err := t.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tok.quoted || tok.is("(") || tok.is(")") {
+			return nil, fmt.Errorf("line %d: expected a block name, got %q", t.line+1, tok.text)
+		}
+		key := strings.ToLower(tok.text)
+		open, err := t.next()
+		if err != nil || !open.is("(") {
+			return nil, fmt.Errorf("line %d: expected ( after %s", t.line+1, key)
+		}
+		b, err := t.block()
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "clrmamepro":
+			idx.Name = b.fields["name"]
+		case "game", "machine", "resource":
+			games++
+			for _, c := range b.children {
+				if c.key != "rom" && c.key != "disk" {
+					continue
+				}
+				f := c.block.fields
+				idx.add(&DATEntry{
+					GameName: b.fields["name"],
+					ROMName:  f["name"],
+					CRC:      f["crc"],
+					MD5:      f["md5"],
+					SHA1:     f["sha1"],
+					Size:     parseDATSize(f["size"]),
+					Disk:     c.key == "disk",
+				})
+			}
+		}
+	}
+	if games == 0 {
+		return nil, fmt.Errorf("no games found in ClrMamePro DAT")
+	}
+	return idx, nil
+}
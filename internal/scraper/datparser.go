@@ -1,10 +1,17 @@
 package scraper
 
 import (
+	"bufio"
 	"encoding/xml"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/systems"
 )
 
 // Logiqx XML DAT format structures
@@ -20,9 +27,10 @@ type datHeader struct {
 }
 
 type datGame struct {
-	Name        string   `xml:"name,attr"`
-	Description string   `xml:"description"`
-	ROMs        []datROM `xml:"rom"`
+	Name        string    `xml:"name,attr"`
+	Description string    `xml:"description"`
+	ROMs        []datROM  `xml:"rom"`
+	Disks       []datDisk `xml:"disk"`
 }
 
 type datROM struct {
@@ -34,24 +42,40 @@ type datROM struct {
 	Status string `xml:"status,attr"`
 }
 
+// datDisk is a CHD in MAME-style DATs, listed by its data SHA1.
+type datDisk struct {
+	Name string `xml:"name,attr"`
+	MD5  string `xml:"md5,attr"`
+	SHA1 string `xml:"sha1,attr"`
+}
+
 // DATIndex provides hash-based lookup into a parsed DAT file.
 type DATIndex struct {
-	Name   string
+	Name string
+	// System is the platform the DAT's name identifies, or empty when it
+	// is not a known No-Intro or Redump platform.
+	System systems.SystemID
 	ByCRC  map[string]*DATEntry
 	ByMD5  map[string]*DATEntry
 	BySHA1 map[string]*DATEntry
+	// ByGame lists each game's ROMs and disks in DAT order.
+	ByGame map[string][]*DATEntry
 }
 
 // DATEntry is a single ROM entry from a DAT file.
 type DATEntry struct {
-	GameName    string
-	ROMName     string
-	CRC         string
-	MD5         string
-	SHA1        string
+	GameName string
+	ROMName  string
+	CRC      string
+	MD5      string
+	SHA1     string
+	Size     int64 // 0 when the DAT does not list it
+	// Disk marks a CHD entry, whose SHA1 is the one stored in the CHD
+	// header rather than a hash of the file.
+	Disk bool
 }
 
-// ParseDAT parses a Logiqx XML DAT file and builds an index.
+// ParseDAT parses a Logiqx XML or ClrMamePro DAT file and builds an index.
 func ParseDAT(path string) (*DATIndex, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -61,48 +85,171 @@ func ParseDAT(path string) (*DATIndex, error) {
 	return ParseDATReader(f)
 }
 
-// ParseDATReader parses a DAT file from a reader.
+// ParseDATReader parses a DAT file from a reader. The format is sniffed
+// from the first non-blank character: '<' is Logiqx XML, anything else is
+// read as ClrMamePro text.
 func ParseDATReader(r io.Reader) (*DATIndex, error) {
+	br := bufio.NewReader(r)
+	if bom, _ := br.Peek(3); string(bom) == "\xEF\xBB\xBF" {
+		br.Discard(3)
+	}
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil, fmt.Errorf("empty DAT file")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+		br.UnreadByte()
+		var idx *DATIndex
+		if c == '<' {
+			idx, err = parseLogiqx(br)
+		} else {
+			idx, err = parseClrMamePro(br)
+		}
+		if err != nil {
+			return nil, err
+		}
+		idx.System, _ = DATNameToSystemID(idx.Name)
+		return idx, nil
+	}
+}
+
+// parseLogiqx parses a Logiqx XML DAT.
+func parseLogiqx(r io.Reader) (*DATIndex, error) {
 	var dat datFile
 	decoder := xml.NewDecoder(r)
 	if err := decoder.Decode(&dat); err != nil {
 		return nil, err
 	}
 
-	idx := &DATIndex{
-		Name:   dat.Header.Name,
+	idx := newDATIndex(dat.Header.Name)
+	for _, game := range dat.Games {
+		for _, rom := range game.ROMs {
+			idx.add(&DATEntry{GameName: game.Name, ROMName: rom.Name, CRC: rom.CRC, MD5: rom.MD5, SHA1: rom.SHA1, Size: parseDATSize(rom.Size)})
+		}
+		for _, disk := range game.Disks {
+			idx.add(&DATEntry{GameName: game.Name, ROMName: disk.Name, MD5: disk.MD5, SHA1: disk.SHA1, Disk: true})
+		}
+	}
+	return idx, nil
+}
+
+// parseDATSize reads a ROM's size attribute, treating a missing or
+// malformed one as unknown.
+func parseDATSize(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func newDATIndex(name string) *DATIndex {
+	return &DATIndex{
+		Name:   name,
 		ByCRC:  make(map[string]*DATEntry),
 		ByMD5:  make(map[string]*DATEntry),
 		BySHA1: make(map[string]*DATEntry),
+		ByGame: make(map[string][]*DATEntry),
 	}
+}
 
-	for _, game := range dat.Games {
-		for _, rom := range game.ROMs {
-			entry := &DATEntry{
-				GameName: game.Name,
-				ROMName:  rom.Name,
-				CRC:      strings.ToUpper(rom.CRC),
-				MD5:      strings.ToLower(rom.MD5),
-				SHA1:     strings.ToLower(rom.SHA1),
-			}
+// add indexes one ROM or disk of a game by each hash it has.
+func (idx *DATIndex) add(entry *DATEntry) {
+	entry.CRC = strings.ToUpper(entry.CRC)
+	entry.MD5 = strings.ToLower(entry.MD5)
+	entry.SHA1 = strings.ToLower(entry.SHA1)
+	idx.ByGame[entry.GameName] = append(idx.ByGame[entry.GameName], entry)
 
-			if entry.CRC != "" {
-				idx.ByCRC[entry.CRC] = entry
+	if entry.CRC != "" {
+		idx.ByCRC[entry.CRC] = entry
+	}
+	if entry.MD5 != "" {
+		idx.ByMD5[entry.MD5] = entry
+	}
+	if entry.SHA1 != "" {
+		idx.BySHA1[entry.SHA1] = entry
+	}
+}
+
+// LoadDATDirs parses every .dat and .xml file under dirs, in either
+// format. Files that fail to parse are reported in errs and skipped.
+func LoadDATDirs(dirs []string) (indices []*DATIndex, errs []error) {
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
 			}
-			if entry.MD5 != "" {
-				idx.ByMD5[entry.MD5] = entry
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".dat":
+			case ".xml":
+				if isHeaderSkipperFile(path) {
+					return nil // see LoadHeaderSkippers
+				}
+			default:
+				return nil
 			}
-			if entry.SHA1 != "" {
-				idx.BySHA1[entry.SHA1] = entry
+			idx, err := ParseDAT(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				return nil
 			}
+			if idx.Name == "" {
+				idx.Name = strings.TrimSuffix(d.Name(), filepath.Ext(path))
+				idx.System, _ = DATNameToSystemID(idx.Name)
+			}
+			indices = append(indices, idx)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
 		}
 	}
+	return indices, errs
+}
 
-	return idx, nil
+// DATMatch is a DAT entry found for a file and which of its hashes found it.
+type DATMatch struct {
+	Entry *DATEntry
+	// Headerless is set when the file matched with its copier header
+	// skipped, as No-Intro hashes NES, FDS, Lynx and 7800 ROMs.
+	Headerless bool
+}
+
+// Match looks the file up by its headerless hashes first, when it has a
+// copier header, then by the hashes of the whole file.
+func (idx *DATIndex) Match(hashes FileHashes) (DATMatch, bool) {
+	if h := hashes.Headerless; h != nil {
+		if entry, ok := idx.lookup(*h); ok {
+			return DATMatch{Entry: entry, Headerless: true}, true
+		}
+	}
+	if entry, ok := idx.lookup(hashes); ok {
+		return DATMatch{Entry: entry}, true
+	}
+	return DATMatch{}, false
 }
 
-// Lookup tries to find a match by SHA1, then MD5, then CRC32.
+// Lookup tries to find a match by SHA1, then MD5, then CRC32, trying the
+// headerless hashes before the whole file's. See Match for which matched.
 func (idx *DATIndex) Lookup(hashes FileHashes) (*DATEntry, bool) {
+	m, ok := idx.Match(hashes)
+	return m.Entry, ok
+}
+
+func (idx *DATIndex) lookup(hashes FileHashes) (*DATEntry, bool) {
 	if hashes.SHA1 != "" {
 		if entry, ok := idx.BySHA1[strings.ToLower(hashes.SHA1)]; ok {
 			return entry, true
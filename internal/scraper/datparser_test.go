@@ -1,6 +1,8 @@
 package scraper
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -51,6 +53,9 @@ func TestDATIndex_Lookup_ByCRC(t *testing.T) {
 	if entry.GameName != "Super Mario Bros. (World)" {
 		t.Errorf("unexpected game name: %s", entry.GameName)
 	}
+	if entry.Size != 40976 {
+		t.Errorf("size = %d, want 40976", entry.Size)
+	}
 }
 
 func TestDATIndex_Lookup_ByMD5(t *testing.T) {
@@ -106,3 +111,83 @@ func TestDATIndex_Lookup_SHA1Priority(t *testing.T) {
 		t.Errorf("expected SHA1 to take priority, got: %s", entry.GameName)
 	}
 }
+
+const sampleCMPDAT = `clrmamepro (
+	name "Test CMP DAT"
+	description "Test ClrMamePro DAT"
+	version 20240101
+)
+
+game (
+	name "Super Mario Bros. (World)"
+	description "Super Mario Bros. (World)"
+	rom ( name "Super Mario Bros. (World).nes" size 40976 crc 3337EC46 md5 811B027EAF99C2DEF7B933C5208636DE sha1 FACEE9C577A5262DBE33B8370E8882C37EA48E2E )
+)
+
+machine (
+	name "pacman"
+	description "Pac-Man (Midway)"
+	rom ( name pacman.6e size 4096 crc c1e6ab10 sha1 e87e059c5be45753f7e9f33dff851f16d6751181 )
+	rom ( name pacman.6f size 4096 crc 1a6fb2d4 sha1 674d3a7f00d8be5e38b1fdc208ebef5a92d38329 flags baddump )
+	disk ( name "pacman-disk" sha1 0000000000000000000000000000000000000000 )
+)
+`
+
+func TestParseDATReader_ClrMamePro(t *testing.T) {
+	idx, err := ParseDATReader(strings.NewReader("\xEF\xBB\xBF\n" + sampleCMPDAT))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if idx.Name != "Test CMP DAT" {
+		t.Errorf("expected name 'Test CMP DAT', got %q", idx.Name)
+	}
+	if len(idx.ByCRC) != 3 || len(idx.BySHA1) != 4 || len(idx.ByMD5) != 1 {
+		t.Errorf("indexed %d CRC, %d SHA1, %d MD5 entries, want 3, 4 (with the disk), 1", len(idx.ByCRC), len(idx.BySHA1), len(idx.ByMD5))
+	}
+
+	entry, ok := idx.Lookup(FileHashes{MD5: "811b027eaf99c2def7b933c5208636de"})
+	if !ok || entry.GameName != "Super Mario Bros. (World)" || entry.ROMName != "Super Mario Bros. (World).nes" || entry.Size != 40976 {
+		t.Errorf("MD5 lookup = %+v, %v", entry, ok)
+	}
+	entry, ok = idx.Lookup(FileHashes{CRC32: "1A6FB2D4"})
+	if !ok || entry.GameName != "pacman" || entry.ROMName != "pacman.6f" {
+		t.Errorf("CRC lookup = %+v, %v", entry, ok)
+	}
+}
+
+func TestParseDATReader_ClrMameProErrors(t *testing.T) {
+	for _, dat := range []string{
+		"",
+		`game ( name "Unclosed"`,
+		`game ( name "Unterminated )`,
+		"just some text",
+	} {
+		if _, err := ParseDATReader(strings.NewReader(dat)); err == nil {
+			t.Errorf("ParseDATReader(%q) succeeded", dat)
+		}
+	}
+}
+
+func TestLoadDATDirs(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "redump"), 0755)
+	os.WriteFile(filepath.Join(dir, "nointro.xml"), []byte(sampleDAT), 0644)
+	os.WriteFile(filepath.Join(dir, "redump", "Nameless.dat"), []byte(`game ( name "A" rom ( name a.bin crc 12345678 ) )`), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.dat"), []byte("not a dat"), 0644)
+	os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("ignored"), 0644)
+
+	indices, errs := LoadDATDirs([]string{dir})
+	if len(indices) != 2 {
+		t.Fatalf("loaded %d DATs, want 2", len(indices))
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken.dat") {
+		t.Errorf("errs = %v, want one for broken.dat", errs)
+	}
+	names := map[string]bool{}
+	for _, idx := range indices {
+		names[idx.Name] = true
+	}
+	if !names["Test DAT"] || !names["Nameless"] {
+		t.Errorf("names = %v", names)
+	}
+}
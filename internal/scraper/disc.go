@@ -0,0 +1,242 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/converter"
+)
+
+// DiscResult is how a disc set compares with its DAT game.
+type DiscResult int
+
+const (
+	DiscMiss    DiscResult = iota // no file matches any DAT game
+	DiscPartial                   // some files match; others differ or are missing
+	DiscFull                      // every file matches and none is missing
+)
+
+func (r DiscResult) String() string {
+	switch r {
+	case DiscFull:
+		return "full"
+	case DiscPartial:
+		return "partial"
+	default:
+		return "miss"
+	}
+}
+
+// DiscTrack is one file of a disc set: the cue or gdi sheet or a track.
+type DiscTrack struct {
+	Path   string
+	Hashes FileHashes
+	// ROMName is the DAT entry the file was compared with: the one of the
+	// same name, or the one its hashes match when it was renamed.
+	ROMName string
+	Matched bool
+	Absent  bool // referenced by the sheet but not on disk
+	// Skipped marks a sheet the DAT game does not list, such as a .gdi
+	// checked against a DAT with Redump-style cues; it does not count.
+	Skipped bool
+}
+
+// DiscMatch is the result of matching a cue or gdi set against the DATs.
+type DiscMatch struct {
+	Result DiscResult
+	DAT    string // name of the DAT the game is from
+	Game   string // the DAT game, unless Result is DiscMiss
+	// Tracks are every file of the set, the sheet first.
+	Tracks []DiscTrack
+	// Missing are the game's ROMs no file of the set accounts for.
+	Missing []string
+}
+
+// Differ returns the files that were compared with a DAT entry and did
+// not match it, including absent ones.
+func (m *DiscMatch) Differ() []DiscTrack {
+	var out []DiscTrack
+	for _, t := range m.Tracks {
+		if !t.Matched && !t.Skipped && t.ROMName != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// MatchDisc hashes every file of the cue or gdi set at sheetPath and
+// matches the whole set against a DAT game, as Redump lists a disc: the
+// sheet plus one entry per track. The game is the one most of the files
+// match; each file is then compared with the game's entry of the same
+// name, or any entry its hashes match.
+func (id *Identifier) MatchDisc(ctx context.Context, sheetPath string) (*DiscMatch, error) {
+	files, err := converter.CompanionFiles(sheetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &DiscMatch{}
+	for _, f := range files {
+		t := DiscTrack{Path: f}
+		h, err := HashFileHeaders(ctx, f, nil)
+		switch {
+		case err == nil:
+			t.Hashes = h
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case os.IsNotExist(err):
+			t.Absent = true
+		default:
+			return nil, err
+		}
+		m.Tracks = append(m.Tracks, t)
+	}
+
+	idx, game := id.discGame(m.Tracks)
+	if idx == nil {
+		return m, nil
+	}
+	m.DAT, m.Game = idx.Name, game
+	var roms []*DATEntry
+	for _, e := range idx.ByGame[game] {
+		if !e.Disk {
+			roms = append(roms, e)
+		}
+	}
+
+	used := make(map[*DATEntry]bool)
+	// Files compared with the entry of the same name.
+	for i := range m.Tracks {
+		t := &m.Tracks[i]
+		for _, e := range roms {
+			if used[e] || !strings.EqualFold(romBase(e.ROMName), filepath.Base(t.Path)) {
+				continue
+			}
+			used[e] = true
+			t.ROMName = e.ROMName
+			t.Matched = !t.Absent && e.matches(t.Hashes)
+			break
+		}
+	}
+	// Renamed files, by their hashes.
+	for i := range m.Tracks {
+		t := &m.Tracks[i]
+		if t.ROMName != "" || t.Absent {
+			continue
+		}
+		for _, e := range roms {
+			if !used[e] && e.matches(t.Hashes) {
+				used[e] = true
+				t.ROMName = e.ROMName
+				t.Matched = true
+				break
+			}
+		}
+	}
+
+	// A renamed sheet that differs is compared with the game's only sheet.
+	sheet := &m.Tracks[0]
+	if sheet.ROMName == "" && !sheet.Absent {
+		var same []*DATEntry
+		for _, e := range roms {
+			if !used[e] && strings.EqualFold(path.Ext(e.ROMName), filepath.Ext(sheet.Path)) {
+				same = append(same, e)
+			}
+		}
+		switch len(same) {
+		case 0:
+			sheet.Skipped = !hasExt(roms, filepath.Ext(sheet.Path))
+		case 1:
+			used[same[0]] = true
+			sheet.ROMName = same[0].ROMName
+		}
+	}
+	for _, e := range roms {
+		if !used[e] {
+			m.Missing = append(m.Missing, e.ROMName)
+		}
+	}
+
+	matched, counted := 0, 0
+	for _, t := range m.Tracks {
+		if t.Skipped {
+			continue
+		}
+		counted++
+		if t.Matched {
+			matched++
+		}
+	}
+	switch {
+	case matched == counted && len(m.Missing) == 0:
+		m.Result = DiscFull
+	case matched > 0:
+		m.Result = DiscPartial
+	}
+	return m, nil
+}
+
+// discGame returns the DAT game most of the tracks' hashes point at.
+func (id *Identifier) discGame(tracks []DiscTrack) (*DATIndex, string) {
+	type key struct {
+		idx  *DATIndex
+		game string
+	}
+	counts := make(map[key]int)
+	var order []key
+	for _, t := range tracks {
+		if t.Absent {
+			continue
+		}
+		for _, idx := range id.datIndices {
+			e, ok := idx.lookup(t.Hashes)
+			if !ok || e.Disk {
+				continue
+			}
+			k := key{idx, e.GameName}
+			if counts[k] == 0 {
+				order = append(order, k)
+			}
+			counts[k]++
+		}
+	}
+	var best key
+	for _, k := range order {
+		if counts[k] > counts[best] {
+			best = k
+		}
+	}
+	return best.idx, best.game
+}
+
+// matches reports whether h are the hashes the entry lists, comparing the
+// strongest hash both have.
+func (e *DATEntry) matches(h FileHashes) bool {
+	switch {
+	case e.SHA1 != "" && h.SHA1 != "":
+		return strings.EqualFold(e.SHA1, h.SHA1)
+	case e.MD5 != "" && h.MD5 != "":
+		return strings.EqualFold(e.MD5, h.MD5)
+	case e.CRC != "" && h.CRC32 != "":
+		return strings.EqualFold(e.CRC, h.CRC32)
+	}
+	return false
+}
+
+// romBase is the file name of a DAT ROM name, which may carry a
+// Windows-style folder.
+func romBase(name string) string {
+	return path.Base(strings.ReplaceAll(name, "\\", "/"))
+}
+
+func hasExt(roms []*DATEntry, ext string) bool {
+	for _, e := range roms {
+		if strings.EqualFold(path.Ext(e.ROMName), ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchDisc(t *testing.T) {
+	dir := t.TempDir()
+	cue := "FILE \"Game (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\nFILE \"Game (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n"
+	track1 := []byte("data track")
+	track2 := []byte("audio track")
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	cuePath := write("Game.cue", []byte(cue))
+	write("Game (Track 1).bin", track1)
+	write("Game (Track 2).bin", track2)
+
+	dat := fmt.Sprintf(`clrmamepro ( name "Sony - PlayStation" )
+game (
+	name "Game (USA)"
+	rom ( name "Game (USA).cue" sha1 %x )
+	rom ( name "Game (USA) (Track 1).bin" sha1 %x )
+	rom ( name "Game (USA) (Track 2).bin" sha1 %x )
+)
+`, sha1.Sum([]byte(cue)), sha1.Sum(track1), sha1.Sum(track2))
+	idx, err := ParseDATReader(strings.NewReader(dat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := NewIdentifier([]*DATIndex{idx}, nil, nil)
+	ctx := context.Background()
+
+	// Renamed files, matched by their hashes.
+	m, err := id.MatchDisc(ctx, cuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Result != DiscFull || m.Game != "Game (USA)" || m.DAT != "Sony - PlayStation" || len(m.Tracks) != 3 {
+		t.Fatalf("match = %+v", m)
+	}
+	if m.Tracks[2].ROMName != "Game (USA) (Track 2).bin" {
+		t.Errorf("track 2 compared with %q", m.Tracks[2].ROMName)
+	}
+
+	// A differing track makes it partial and is reported.
+	write("Game (Track 2).bin", []byte("re-ripped audio"))
+	m, _ = id.MatchDisc(ctx, cuePath)
+	if m.Result != DiscPartial || len(m.Missing) != 1 || m.Missing[0] != "Game (USA) (Track 2).bin" {
+		t.Errorf("differing track: %v, missing %v", m.Result, m.Missing)
+	}
+
+	// Named like the DAT, the differing track is compared with its entry.
+	os.Rename(filepath.Join(dir, "Game (Track 2).bin"), filepath.Join(dir, "Game (USA) (Track 2).bin"))
+	os.WriteFile(cuePath, []byte(strings.Replace(cue, "Game (Track 2)", "Game (USA) (Track 2)", 1)), 0644)
+	m, _ = id.MatchDisc(ctx, cuePath)
+	differ := m.Differ()
+	if m.Result != DiscPartial || len(differ) != 2 {
+		t.Fatalf("renamed: %v, differ %+v", m.Result, differ)
+	}
+	if differ[0].ROMName != "Game (USA).cue" || differ[1].ROMName != "Game (USA) (Track 2).bin" {
+		t.Errorf("differ = %+v", differ)
+	}
+
+	// A track the sheet references but that is gone.
+	os.Remove(filepath.Join(dir, "Game (USA) (Track 2).bin"))
+	m, _ = id.MatchDisc(ctx, cuePath)
+	if m.Result != DiscPartial || !m.Tracks[2].Absent {
+		t.Errorf("absent track: %v, %+v", m.Result, m.Tracks[2])
+	}
+
+	// A gdi sheet the DAT doesn't list does not count against the set.
+	gdi := write("Game.gdi", []byte("1\n1 0 4 2352 \"Game (Track 1).bin\" 0\n"))
+	m, _ = id.MatchDisc(ctx, gdi)
+	if !m.Tracks[0].Skipped || !m.Tracks[1].Matched {
+		t.Errorf("gdi tracks = %+v", m.Tracks)
+	}
+
+	// Nothing known.
+	other := write("Other.cue", []byte("FILE \"Other.bin\" BINARY\n"))
+	write("Other.bin", []byte("unknown"))
+	if m, _ := id.MatchDisc(ctx, other); m.Result != DiscMiss || m.Game != "" {
+		t.Errorf("unknown = %+v", m)
+	}
+}
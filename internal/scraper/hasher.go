@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"fmt"
+	"hash"
 	"hash/crc32"
 	"io"
 	"os"
@@ -14,8 +15,16 @@ import (
 const hashBufSize = 256 * 1024
 
 // HashFile computes CRC32, MD5, and SHA1 of a file in a single pass.
-// It checks ctx for cancellation between read chunks.
+// It checks ctx for cancellation between read chunks. A copier header
+// recognized by BuiltinHeaderSkippers is reported in Header, with the
+// hashes of the file without it in Headerless.
 func HashFile(ctx context.Context, path string) (FileHashes, error) {
+	return HashFileHeaders(ctx, path, BuiltinHeaderSkippers)
+}
+
+// HashFileHeaders is HashFile with the given header skippers; the first
+// one with a matching rule is used.
+func HashFileHeaders(ctx context.Context, path string, skippers []*HeaderSkipper) (FileHashes, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return FileHashes{}, err
@@ -26,14 +35,31 @@ func HashFile(ctx context.Context, path string) (FileHashes, error) {
 	if err != nil {
 		return FileHashes{}, err
 	}
+	return hashReader(ctx, f, info.Size(), skippers)
+}
 
-	crc := crc32.NewIEEE()
-	md := md5.New()
-	sh := sha1.New()
+// hashReader hashes size bytes read from r, such as a zip member.
+func hashReader(ctx context.Context, r io.Reader, size int64, skippers []*HeaderSkipper) (FileHashes, error) {
+	head := make([]byte, headBytes(skippers))
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return FileHashes{}, err
+	}
+	head = head[:n]
+	skipper, start, end := detectHeader(skippers, head, size)
 
-	w := io.MultiWriter(crc, md, sh)
-	buf := make([]byte, hashBufSize)
+	full := newHashSet()
+	w := io.Writer(full)
+	var bare *hashSet
+	if skipper != nil {
+		bare = newHashSet()
+		w = io.MultiWriter(full, &rangeWriter{w: bare, start: start, end: end})
+	}
+	if _, err := w.Write(head); err != nil {
+		return FileHashes{}, err
+	}
 
+	buf := make([]byte, hashBufSize)
 	for {
 		select {
 		case <-ctx.Done():
@@ -41,7 +67,7 @@ func HashFile(ctx context.Context, path string) (FileHashes, error) {
 		default:
 		}
 
-		n, err := f.Read(buf)
+		n, err := r.Read(buf)
 		if n > 0 {
 			if _, werr := w.Write(buf[:n]); werr != nil {
 				return FileHashes{}, werr
@@ -55,10 +81,59 @@ func HashFile(ctx context.Context, path string) (FileHashes, error) {
 		}
 	}
 
+	hashes := full.sums(size)
+	if skipper != nil {
+		h := bare.sums(end - start)
+		hashes.Header = skipper.Name
+		hashes.Headerless = &h
+	}
+	return hashes, nil
+}
+
+// hashSet computes CRC32, MD5 and SHA1 together.
+type hashSet struct {
+	crc, md, sh hash.Hash
+	w           io.Writer
+}
+
+func newHashSet() *hashSet {
+	h := &hashSet{crc: crc32.NewIEEE(), md: md5.New(), sh: sha1.New()}
+	h.w = io.MultiWriter(h.crc, h.md, h.sh)
+	return h
+}
+
+func (h *hashSet) Write(p []byte) (int, error) { return h.w.Write(p) }
+
+func (h *hashSet) sums(size int64) FileHashes {
 	return FileHashes{
-		CRC32: fmt.Sprintf("%08X", crc.Sum32()),
-		MD5:   fmt.Sprintf("%x", md.Sum(nil)),
-		SHA1:  fmt.Sprintf("%x", sh.Sum(nil)),
-		Size:  info.Size(),
-	}, nil
+		CRC32: fmt.Sprintf("%X", h.crc.Sum(nil)),
+		MD5:   fmt.Sprintf("%x", h.md.Sum(nil)),
+		SHA1:  fmt.Sprintf("%x", h.sh.Sum(nil)),
+		Size:  size,
+	}
+}
+
+// rangeWriter passes on only the bytes between start and end of the
+// stream written to it.
+type rangeWriter struct {
+	w          io.Writer
+	start, end int64
+	pos        int64
+}
+
+func (r *rangeWriter) Write(p []byte) (int, error) {
+	lo, hi := r.start-r.pos, r.end-r.pos
+	r.pos += int64(len(p))
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > int64(len(p)) {
+		hi = int64(len(p))
+	}
+	if lo < hi {
+		if _, err := r.w.Write(p[lo:hi]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
 }
@@ -50,3 +50,32 @@ func TestHashFile_NotFound(t *testing.T) {
 		t.Error("expected error for nonexistent file")
 	}
 }
+
+func TestHashFile_Headered(t *testing.T) {
+	dir := t.TempDir()
+	prg := []byte("PRG and CHR data of a headerless NES dump")
+	header := append([]byte("NES\x1a"), make([]byte, 12)...)
+	headered := filepath.Join(dir, "game.nes")
+	bare := filepath.Join(dir, "game.bin")
+	os.WriteFile(headered, append(header, prg...), 0644)
+	os.WriteFile(bare, prg, 0644)
+
+	got, err := HashFile(context.Background(), headered)
+	if err != nil {
+		t.Fatalf("HashFile failed: %v", err)
+	}
+	want, _ := HashFile(context.Background(), bare)
+
+	if got.Header != "iNES" || got.Headerless == nil {
+		t.Fatalf("header = %q, headerless = %v", got.Header, got.Headerless)
+	}
+	if *got.Headerless != want {
+		t.Errorf("headerless = %+v, want %+v", *got.Headerless, want)
+	}
+	if got.SHA1 == want.SHA1 || got.Size != int64(len(header)+len(prg)) {
+		t.Errorf("full hashes should cover the header: %+v", got)
+	}
+	if want.Header != "" || want.Headerless != nil {
+		t.Errorf("headerless file reported a header: %+v", want)
+	}
+}
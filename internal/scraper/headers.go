@@ -0,0 +1,267 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HeaderSkipper detects a copier header at the start of a ROM, such as
+// iNES on NES dumps, so the ROM can be hashed without it the way No-Intro
+// DATs do. It mirrors a clrmamepro header-skipper XML definition.
+type HeaderSkipper struct {
+	Name  string
+	rules []headerRule
+}
+
+// headerRule matches when all of its tests pass; the ROM data is then the
+// bytes from start up to end (-1 for the end of the file).
+type headerRule struct {
+	start, end int64
+	tests      []headerTest
+}
+
+// headerTest is a data test (value at offset) or, when value is nil, a
+// file size test. result is the outcome the test must have.
+type headerTest struct {
+	offset int64
+	value  []byte
+	size   int64
+	sizeOp string // "equal", "less" or "greater"
+	po2    bool   // size must be a power of two
+	result bool
+}
+
+// BuiltinHeaderSkippers cover the headers No-Intro strips: iNES (NES),
+// fwNES (Famicom Disk System), LNX (Lynx) and A78 (Atari 7800).
+var BuiltinHeaderSkippers = []*HeaderSkipper{
+	{Name: "iNES", rules: []headerRule{{start: 16, end: -1, tests: []headerTest{{value: []byte("NES\x1a"), result: true}}}}},
+	{Name: "fwNES", rules: []headerRule{{start: 16, end: -1, tests: []headerTest{{value: []byte("FDS\x1a"), result: true}}}}},
+	{Name: "LNX", rules: []headerRule{{start: 64, end: -1, tests: []headerTest{{value: []byte("LYNX"), result: true}}}}},
+	{Name: "A78", rules: []headerRule{{start: 128, end: -1, tests: []headerTest{{offset: 1, value: []byte("ATARI7800"), result: true}}}}},
+}
+
+// headBytes is how much of the file detection needs to see.
+func headBytes(skippers []*HeaderSkipper) int64 {
+	var n int64
+	for _, s := range skippers {
+		for _, r := range s.rules {
+			for _, t := range r.tests {
+				if end := t.offset + int64(len(t.value)); end > n {
+					n = end
+				}
+			}
+		}
+	}
+	return n
+}
+
+// detectHeader returns the first skipper with a rule matching the file,
+// given its first bytes and size, and the range of ROM data it leaves.
+func detectHeader(skippers []*HeaderSkipper, head []byte, size int64) (s *HeaderSkipper, start, end int64) {
+	for _, s := range skippers {
+		for _, r := range s.rules {
+			if !r.matches(head, size) {
+				continue
+			}
+			end := r.end
+			if end < 0 || end > size {
+				end = size
+			}
+			if r.start < 0 || r.start >= end || (r.start == 0 && end == size) {
+				continue
+			}
+			return s, r.start, end
+		}
+	}
+	return nil, 0, size
+}
+
+func (r headerRule) matches(head []byte, size int64) bool {
+	for _, t := range r.tests {
+		var ok bool
+		if t.value != nil {
+			end := t.offset + int64(len(t.value))
+			ok = end <= int64(len(head)) && bytes.Equal(head[t.offset:end], t.value)
+		} else {
+			switch {
+			case t.po2:
+				ok = size > 0 && size&(size-1) == 0
+			case t.sizeOp == "less":
+				ok = size < t.size
+			case t.sizeOp == "greater":
+				ok = size > t.size
+			default:
+				ok = size == t.size
+			}
+		}
+		if ok != t.result {
+			return false
+		}
+	}
+	return len(r.tests) > 0
+}
+
+// clrmamepro header-skipper XML:
+//
+//	<detector>
+//		<name>No-Intro NES Dat iNES Header Skipper</name>
+//		<rule start_offset="10">
+//			<data offset="0" value="4E45531A"/>
+//		</rule>
+//	</detector>
+//
+// Offsets and sizes are hexadecimal.
+type detectorXML struct {
+	XMLName xml.Name `xml:"detector"`
+	Name    string   `xml:"name"`
+	Rules   []struct {
+		StartOffset string `xml:"start_offset,attr"`
+		EndOffset   string `xml:"end_offset,attr"`
+		Operation   string `xml:"operation,attr"`
+		Data        []struct {
+			Offset string `xml:"offset,attr"`
+			Value  string `xml:"value,attr"`
+			Result string `xml:"result,attr"`
+		} `xml:"data"`
+		File []struct {
+			Size     string `xml:"size,attr"`
+			Operator string `xml:"operator,attr"`
+			Result   string `xml:"result,attr"`
+		} `xml:"file"`
+		Others []struct {
+			XMLName xml.Name
+		} `xml:",any"`
+	} `xml:"rule"`
+}
+
+// ParseHeaderSkipper reads a clrmamepro header-skipper definition. Rules
+// that transform the data (bitswap, byteswap, wordswap) or use bitmask
+// tests are not supported and are left out; a definition with no usable
+// rule is an error.
+func ParseHeaderSkipper(r io.Reader) (*HeaderSkipper, error) {
+	var d detectorXML
+	if err := xml.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+	s := &HeaderSkipper{Name: d.Name}
+	for _, xr := range d.Rules {
+		if op := strings.ToLower(xr.Operation); op != "" && op != "none" {
+			continue
+		}
+		if len(xr.Others) > 0 {
+			continue // and, or, xor tests
+		}
+		rule := headerRule{end: -1}
+		var err error
+		if rule.start, err = parseHexOffset(xr.StartOffset, 0); err != nil {
+			return nil, err
+		}
+		if rule.end, err = parseHexOffset(xr.EndOffset, -1); err != nil {
+			return nil, err
+		}
+		for _, xd := range xr.Data {
+			t := headerTest{result: xd.Result != "false"}
+			if strings.EqualFold(xd.Offset, "EOF") {
+				return nil, fmt.Errorf("data test at EOF is not supported")
+			}
+			if t.offset, err = parseHexOffset(xd.Offset, 0); err != nil {
+				return nil, err
+			}
+			if t.value, err = hex.DecodeString(xd.Value); err != nil || len(t.value) == 0 {
+				return nil, fmt.Errorf("bad data value %q", xd.Value)
+			}
+			rule.tests = append(rule.tests, t)
+		}
+		for _, xf := range xr.File {
+			t := headerTest{result: xf.Result != "false", sizeOp: strings.ToLower(xf.Operator)}
+			if strings.EqualFold(xf.Size, "PO2") {
+				t.po2 = true
+			} else if t.size, err = strconv.ParseInt(xf.Size, 16, 64); err != nil {
+				return nil, fmt.Errorf("bad file size %q", xf.Size)
+			}
+			rule.tests = append(rule.tests, t)
+		}
+		s.rules = append(s.rules, rule)
+	}
+	if len(s.rules) == 0 {
+		return nil, fmt.Errorf("header skipper %q has no supported rules", d.Name)
+	}
+	return s, nil
+}
+
+// parseHexOffset parses a hexadecimal offset; "EOF" or empty is def.
+func parseHexOffset(s string, def int64) (int64, error) {
+	if s == "" || strings.EqualFold(s, "EOF") {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad offset %q", s)
+	}
+	return n, nil
+}
+
+// isHeaderSkipperFile reports whether an XML file is a header-skipper
+// definition rather than a DAT.
+func isHeaderSkipperFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local == "detector"
+		}
+	}
+}
+
+// LoadHeaderSkippers parses the header-skipper XML files under dirs,
+// typically kept next to the DATs. Files that fail to parse are reported
+// in errs and skipped.
+func LoadHeaderSkippers(dirs []string) (skippers []*HeaderSkipper, errs []error) {
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".xml") || !isHeaderSkipperFile(path) {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			defer f.Close()
+			s, err := ParseHeaderSkipper(f)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				return nil
+			}
+			skippers = append(skippers, s)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return skippers, errs
+}
@@ -0,0 +1,101 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleSkipper = `<?xml version="1.0"?>
+<detector>
+	<name>No-Intro Atari Lynx Dat LNX Header Skipper</name>
+	<author>Yakushi~Kabuto</author>
+	<version>20070321</version>
+	<rule start_offset="40" end_offset="EOF" operation="none">
+		<data offset="0" value="4C594E58" result="true"/>
+		<file size="40" operator="greater"/>
+	</rule>
+	<rule start_offset="0" operation="byteswap">
+		<data offset="0" value="37804012"/>
+	</rule>
+</detector>`
+
+func TestParseHeaderSkipper(t *testing.T) {
+	s, err := ParseHeaderSkipper(strings.NewReader(sampleSkipper))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(s.rules) != 1 {
+		t.Fatalf("got %d rules, want 1 (byteswap is unsupported)", len(s.rules))
+	}
+	r := s.rules[0]
+	if r.start != 0x40 || r.end != -1 || len(r.tests) != 2 {
+		t.Errorf("rule = %+v", r)
+	}
+
+	head := append([]byte("LYNX"), make([]byte, 60)...)
+	if got, start, end := detectHeader([]*HeaderSkipper{s}, head, 1024); got != s || start != 64 || end != 1024 {
+		t.Errorf("detectHeader = %v, %d, %d", got, start, end)
+	}
+	// Too small for the file test.
+	if got, _, _ := detectHeader([]*HeaderSkipper{s}, head, 64); got != nil {
+		t.Error("matched a file no bigger than its header")
+	}
+
+	if _, err := ParseHeaderSkipper(strings.NewReader(`<detector><name>x</name><rule><and offset="0" mask="FF" value="00"/></rule></detector>`)); err == nil {
+		t.Error("accepted a skipper with no supported rules")
+	}
+}
+
+func TestLoadHeaderSkippers(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lnx.xml"), []byte(sampleSkipper), 0644)
+	os.WriteFile(filepath.Join(dir, "nointro.xml"), []byte(sampleDAT), 0644)
+
+	skippers, errs := LoadHeaderSkippers([]string{dir})
+	if len(skippers) != 1 || len(errs) != 0 {
+		t.Fatalf("got %d skippers, errs %v", len(skippers), errs)
+	}
+	// The skipper next to the DAT is not mistaken for one.
+	indices, errs := LoadDATDirs([]string{dir})
+	if len(indices) != 1 || len(errs) != 0 {
+		t.Errorf("got %d DATs, errs %v", len(indices), errs)
+	}
+
+	rom := filepath.Join(dir, "game.lnx")
+	os.WriteFile(rom, append(append([]byte("LYNX"), make([]byte, 60)...), []byte("cart data")...), 0644)
+	h, err := HashFileHeaders(context.Background(), rom, skippers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Header != skippers[0].Name || h.Headerless == nil || h.Headerless.Size != 9 {
+		t.Errorf("hashes = %+v", h)
+	}
+}
+
+func TestDATIndex_Match_Headerless(t *testing.T) {
+	idx, _ := ParseDATReader(strings.NewReader(sampleDAT))
+
+	// Mario's DAT hashes are of the ROM without its iNES header.
+	hashes := FileHashes{
+		SHA1:       "0123456789abcdef0123456789abcdef01234567",
+		Header:     "iNES",
+		Headerless: &FileHashes{SHA1: "facee9c577a5262dbe33b8370e8882c37ea48e2e"},
+	}
+	m, ok := idx.Match(hashes)
+	if !ok || !m.Headerless || m.Entry.GameName != "Super Mario Bros. (World)" {
+		t.Errorf("Match = %+v, %v", m, ok)
+	}
+
+	// Sonic's DAT entry is of the whole file.
+	hashes = FileHashes{CRC32: "16FB1316", Headerless: &FileHashes{CRC32: "00000000"}}
+	m, ok = idx.Match(hashes)
+	if !ok || m.Headerless {
+		t.Errorf("Match = %+v, %v, want a whole-file match", m, ok)
+	}
+	if entry, ok := idx.Lookup(hashes); !ok || entry != m.Entry {
+		t.Error("Lookup disagrees with Match")
+	}
+}
@@ -5,14 +5,16 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/kurlmarx/romwrangler/internal/chd"
 	"github.com/kurlmarx/romwrangler/internal/systems"
 )
 
 // Identifier orchestrates ROM identification via multiple sources.
 type Identifier struct {
-	datIndices    []*DATIndex
-	ssClient      *ScreenScraperClient
-	cache         Cache
+	datIndices []*DATIndex
+	ssClient   *ScreenScraperClient
+	cache      Cache
+	headers    []*HeaderSkipper
 }
 
 // Cache is an interface for storing/retrieving identification results.
@@ -27,69 +29,151 @@ func NewIdentifier(datIndices []*DATIndex, ssClient *ScreenScraperClient, cache
 		datIndices: datIndices,
 		ssClient:   ssClient,
 		cache:      cache,
+		headers:    BuiltinHeaderSkippers,
 	}
 }
 
+// SetHeaderSkippers replaces the header skippers used when hashing, e.g.
+// with definitions from LoadHeaderSkippers followed by
+// BuiltinHeaderSkippers.
+func (id *Identifier) SetHeaderSkippers(skippers []*HeaderSkipper) {
+	id.headers = skippers
+}
+
 // Identify attempts to identify a ROM file. Order:
 // 1. Check cache
 // 2. Hash the file
 // 3. Try DAT files
 // 4. Try ScreenScraper API
 // 5. Cache and return result
+//
+// A .zip is identified by its contents without extracting it; see
+// identifyZip. A .chd is first identified by the SHA1s in its header; see
+// identifyCHD.
 func (id *Identifier) Identify(ctx context.Context, filePath string, systemID systems.SystemID) (*ROMMatch, error) {
 	match := &ROMMatch{FilePath: filePath}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".zip":
+		return id.identifyZip(ctx, filePath, systemID)
+	case ".chd":
+		if id.identifyCHD(match, systemID) || id.ssClient == nil {
+			return match, nil
+		}
+		// ScreenScraper only knows the hashes of the whole file.
+	}
 
 	// Hash the file
-	hashes, err := HashFile(ctx, filePath)
+	hashes, err := HashFileHeaders(ctx, filePath, id.headers)
 	if err != nil {
 		return nil, err
 	}
 	match.Hashes = hashes
 
+	id.identify(ctx, match, systemID, true)
+	return match, nil
+}
+
+// identify looks match.Hashes up in the cache, the DAT files and, when
+// useSS is set, ScreenScraper, filling in match.Game on success.
+func (id *Identifier) identify(ctx context.Context, match *ROMMatch, systemID systems.SystemID, useSS bool) {
+	hashes := match.Hashes
+
 	// Check cache
-	if id.cache != nil {
+	if id.cache != nil && hashes.SHA1 != "" {
 		if info, ok := id.cache.GetByHash(hashes.SHA1); ok {
 			match.Game = info
 			match.Matched = true
-			return match, nil
+			return
 		}
 	}
 
-	// Try DAT files
-	for _, idx := range id.datIndices {
-		if entry, ok := idx.Lookup(hashes); ok {
-			info := &GameInfo{
-				Name:   cleanGameName(entry.GameName),
-				System: systemID,
-				Source: "dat",
-			}
-			match.Game = info
-			match.Matched = true
-			if id.cache != nil {
-				id.cache.Put(hashes.SHA1, info)
-			}
-			return match, nil
-		}
+	// Try DAT files. A hit from a DAT whose system is unknown still asks
+	// ScreenScraper, which knows it.
+	if id.matchDATs(match, systemID) && (match.Game.System != "" || id.ssClient == nil || !useSS) {
+		return
 	}
 
 	// Try ScreenScraper
-	if id.ssClient != nil {
+	if id.ssClient != nil && useSS {
 		info, err := id.ssClient.Identify(ctx, hashes, systemID)
 		if err != nil {
 			// Non-fatal: just means we couldn't identify
-			return match, nil
+			return
 		}
 		if info != nil {
 			match.Game = info
 			match.Matched = true
-			if id.cache != nil {
-				id.cache.Put(hashes.SHA1, info)
+			id.cachePut(hashes.SHA1, info)
+		}
+	}
+}
+
+// matchDATs looks match.Hashes up in the DAT files. When systemID is
+// empty, the system is the one the matching DAT is for.
+func (id *Identifier) matchDATs(match *ROMMatch, systemID systems.SystemID) bool {
+	for _, idx := range id.datIndices {
+		if m, ok := idx.Match(match.Hashes); ok {
+			id.setDATMatch(match, idx, m, systemID)
+			return true
+		}
+	}
+	return false
+}
+
+// setDATMatch records m, found in idx, as match's game.
+func (id *Identifier) setDATMatch(match *ROMMatch, idx *DATIndex, m DATMatch, systemID systems.SystemID) {
+	sys := systemID
+	if sys == "" {
+		sys = idx.System
+	}
+	info := &GameInfo{
+		Name:   cleanGameName(m.Entry.GameName),
+		System: sys,
+		Source: "dat",
+	}
+	match.Game = info
+	match.Matched = true
+	match.Headerless = m.Headerless
+	if sys != "" {
+		id.cachePut(match.Hashes.SHA1, info)
+	}
+}
+
+// identifyCHD reads the CHD header at match.FilePath and looks its SHA1s
+// up in the cache and the DAT files: the data SHA1 is what DATs list for
+// disks, the raw SHA1 covers the disc data alone. A file that is not a
+// readable CHD is left unmatched.
+func (id *Identifier) identifyCHD(match *ROMMatch, systemID systems.SystemID) bool {
+	h, err := chd.ReadHeader(match.FilePath)
+	if err != nil {
+		return false
+	}
+	match.CHD = h
+
+	for _, sha1 := range []string{h.SHA1, h.RawSHA1} {
+		if sha1 == "" {
+			continue
+		}
+		if id.cache != nil {
+			if info, ok := id.cache.GetByHash(sha1); ok {
+				match.Game = info
+				match.Matched = true
+				return true
 			}
-			return match, nil
+		}
+		match.Hashes = FileHashes{SHA1: sha1, Size: int64(h.LogicalBytes)}
+		if id.matchDATs(match, systemID) {
+			return true
 		}
 	}
+	match.Hashes = FileHashes{}
+	return false
+}
 
-	return match, nil
+func (id *Identifier) cachePut(sha1 string, info *GameInfo) {
+	if id.cache != nil && sha1 != "" {
+		id.cache.Put(sha1, info)
+	}
 }
 
 // cleanGameName normalizes a game name from DAT entry.
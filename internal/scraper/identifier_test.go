@@ -0,0 +1,131 @@
+package scraper
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type mapCache map[string]*GameInfo
+
+func (c mapCache) GetByHash(sha1 string) (*GameInfo, bool) {
+	info, ok := c[sha1]
+	return info, ok
+}
+
+func (c mapCache) Put(sha1 string, info *GameInfo) error {
+	c[sha1] = info
+	return nil
+}
+
+// writeCHD writes a CHD v5 header with the given raw and data SHA1s.
+func writeCHD(t *testing.T, path string, raw, data byte) {
+	t.Helper()
+	b := make([]byte, 124)
+	copy(b, "MComprHD")
+	binary.BigEndian.PutUint32(b[8:], 124)
+	binary.BigEndian.PutUint32(b[12:], 5)
+	copy(b[16:], "cdlz")
+	binary.BigEndian.PutUint64(b[32:], 1<<20)
+	for i := 0; i < 20; i++ {
+		b[64+i] = raw
+		b[84+i] = data
+	}
+	if err := os.WriteFile(path, append(b, "hunks"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdentify_CHD(t *testing.T) {
+	dat := `<?xml version="1.0"?>
+<datafile>
+	<header><name>Disks</name></header>
+	<game name="Grid Runner (USA)">
+		<disk name="Grid Runner (USA)" sha1="` + strings.Repeat("bb", 20) + `"/>
+	</game>
+</datafile>`
+	idx, err := ParseDATReader(strings.NewReader(dat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := mapCache{}
+	id := NewIdentifier([]*DATIndex{idx}, nil, cache)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	game := filepath.Join(dir, "Grid Runner (USA).chd")
+	writeCHD(t, game, 0xAA, 0xBB)
+	m, err := id.Identify(ctx, game, "sony_psx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Matched || m.Game.Name != "Grid Runner (USA)" || m.CHD == nil || m.CHD.LogicalBytes != 1<<20 {
+		t.Fatalf("match = %+v", m)
+	}
+	if m.Hashes.SHA1 != strings.Repeat("bb", 20) {
+		t.Errorf("matched SHA1 = %q", m.Hashes.SHA1)
+	}
+	if _, ok := cache[strings.Repeat("bb", 20)]; !ok {
+		t.Error("match not cached by the CHD's SHA1")
+	}
+
+	// Unknown to the DATs but in the cache by its raw SHA1.
+	other := filepath.Join(dir, "other.chd")
+	writeCHD(t, other, 0xCC, 0xDD)
+	cache[strings.Repeat("cc", 20)] = &GameInfo{Name: "Cached", Source: "screenscraper"}
+	if m, _ := id.Identify(ctx, other, ""); !m.Matched || m.Game.Name != "Cached" {
+		t.Errorf("cached match = %+v", m)
+	}
+
+	// Not a CHD at all.
+	bogus := filepath.Join(dir, "bogus.chd")
+	os.WriteFile(bogus, []byte("not a chd"), 0644)
+	if m, err := id.Identify(ctx, bogus, ""); err != nil || m.Matched || m.CHD != nil {
+		t.Errorf("bogus = %+v, %v", m, err)
+	}
+}
+
+func TestIdentify_DATSystem(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "mystery.bin")
+	if err := os.WriteFile(rom, []byte("rom data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := HashFile(context.Background(), rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dat := `clrmamepro ( name "Sega - Mega Drive - Genesis" )
+game ( name "Mystery (USA)" rom ( name "Mystery (USA).md" sha1 ` + h.SHA1 + ` ) )`
+	idx, err := ParseDATReader(strings.NewReader(dat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.System != "sega_smd" {
+		t.Fatalf("DAT system = %q", idx.System)
+	}
+
+	cache := mapCache{}
+	m, err := NewIdentifier([]*DATIndex{idx}, nil, cache).Identify(context.Background(), rom, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Matched || m.Game.System != "sega_smd" || m.Game.Source != "dat" {
+		t.Errorf("match = %+v, game = %+v", m, m.Game)
+	}
+
+	// A DAT for an unknown platform still names the game but is not cached
+	// without a system.
+	idx.System = ""
+	cache = mapCache{}
+	m, _ = NewIdentifier([]*DATIndex{idx}, nil, cache).Identify(context.Background(), rom, "")
+	if !m.Matched || m.Game.System != "" {
+		t.Errorf("unknown platform match = %+v", m.Game)
+	}
+	if len(cache) != 0 {
+		t.Errorf("system-less match cached: %v", cache)
+	}
+}
@@ -1,6 +1,10 @@
 package scraper
 
-import "github.com/kurlmarx/romwrangler/internal/systems"
+import (
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/systems"
+)
 
 // screenScraperSystems maps ScreenScraper API system IDs (integers)
 // to internal SystemID constants. Only systems supported by ReplayOS
@@ -89,3 +93,88 @@ func ScreenScraperToSystemID(ssID int) (systems.SystemID, bool) {
 	sys, ok := screenScraperSystems[ssID]
 	return sys, ok
 }
+
+// datSystems maps the platform names No-Intro and Redump DATs carry in
+// their header, lowercased, to internal SystemID constants.
+var datSystems = map[string]systems.SystemID{
+	// Amstrad
+	"amstrad - cpc": systems.AmstradCPC,
+
+	// Atari
+	"atari - 2600":   systems.Atari2600,
+	"atari - 5200":   systems.Atari5200,
+	"atari - 7800":   systems.Atari7800,
+	"atari - lynx":   systems.AtariLynx,
+	"atari - jaguar": systems.AtariJaguar,
+
+	// Commodore
+	"commodore - 64":         systems.CommodoreC64,
+	"commodore - amiga":      systems.CommodoreAmiga,
+	"commodore - amiga cd32": systems.CommodoreAmigaCD,
+
+	// Microsoft
+	"microsoft - msx":  systems.MSX,
+	"microsoft - msx2": systems.MSX2,
+
+	// NEC
+	"nec - pc engine - turbografx-16":    systems.NECPCE,
+	"nec - pc engine - turbografx 16":    systems.NECPCE,
+	"nec - pc engine cd & turbografx cd": systems.NECPCECD,
+	"nec - pc engine cd - turbografx-cd": systems.NECPCECD,
+
+	// Nintendo
+	"nintendo - nintendo entertainment system":       systems.NintendoNES,
+	"nintendo - family computer disk system":         systems.NintendoFDS,
+	"nintendo - super nintendo entertainment system": systems.NintendoSNES,
+	"nintendo - nintendo 64":                         systems.NintendoN64,
+	"nintendo - game boy":                            systems.NintendoGB,
+	"nintendo - game boy color":                      systems.NintendoGBC,
+	"nintendo - game boy advance":                    systems.NintendoGBA,
+	"nintendo - nintendo ds":                         systems.NintendoNDS,
+
+	// Panasonic
+	"panasonic - 3do interactive multiplayer": systems.Panasonic3DO,
+
+	// Philips
+	"philips - cd-i": systems.PhilipsCDi,
+
+	// Sega
+	"sega - sg-1000":                  systems.SegaSG1000,
+	"sega - master system - mark iii": systems.SegaMS,
+	"sega - mega drive - genesis":     systems.SegaMD,
+	"sega - 32x":                      systems.Sega32X,
+	"sega - mega-cd - sega cd":        systems.SegaCD,
+	"sega - mega cd & sega cd":        systems.SegaCD,
+	"sega - saturn":                   systems.SegaSaturn,
+	"sega - dreamcast":                systems.SegaDC,
+	"sega - game gear":                systems.SegaGG,
+
+	// Sharp
+	"sharp - x68000": systems.SharpX68K,
+
+	// Sinclair
+	"sinclair - zx spectrum +3": systems.SinclairZX,
+
+	// SNK
+	"snk - neo geo cd":           systems.SNKNeoGeoCD,
+	"snk - neogeo cd":            systems.SNKNeoGeoCD,
+	"snk - neo geo pocket":       systems.SNKNGP,
+	"snk - neo geo pocket color": systems.SNKNGPC,
+
+	// Sony
+	"sony - playstation": systems.SonyPSX,
+}
+
+// DATNameToSystemID converts the name in a No-Intro or Redump DAT header,
+// e.g. "Nintendo - Nintendo Entertainment System (Headered)", to an
+// internal SystemID. Returns false if the platform is unknown or
+// unsupported.
+func DATNameToSystemID(name string) (systems.SystemID, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.Index(name, " ("); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(name, " - datfile")
+	sys, ok := datSystems[name]
+	return sys, ok
+}
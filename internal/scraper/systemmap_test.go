@@ -58,3 +58,28 @@ func TestScreenScraperToSystemID(t *testing.T) {
 		})
 	}
 }
+
+func TestDATNameToSystemID(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantSys systems.SystemID
+		wantOK  bool
+	}{
+		{"Nintendo - Nintendo Entertainment System", systems.NintendoNES, true},
+		{"Nintendo - Nintendo Entertainment System (Headered)", systems.NintendoNES, true},
+		{"Sega - Mega Drive - Genesis (Parent-Clone)", systems.SegaMD, true},
+		{"Sony - PlayStation - Datfile (10851) (2024-01-01 00-00-00)", systems.SonyPSX, true},
+		{"sega - dreamcast", systems.SegaDC, true},
+		{"Nintendo - Virtual Boy", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSys, gotOK := DATNameToSystemID(tt.name)
+			if gotOK != tt.wantOK || gotSys != tt.wantSys {
+				t.Errorf("DATNameToSystemID(%q) = %q, %v, want %q, %v", tt.name, gotSys, gotOK, tt.wantSys, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,13 +1,20 @@
 package scraper
 
-import "github.com/kurlmarx/romwrangler/internal/systems"
+import (
+	"github.com/kurlmarx/romwrangler/internal/chd"
+	"github.com/kurlmarx/romwrangler/internal/systems"
+)
 
 // FileHashes holds computed checksums for a file.
 type FileHashes struct {
-	CRC32  string
-	MD5    string
-	SHA1   string
-	Size   int64
+	CRC32 string
+	MD5   string
+	SHA1  string
+	Size  int64
+	// Header names the copier header found at the start of the file, if
+	// any; Headerless holds the hashes of the file without it.
+	Header     string
+	Headerless *FileHashes
 }
 
 // GameInfo holds identified game metadata.
@@ -25,7 +32,17 @@ type GameInfo struct {
 // ROMMatch pairs a file with its identified game info.
 type ROMMatch struct {
 	FilePath string
-	Hashes   FileHashes
-	Game     *GameInfo
-	Matched  bool
+	// Member is the file inside the zip at FilePath this match is for.
+	Member  string
+	Hashes  FileHashes
+	Game    *GameInfo
+	Matched bool
+	// Headerless is set when a DAT matched the file's hashes with its
+	// copier header skipped.
+	Headerless bool
+	// Members holds the matches of each file in a multi-file zip.
+	Members []*ROMMatch
+	// CHD is the header of a .chd file. When one of its SHA1s matched,
+	// Hashes holds that SHA1 rather than hashes of the file.
+	CHD *chd.Header
 }
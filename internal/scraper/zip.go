@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+
+	"github.com/kurlmarx/romwrangler/internal/systems"
+)
+
+// identifyZip identifies the files inside a zip archive without
+// extracting it. Each member is first looked up in the DAT files by the
+// CRC32 and size in the zip's central directory; only members that miss
+// are read and hashed in full, streamed from the archive.
+//
+// A single-file zip is matched by its inner ROM: the result describes that
+// file, with Member set to its name. A multi-file zip is reported member by
+// member in Members, and counts as matched when every member matches the
+// same game, as with arcade sets. ScreenScraper is only asked about
+// single-file zips.
+func (id *Identifier) identifyZip(ctx context.Context, filePath string, systemID systems.SystemID) (*ROMMatch, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var files []*zip.File
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			files = append(files, f)
+		}
+	}
+
+	match := &ROMMatch{FilePath: filePath}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		m, err := id.identifyZipMember(ctx, filePath, f, systemID, len(files) == 1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		match.Members = append(match.Members, m)
+	}
+	if len(files) == 1 {
+		return match.Members[0], nil
+	}
+
+	for i, m := range match.Members {
+		if !m.Matched || m.Game == nil || (i > 0 && m.Game.Name != match.Members[0].Game.Name) {
+			return match, nil
+		}
+	}
+	if len(match.Members) > 0 {
+		match.Game = match.Members[0].Game
+		match.Matched = true
+	}
+	return match, nil
+}
+
+// identifyZipMember identifies one file in a zip, trying its central
+// directory CRC32 and size against the DATs before hashing its contents.
+func (id *Identifier) identifyZipMember(ctx context.Context, zipPath string, f *zip.File, systemID systems.SystemID, useSS bool) (*ROMMatch, error) {
+	size := int64(f.UncompressedSize64)
+	m := &ROMMatch{
+		FilePath: zipPath,
+		Member:   f.Name,
+		Hashes:   FileHashes{CRC32: fmt.Sprintf("%08X", f.CRC32), Size: size},
+	}
+	// A CRC32 alone is too weak to skip hashing; the DAT must list the
+	// same size too.
+	for _, idx := range id.datIndices {
+		if e, ok := idx.ByCRC[m.Hashes.CRC32]; ok && e.Size == size {
+			id.setDATMatch(m, idx, DATMatch{Entry: e}, systemID)
+			return m, nil
+		}
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	hashes, err := hashReader(ctx, rc, size, id.headers)
+	if err != nil {
+		return nil, err
+	}
+	m.Hashes = hashes
+	id.identify(ctx, m, systemID, useSS)
+	return m, nil
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeZip(t *testing.T, path string, files map[string][]byte) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdentify_Zip(t *testing.T) {
+	prg := []byte("NES program data")
+	header := append([]byte("NES\x1a"), make([]byte, 12)...)
+	gun := []byte("Gun.Smoke rom 1")
+	gun2 := []byte("Gun.Smoke rom 2")
+
+	dat := fmt.Sprintf(`clrmamepro ( name "Test" )
+game ( name "Mario (World)" rom ( name "Mario (World).nes" sha1 %x ) )
+game ( name "Sonic (USA)" rom ( name "Sonic (USA).md" size 5 crc %08X ) )
+game ( name "Tetris (World)" rom ( name "Tetris (World).gb" size 999 crc %08X sha1 %x ) )
+game ( name "gunsmoke" rom ( name 1.bin crc %08X ) rom ( name 2.bin crc %08X ) )
+`, sha1.Sum(prg), crc32.ChecksumIEEE([]byte("sonic")), crc32.ChecksumIEEE([]byte("tetris")), sha1.Sum([]byte("tetris")), crc32.ChecksumIEEE(gun), crc32.ChecksumIEEE(gun2))
+	idx, err := ParseDATReader(strings.NewReader(dat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := NewIdentifier([]*DATIndex{idx}, nil, nil)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// Single file, matched by the central directory CRC alone.
+	sonic := filepath.Join(dir, "sonic.zip")
+	writeZip(t, sonic, map[string][]byte{"Sonic (USA).md": []byte("sonic")})
+	m, err := id.Identify(ctx, sonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Matched || m.Game.Name != "Sonic (USA)" || m.Member != "Sonic (USA).md" || m.FilePath != sonic {
+		t.Errorf("sonic = %+v", m)
+	}
+	if m.Hashes.SHA1 != "" {
+		t.Error("member was hashed although its CRC matched")
+	}
+
+	// The CRC matches but the DAT lists another size: the member is hashed.
+	tetris := filepath.Join(dir, "tetris.zip")
+	writeZip(t, tetris, map[string][]byte{"Tetris.gb": []byte("tetris")})
+	m, err = id.Identify(ctx, tetris, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Hashes.SHA1 == "" {
+		t.Error("member was not hashed although its size differs from the DAT's")
+	}
+
+	// Single headered file: the CRC misses, the streamed headerless hash hits.
+	mario := filepath.Join(dir, "mario.zip")
+	writeZip(t, mario, map[string][]byte{"Mario.nes": append(header, prg...)})
+	m, err = id.Identify(ctx, mario, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Matched || !m.Headerless || m.Game.Name != "Mario (World)" {
+		t.Errorf("mario = %+v", m)
+	}
+
+	// Multi-file set, matched member by member.
+	set := filepath.Join(dir, "gunsmoke.zip")
+	writeZip(t, set, map[string][]byte{"1.bin": gun, "2.bin": gun2})
+	m, err = id.Identify(ctx, set, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Matched || m.Game.Name != "gunsmoke" || len(m.Members) != 2 {
+		t.Errorf("gunsmoke = %+v", m)
+	}
+
+	mixed := filepath.Join(dir, "mixed.zip")
+	writeZip(t, mixed, map[string][]byte{"1.bin": gun, "readme.txt": []byte("hi")})
+	m, err = id.Identify(ctx, mixed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Matched || len(m.Members) != 2 {
+		t.Fatalf("mixed = %+v", m)
+	}
+	for _, mm := range m.Members {
+		if want := mm.Member == "1.bin"; mm.Matched != want {
+			t.Errorf("member %s matched = %v", mm.Member, mm.Matched)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package transfer
+
+import (
+	"fmt"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+// NewBackend creates a per-file backend for the configured device. method
+// is "sftp" or "usb"; an empty method uses cfg.Transfer.Method. rsync only
+// syncs whole folders, so "rsync" falls back to SFTP over the same SSH
+// credentials. Uploads honor the configured bandwidth limit and window.
+func NewBackend(cfg *config.Config, method string) (TransferBackend, error) {
+	throttle, err := ThrottleFromConfig(cfg.Transfer)
+	if err != nil {
+		return nil, err
+	}
+	return NewDeviceBackend(cfg, cfg.Device, method, throttle)
+}
+
+// NewDeviceBackend is like NewBackend for one of the configured devices.
+// Backends that share throttle share its bandwidth limit.
+func NewDeviceBackend(cfg *config.Config, dev config.DeviceConfig, method string, throttle *Throttle) (TransferBackend, error) {
+	if method == "" {
+		method = cfg.Transfer.Method
+	}
+	switch method {
+	case "sftp", "rsync", "":
+		backend := NewSFTPBackend(
+			dev.Host,
+			dev.Port,
+			dev.User,
+			SSHAuthFromDevice(dev),
+			dev.RootPath,
+		)
+		backend.Throttle = throttle
+		return backend, nil
+	case "usb":
+		if cfg.Transfer.USBPath == "" {
+			return nil, fmt.Errorf("no USB path configured")
+		}
+		backend := NewUSBBackend(cfg.Transfer.USBPath)
+		backend.FSType = cfg.Transfer.USBFilesystem
+		backend.Throttle = throttle
+		return backend, nil
+	default:
+		return nil, fmt.Errorf("transfer method %q does not support this operation (use sftp or usb)", method)
+	}
+}
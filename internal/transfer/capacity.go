@@ -0,0 +1,297 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/multidisc"
+)
+
+// SpaceReporter is implemented by backends that can report the free space
+// on the destination.
+type SpaceReporter interface {
+	FreeSpace(ctx context.Context) (int64, error)
+}
+
+// Reasons a file is left out of a plan.
+const (
+	OmitExcluded = "excluded" // an include/exclude rule
+	OmitCapacity = "capacity" // did not fit the capacity budget
+)
+
+// OmittedItem is a file left out of a plan. System is the ReplayOS system
+// folder for ROMs, or the top-level folder (bios, saves, ...) otherwise.
+type OmittedItem struct {
+	TransferItem
+	System string
+	Reason string
+	Hint   string // what the user can do about it, if anything
+}
+
+// OmittedSummary totals the omitted files of one system for one reason.
+type OmittedSummary struct {
+	System string
+	Reason string
+	Files  int
+	Size   int64
+}
+
+// OmittedBySystem groups the plan's omitted files by system and reason,
+// largest first.
+func (p *TransferPlan) OmittedBySystem() []OmittedSummary {
+	index := make(map[[2]string]int)
+	var out []OmittedSummary
+	for _, o := range p.Omitted {
+		k := [2]string{o.System, o.Reason}
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, OmittedSummary{System: o.System, Reason: o.Reason})
+		}
+		out[i].Files++
+		out[i].Size += o.Size
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Size > out[j].Size })
+	return out
+}
+
+// CapacityBudget returns the number of bytes a plan may add to the
+// destination under cc: the destination's free space when FitToDevice is
+// set, capped by BudgetGB, minus ReserveMB. limited is false when cc sets
+// no limit.
+func CapacityBudget(ctx context.Context, backend TransferBackend, cc config.CapacityConfig) (budget int64, limited bool, err error) {
+	if !cc.FitToDevice && cc.BudgetGB <= 0 {
+		return 0, false, nil
+	}
+
+	budget = -1
+	if cc.FitToDevice {
+		reporter, ok := backend.(SpaceReporter)
+		if !ok {
+			return 0, false, fmt.Errorf("transfer method cannot report free space")
+		}
+		if budget, err = reporter.FreeSpace(ctx); err != nil {
+			return 0, false, err
+		}
+	}
+	if cc.BudgetGB > 0 {
+		fixed := int64(cc.BudgetGB * 1e9)
+		if budget < 0 || fixed < budget {
+			budget = fixed
+		}
+	}
+
+	budget -= int64(cc.ReserveMB) * 1024 * 1024
+	if budget < 0 {
+		budget = 0
+	}
+	return budget, true, nil
+}
+
+// planSystem returns the system a destination path belongs to: the folder
+// under roms/, or the top-level folder for everything else.
+func planSystem(remotePath string) string {
+	parts := strings.Split(remotePath, "/")
+	if parts[0] == romsBase && len(parts) > 2 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+// trackPattern matches the track suffix of split bin/cue images.
+var trackPattern = regexp.MustCompile(`(?i)\s*\(Track\s*\d+\)`)
+
+// gameKey groups the files of one game within a system so capacity fitting
+// never sends half of it: a subfolder is one game, and loose files sharing
+// a name once disc and track numbers are removed (discs, tracks, cue and
+// m3u) are one game.
+func gameKey(remotePath string) string {
+	parts := strings.Split(remotePath, "/")
+	rest := parts[1:]
+	if parts[0] == romsBase && len(parts) > 2 {
+		rest = parts[2:]
+	}
+	if len(rest) > 1 {
+		return rest[0] + "/"
+	}
+	name := path.Base(remotePath)
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	return strings.ToLower(multidisc.StripDiscPattern(trackPattern.ReplaceAllString(stem, "")))
+}
+
+// matchesRule reports whether pattern matches remotePath: a path.Match
+// pattern may name any single component ("nintendo_n64", "*(Beta)*") or
+// any leading part of the path ("roms/arcade_*").
+func matchesRule(pattern, remotePath string) bool {
+	parts := strings.Split(remotePath, "/")
+	for i, part := range parts {
+		if ok, _ := path.Match(pattern, part); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, strings.Join(parts[:i+1], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedByRules applies include and exclude rules: with any include
+// rules, a file must match one; a file matching an exclude rule is always
+// left out.
+func excludedByRules(remotePath string, include, exclude []string) bool {
+	if len(include) > 0 {
+		included := false
+		for _, p := range include {
+			if matchesRule(p, remotePath) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return true
+		}
+	}
+	for _, p := range exclude {
+		if matchesRule(p, remotePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// FitTransferPlan trims plan so the files it sends fit in budget bytes.
+// Skipped files are already on the destination and cost nothing. Systems
+// are taken whole in priority order (higher first, then by name; folders
+// outside roms/ such as bios come first). Systems that do not fit whole
+// are then filled game by game with whatever space is left. Everything
+// dropped is recorded in plan.Omitted.
+func FitTransferPlan(plan *TransferPlan, budget int64, priorities map[string]int) {
+	type game struct {
+		items []int
+		size  int64
+	}
+	type system struct {
+		name  string
+		rom   bool
+		size  int64
+		games []*game
+		byKey map[string]*game
+	}
+
+	keep := make([]bool, len(plan.Items))
+	bySystem := make(map[string]*system)
+	var order []*system
+	for i, item := range plan.Items {
+		if item.Skip {
+			keep[i] = true
+			continue
+		}
+		name := planSystem(item.RemotePath)
+		sys := bySystem[name]
+		if sys == nil {
+			sys = &system{
+				name:  name,
+				rom:   strings.HasPrefix(item.RemotePath, romsBase+"/"),
+				byKey: make(map[string]*game),
+			}
+			bySystem[name] = sys
+			order = append(order, sys)
+		}
+		key := gameKey(item.RemotePath)
+		g := sys.byKey[key]
+		if g == nil {
+			g = &game{}
+			sys.byKey[key] = g
+			sys.games = append(sys.games, g)
+		}
+		g.items = append(g.items, i)
+		g.size += item.Size
+		sys.size += item.Size
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].rom != order[j].rom {
+			return !order[i].rom
+		}
+		pi, pj := priorities[order[i].name], priorities[order[j].name]
+		if pi != pj {
+			return pi > pj
+		}
+		return order[i].name < order[j].name
+	})
+
+	remaining := budget
+	var partial []*system
+	for _, sys := range order {
+		if sys.size <= remaining {
+			for _, g := range sys.games {
+				for _, i := range g.items {
+					keep[i] = true
+				}
+			}
+			remaining -= sys.size
+			continue
+		}
+		partial = append(partial, sys)
+	}
+	for _, sys := range partial {
+		for _, g := range sys.games {
+			if g.size > remaining {
+				continue
+			}
+			for _, i := range g.items {
+				keep[i] = true
+			}
+			remaining -= g.size
+		}
+	}
+
+	items := plan.Items[:0:0]
+	plan.TotalSize = 0
+	for i, item := range plan.Items {
+		if keep[i] {
+			items = append(items, item)
+			if !item.Skip {
+				plan.TotalSize += item.Size
+			}
+			continue
+		}
+		plan.omit(item, OmitCapacity, "")
+	}
+	plan.Items = items
+}
+
+// parseDFAvailable reads the Available column (in 1K blocks) from the
+// POSIX output of `df -Pk`.
+func parseDFAvailable(out []byte) (int64, error) {
+	u, err := parseDF(out)
+	return u.Free, err
+}
+
+// parseDF reads the total, used and available columns of `df -Pk` output.
+func parseDF(out []byte) (DiskUsage, error) {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) < 2 {
+		return DiskUsage{}, fmt.Errorf("unexpected df output: %q", out)
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 6 {
+		return DiskUsage{}, fmt.Errorf("unexpected df output: %q", out)
+	}
+	var kb [3]int64
+	for i := range kb {
+		n, err := strconv.ParseInt(fields[1+i], 10, 64)
+		if err != nil {
+			return DiskUsage{}, fmt.Errorf("unexpected df output: %q", out)
+		}
+		kb[i] = n * 1024
+	}
+	return DiskUsage{Total: kb[0], Used: kb[1], Free: kb[2]}, nil
+}
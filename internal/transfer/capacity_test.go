@@ -0,0 +1,210 @@
+package transfer
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+func planOf(items ...TransferItem) *TransferPlan {
+	plan := &TransferPlan{Items: items}
+	for _, it := range items {
+		if it.Skip {
+			plan.SkipCount++
+		} else {
+			plan.TotalSize += it.Size
+		}
+	}
+	return plan
+}
+
+func remotePaths(items []TransferItem) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.RemotePath)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestFitTransferPlan(t *testing.T) {
+	plan := planOf(
+		TransferItem{RemotePath: "bios/scph1001.bin", Size: 1},
+		TransferItem{RemotePath: "roms/nintendo_snes/A.sfc", Size: 10},
+		TransferItem{RemotePath: "roms/nintendo_snes/B.sfc", Size: 10},
+		TransferItem{RemotePath: "roms/sony_psx/Game (Disc 1).chd", Size: 30},
+		TransferItem{RemotePath: "roms/sony_psx/Game (Disc 2).chd", Size: 30},
+		TransferItem{RemotePath: "roms/sony_psx/Game.m3u", Size: 1},
+		TransferItem{RemotePath: "roms/sony_psx/Small.chd", Size: 5},
+		TransferItem{RemotePath: "roms/sega_smd/Already.md", Size: 500, Skip: true},
+	)
+
+	// PSX has priority but does not fit whole; SNES fits whole, then PSX
+	// is filled game by game: the 61-byte multi-disc set does not fit,
+	// Small.chd does.
+	FitTransferPlan(plan, 40, map[string]int{"sony_psx": 10})
+
+	got := remotePaths(plan.Items)
+	want := []string{
+		"bios/scph1001.bin",
+		"roms/nintendo_snes/A.sfc",
+		"roms/nintendo_snes/B.sfc",
+		"roms/sega_smd/Already.md",
+		"roms/sony_psx/Small.chd",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("kept %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("kept[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if plan.TotalSize != 26 {
+		t.Errorf("TotalSize = %d, want 26", plan.TotalSize)
+	}
+	if len(plan.Omitted) != 3 || plan.OmittedSize != 61 {
+		t.Errorf("Omitted = %d files / %d bytes, want 3 / 61", len(plan.Omitted), plan.OmittedSize)
+	}
+	summary := plan.OmittedBySystem()
+	if len(summary) != 1 || summary[0].System != "sony_psx" || summary[0].Files != 3 || summary[0].Reason != OmitCapacity {
+		t.Errorf("OmittedBySystem = %+v", summary)
+	}
+}
+
+func TestFitTransferPlan_PriorityWins(t *testing.T) {
+	plan := planOf(
+		TransferItem{RemotePath: "roms/nintendo_snes/A.sfc", Size: 10},
+		TransferItem{RemotePath: "roms/sony_psx/B.chd", Size: 10},
+	)
+	FitTransferPlan(plan, 10, map[string]int{"sony_psx": 1})
+	if len(plan.Items) != 1 || plan.Items[0].RemotePath != "roms/sony_psx/B.chd" {
+		t.Errorf("kept %v, want only the higher-priority system", remotePaths(plan.Items))
+	}
+}
+
+func TestGameKey(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"roms/sony_psx/Game (Disc 1).chd", "roms/sony_psx/Game.m3u"},
+		{"roms/sega_cd/Sonic CD (Track 01).bin", "roms/sega_cd/Sonic CD.cue"},
+		{"roms/sony_psx/Multi/disc1.chd", "roms/sony_psx/Multi/disc2.chd"},
+	}
+	for _, tt := range tests {
+		if gameKey(tt.a) != gameKey(tt.b) {
+			t.Errorf("gameKey(%q) = %q, gameKey(%q) = %q; want equal", tt.a, gameKey(tt.a), tt.b, gameKey(tt.b))
+		}
+	}
+	if gameKey("roms/sony_psx/A.chd") == gameKey("roms/sony_psx/B.chd") {
+		t.Error("different games share a key")
+	}
+}
+
+func TestExcludedByRules(t *testing.T) {
+	tests := []struct {
+		path             string
+		include, exclude []string
+		want             bool
+	}{
+		{"roms/nintendo_n64/Game.z64", nil, []string{"nintendo_n64"}, true},
+		{"roms/nintendo_snes/Game (Beta).sfc", nil, []string{"*(Beta)*"}, true},
+		{"roms/arcade_mame/pacman.zip", nil, []string{"roms/arcade_*"}, true},
+		{"roms/nintendo_snes/Game.sfc", []string{"nintendo_snes"}, nil, false},
+		{"roms/sony_psx/Game.chd", []string{"nintendo_snes"}, nil, true},
+		{"roms/nintendo_snes/Game (Beta).sfc", []string{"nintendo_snes"}, []string{"*(Beta)*"}, true},
+	}
+	for _, tt := range tests {
+		if got := excludedByRules(tt.path, tt.include, tt.exclude); got != tt.want {
+			t.Errorf("excludedByRules(%q, %v, %v) = %v, want %v", tt.path, tt.include, tt.exclude, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTransferPlan_RulesAndBudget(t *testing.T) {
+	local := t.TempDir()
+	writeFile(t, filepath.Join(local, "nintendo_snes", "A.sfc"), "aaaa")
+	writeFile(t, filepath.Join(local, "nintendo_snes", "A (Beta).sfc"), "bbbb")
+	writeFile(t, filepath.Join(local, "sony_psx", "C.chd"), "cccccccc")
+
+	plan, err := BuildTransferPlanWithOptions(context.Background(), nil, local, "roms", PlanOptions{
+		Exclude: []string{"*(Beta)*"},
+		Budget:  5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := remotePaths(plan.Items); len(got) != 1 || got[0] != "roms/nintendo_snes/A.sfc" {
+		t.Errorf("Items = %v", got)
+	}
+	reasons := map[string]string{}
+	for _, o := range plan.Omitted {
+		reasons[o.RemotePath] = o.Reason
+	}
+	if reasons["roms/nintendo_snes/A (Beta).sfc"] != OmitExcluded || reasons["roms/sony_psx/C.chd"] != OmitCapacity {
+		t.Errorf("Omitted reasons = %v", reasons)
+	}
+}
+
+type fixedSpaceBackend struct {
+	TransferBackend
+	free int64
+}
+
+func (f fixedSpaceBackend) FreeSpace(context.Context) (int64, error) { return f.free, nil }
+
+func TestCapacityBudget(t *testing.T) {
+	ctx := context.Background()
+	backend := fixedSpaceBackend{free: 10e9}
+	mb := int64(1024 * 1024)
+
+	tests := []struct {
+		name    string
+		cc      config.CapacityConfig
+		want    int64
+		limited bool
+	}{
+		{"off", config.CapacityConfig{}, 0, false},
+		{"free space", config.CapacityConfig{FitToDevice: true}, 10e9, true},
+		{"reserve", config.CapacityConfig{FitToDevice: true, ReserveMB: 100}, 10e9 - 100*mb, true},
+		{"fixed smaller", config.CapacityConfig{FitToDevice: true, BudgetGB: 4}, 4e9, true},
+		{"fixed larger", config.CapacityConfig{FitToDevice: true, BudgetGB: 64}, 10e9, true},
+		{"fixed only", config.CapacityConfig{BudgetGB: 64}, 64e9, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, limited, err := CapacityBudget(ctx, backend, tt.cc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || limited != tt.limited {
+				t.Errorf("CapacityBudget = %d, %v; want %d, %v", got, limited, tt.want, tt.limited)
+			}
+		})
+	}
+}
+
+func TestParseDFAvailable(t *testing.T) {
+	out := []byte("Filesystem     1024-blocks     Used Available Capacity Mounted on\n/dev/mmcblk0p2    60000000 20000000  40000000      34% /\n")
+	got, err := parseDFAvailable(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 40000000*1024 {
+		t.Errorf("parseDFAvailable = %d", got)
+	}
+	if _, err := parseDFAvailable([]byte("df: /nope: No such file or directory\n")); err == nil {
+		t.Error("expected error for bad output")
+	}
+}
+
+func TestFreeSpace(t *testing.T) {
+	if free, err := NewUSBBackend(t.TempDir()).FreeSpace(context.Background()); err != nil || free <= 0 {
+		t.Errorf("USB FreeSpace = %d, %v", free, err)
+	}
+	backend := newTestSFTPBackend(t, t.TempDir())
+	if free, err := backend.FreeSpace(context.Background()); err != nil || free <= 0 {
+		t.Errorf("SFTP FreeSpace = %d, %v", free, err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package transfer
+
+import "os"
+
+// dropCache is a no-op on non-Linux platforms; reads there may be served
+// from the cache.
+func dropCache(_ *os.File) error {
+	return nil
+}
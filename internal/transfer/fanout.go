@@ -0,0 +1,139 @@
+package transfer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DeviceProgress is a TransferProgress from one device of a fan-out
+// transfer.
+type DeviceProgress struct {
+	Device string
+	TransferProgress
+}
+
+// DeviceResult summarizes one device's part of a fan-out transfer. For
+// bulk transfers Files counts folders.
+type DeviceResult struct {
+	Device   string
+	Files    int
+	Bytes    int64
+	Duration time.Duration
+	Err      error
+}
+
+// DeviceTransfer is one destination of ExecuteDevices: a connected
+// backend and the plan built for it.
+type DeviceTransfer struct {
+	Device    string
+	Backend   TransferBackend
+	Plan      *TransferPlan
+	Manifests Manifests // saved after the device finishes; may be nil
+}
+
+// BulkDeviceTransfer is one destination of TransferDevicesBulk.
+type BulkDeviceTransfer struct {
+	Device  string
+	Backend BulkTransferBackend
+	Folders []FolderMapping
+}
+
+// ExecuteDevices runs every device's plan concurrently, each with opts
+// (Manifests is taken from the target). A failing device does not stop the
+// others. Progress from all devices is sent on progressCh, which is closed
+// when every device has finished. Results are in target order.
+func ExecuteDevices(ctx context.Context, targets []DeviceTransfer, opts ExecuteOptions, progressCh chan<- DeviceProgress) []DeviceResult {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Device
+	}
+	results := fanOut(names, progressCh, func(i int, ch chan<- TransferProgress) error {
+		t := targets[i]
+		o := opts
+		o.Manifests = t.Manifests
+		err := ExecuteWithOptions(ctx, t.Backend, t.Plan, o, ch)
+		// Record what was sent even when the device failed part way.
+		if saveErr := t.Manifests.Save(context.Background(), t.Backend); err == nil {
+			err = saveErr
+		}
+		return err
+	})
+	for i, t := range targets {
+		if results[i].Err == nil {
+			results[i].Files = len(t.Plan.Items) - t.Plan.SkipCount
+			results[i].Bytes = t.Plan.TotalSize
+		}
+	}
+	return results
+}
+
+// TransferDevicesBulk runs a bulk (rsync) transfer to every device
+// concurrently, like ExecuteDevices.
+func TransferDevicesBulk(ctx context.Context, targets []BulkDeviceTransfer, progressCh chan<- DeviceProgress) []DeviceResult {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Device
+	}
+	results := fanOut(names, progressCh, func(i int, ch chan<- TransferProgress) error {
+		return targets[i].Backend.TransferFolders(ctx, targets[i].Folders, ch)
+	})
+	for i, t := range targets {
+		if results[i].Err == nil {
+			results[i].Files = len(t.Folders)
+		}
+	}
+	return results
+}
+
+// fanOut calls run for every device in its own goroutine with a private
+// progress channel, which run must close, and forwards that progress
+// tagged with the device name. Files and Bytes count completed files as
+// reported by progress; callers replace them with exact numbers on success.
+func fanOut(names []string, progressCh chan<- DeviceProgress, run func(i int, ch chan<- TransferProgress) error) []DeviceResult {
+	results := make([]DeviceResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			start := time.Now()
+
+			ch := make(chan TransferProgress, 100)
+			forwarded := make(chan struct{})
+			go func() {
+				defer close(forwarded)
+				for p := range ch {
+					if p.Done {
+						results[i].Files++
+						results[i].Bytes += p.FileSize
+					}
+					if progressCh == nil {
+						continue
+					}
+					// Byte progress may be dropped; events may not.
+					dp := DeviceProgress{Device: name, TransferProgress: p}
+					if p.isEvent() {
+						progressCh <- dp
+						continue
+					}
+					select {
+					case progressCh <- dp:
+					default:
+					}
+				}
+			}()
+
+			err := run(i, ch)
+			<-forwarded
+			results[i].Device = name
+			results[i].Err = err
+			results[i].Duration = time.Since(start)
+		}(i, name)
+	}
+	wg.Wait()
+	if progressCh != nil {
+		close(progressCh)
+	}
+	return results
+}
@@ -0,0 +1,112 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingBackend is a USBBackend whose uploads always fail.
+type failingBackend struct{ *USBBackend }
+
+func (f failingBackend) Upload(context.Context, string, string, func(int64)) error {
+	return errors.New("device offline")
+}
+
+func (f failingBackend) UploadSHA1(context.Context, string, string, func(int64)) (string, error) {
+	return "", errors.New("device offline")
+}
+
+func TestExecuteDevices(t *testing.T) {
+	local := t.TempDir()
+	writeFile(t, filepath.Join(local, "roms", "snes", "A.sfc"), "aaaa")
+	writeFile(t, filepath.Join(local, "roms", "gba", "B.gba"), "bbbbbb")
+
+	ctx := context.Background()
+	mounts := []string{t.TempDir(), t.TempDir(), t.TempDir()}
+	backends := []TransferBackend{NewUSBBackend(mounts[0]), NewUSBBackend(mounts[1]), failingBackend{NewUSBBackend(mounts[2])}}
+	systems := [][]string{nil, {"gba"}, nil}
+	names := []string{"crt", "handheld", "bartop"}
+
+	var targets []DeviceTransfer
+	for i, b := range backends {
+		sp, err := PlanSync(ctx, b, SyncOptions{LocalRoot: local, Folders: []string{"roms"}, Systems: systems[i]})
+		if err != nil {
+			t.Fatalf("PlanSync %s: %v", names[i], err)
+		}
+		targets = append(targets, DeviceTransfer{Device: names[i], Backend: b, Plan: sp.Plan})
+	}
+
+	progressCh := make(chan DeviceProgress, 100)
+	seen := make(map[string]bool)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range progressCh {
+			seen[p.Device] = true
+		}
+	}()
+	results := ExecuteDevices(ctx, targets, ExecuteOptions{Concurrency: 1}, progressCh)
+	<-done
+
+	if len(results) != 3 {
+		t.Fatalf("results = %d, want 3", len(results))
+	}
+	if r := results[0]; r.Device != "crt" || r.Err != nil || r.Files != 2 || r.Bytes != 10 {
+		t.Errorf("crt = %+v, want 2 files, 10 bytes", r)
+	}
+	if r := results[1]; r.Err != nil || r.Files != 1 {
+		t.Errorf("handheld = %+v, want 1 file", r)
+	}
+	if _, err := os.Stat(filepath.Join(mounts[1], "roms", "snes", "A.sfc")); !os.IsNotExist(err) {
+		t.Error("handheld got a system it does not select")
+	}
+	if r := results[2]; r.Device != "bartop" || r.Err == nil {
+		t.Errorf("bartop = %+v, want an error", r)
+	}
+	if !seen["crt"] || !seen["handheld"] {
+		t.Errorf("progress seen from %v", seen)
+	}
+}
+
+func TestBuildTransferPlan_SystemsKeepsUnderscoreFolders(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(local, "snes", "A.sfc"), "a")
+	writeFile(t, filepath.Join(local, "gba", "B.gba"), "b")
+	writeFile(t, filepath.Join(local, "_favorites", "B.gba"), "b")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "Old.sfc"), "old")
+	writeFile(t, filepath.Join(mount, "roms", "gba", "Old.gba"), "old")
+
+	plan, err := BuildTransferPlanWithOptions(context.Background(), NewUSBBackend(mount), local, "roms",
+		PlanOptions{Systems: []string{"gba"}, Mirror: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := remotePaths(plan.Items)
+	if len(got) != 2 || got[0] != "roms/_favorites/B.gba" || got[1] != "roms/gba/B.gba" {
+		t.Errorf("Items = %v", got)
+	}
+	// Unselected systems are never mirrored away.
+	if len(plan.Deletions) != 1 || plan.Deletions[0].Path != "roms/gba/Old.gba" {
+		t.Errorf("Deletions = %+v, want only roms/gba/Old.gba", plan.Deletions)
+	}
+}
+
+func TestDeviceFolderMappings(t *testing.T) {
+	m := DeviceFolderMappings("/lib", "/media/sd", []string{"roms", "bios"}, []string{"gba"})
+	if len(m) != 2 {
+		t.Fatalf("mappings = %+v", m)
+	}
+	if m[0].LocalDir != filepath.Join("/lib", "roms") || m[0].RemoteDir != "/media/sd/roms" {
+		t.Errorf("roms mapping = %+v", m[0])
+	}
+	if len(m[0].Only) != 2 || m[0].Only[0] != "gba" || m[0].Only[1] != "_*" {
+		t.Errorf("roms Only = %v", m[0].Only)
+	}
+	if m[1].Only != nil {
+		t.Errorf("bios Only = %v, want nil", m[1].Only)
+	}
+}
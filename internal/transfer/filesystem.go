@@ -0,0 +1,217 @@
+package transfer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/converter"
+)
+
+// FSInfo describes the limits of a destination filesystem.
+type FSInfo struct {
+	Type string // "vfat", "exfat", ... or "" when unknown
+
+	// MaxFileSize is the largest file the filesystem can hold; 0 means no
+	// limit worth checking.
+	MaxFileSize int64
+	// RestrictedNames is set for FAT and exFAT, which reject
+	// `"*/:<>?\|`, control characters and trailing dots or spaces.
+	RestrictedNames bool
+}
+
+// fat32MaxFileSize is the FAT32 file size limit (4 GiB - 1).
+const fat32MaxFileSize = 1<<32 - 1
+
+// FilesystemDetector is implemented by backends that can tell which
+// filesystem the destination uses.
+type FilesystemDetector interface {
+	Filesystem() (FSInfo, error)
+}
+
+// FSInfoForType returns the limits for a filesystem type as reported by
+// the OS ("vfat", "msdos", "exfat", ...) or set by the user ("fat32").
+// Unknown types have no limits.
+func FSInfoForType(fsType string) FSInfo {
+	switch strings.ToLower(fsType) {
+	case "vfat", "msdos", "fat", "fat32":
+		return FSInfo{Type: "vfat", MaxFileSize: fat32MaxFileSize, RestrictedNames: true}
+	case "exfat":
+		return FSInfo{Type: "exfat", RestrictedNames: true}
+	}
+	return FSInfo{Type: fsType}
+}
+
+// Restricted reports whether plans for this filesystem need a preflight.
+func (fs FSInfo) Restricted() bool {
+	return fs.MaxFileSize > 0 || fs.RestrictedNames
+}
+
+// Preflight omission reasons, alongside OmitExcluded and OmitCapacity.
+const (
+	OmitTooLarge     = "too large for filesystem"
+	OmitNameConflict = "name conflict after renaming"
+)
+
+// RenamedItem records a destination path changed to suit the filesystem.
+type RenamedItem struct {
+	From, To string
+}
+
+// fatReplacer maps characters FAT and exFAT reject to look-alike safe ones.
+var fatReplacer = strings.NewReplacer(
+	":", " -",
+	"?", "",
+	"*", "-",
+	`"`, "'",
+	"<", "(",
+	">", ")",
+	"|", "-",
+	`\`, "-",
+)
+
+// SanitizeName makes one path component valid on FAT and exFAT: illegal
+// characters are mapped to safe equivalents, control characters dropped
+// and trailing dots and spaces trimmed.
+func SanitizeName(name string) string {
+	s := fatReplacer.Replace(name)
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.TrimRight(s, ". ")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// sanitizePath applies SanitizeName to every component of a slash path.
+func sanitizePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = SanitizeName(part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// oversizeHint suggests what to do with a file over the size limit.
+func oversizeHint(localPath string) string {
+	ext := strings.ToLower(filepath.Ext(localPath))
+	if converter.IsConvertible(localPath) || ext == ".bin" || ext == ".img" {
+		return "convert to CHD to shrink it below 4 GB"
+	}
+	return "use an exFAT or ext4 formatted drive"
+}
+
+// cueFileLine matches a cue sheet FILE line, quoted or not.
+var cueFileLine = regexp.MustCompile(`(?i)^(\s*FILE\s+)(?:"([^"]+)"|(\S+))(.*)$`)
+
+// rewriteReferences returns data with every file reference in an .m3u
+// playlist or .cue sheet sanitized, and whether anything changed. Line
+// endings are preserved.
+func rewriteReferences(data []byte, ext string) ([]byte, bool) {
+	lines := strings.Split(string(data), "\n")
+	changed := false
+	for i, line := range lines {
+		body := strings.TrimSuffix(line, "\r")
+		switch ext {
+		case ".m3u":
+			ref := strings.TrimSpace(body)
+			if ref == "" || strings.HasPrefix(ref, "#") {
+				continue
+			}
+			if s := sanitizePath(ref); s != ref {
+				lines[i] = strings.Replace(line, ref, s, 1)
+				changed = true
+			}
+		case ".cue":
+			m := cueFileLine.FindStringSubmatch(body)
+			if m == nil {
+				continue
+			}
+			ref, quoted := m[2], true
+			if ref == "" {
+				ref, quoted = m[3], false
+			}
+			s := sanitizePath(ref)
+			if s == ref {
+				continue
+			}
+			if quoted || strings.Contains(s, " ") {
+				s = `"` + s + `"`
+			}
+			lines[i] = m[1] + s + m[4] + line[len(body):]
+			changed = true
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), changed
+}
+
+// preflightItem adapts item to fs. It returns the reason to omit the item,
+// if any, with a hint for the user. Playlists and cue sheets whose
+// references change are rewritten into plan's temporary directory.
+func preflightItem(plan *TransferPlan, fs FSInfo, item *TransferItem, seen map[string]string) (reason, hint string, err error) {
+	if fs.MaxFileSize > 0 && item.Size > fs.MaxFileSize {
+		return OmitTooLarge, oversizeHint(item.LocalPath), nil
+	}
+	if !fs.RestrictedNames {
+		return "", "", nil
+	}
+
+	safe := sanitizePath(item.RemotePath)
+	key := strings.ToLower(safe) // FAT names are case-insensitive
+	if other, ok := seen[key]; ok && other != item.LocalPath {
+		return OmitNameConflict, "same name as " + filepath.Base(other), nil
+	}
+	seen[key] = item.LocalPath
+	if safe != item.RemotePath {
+		plan.Renamed = append(plan.Renamed, RenamedItem{From: item.RemotePath, To: safe})
+		item.RemotePath = safe
+	}
+
+	ext := strings.ToLower(path.Ext(item.RemotePath))
+	if ext != ".m3u" && ext != ".cue" {
+		return "", "", nil
+	}
+	data, err := os.ReadFile(item.LocalPath)
+	if err != nil {
+		return "", "", err
+	}
+	rewritten, changed := rewriteReferences(data, ext)
+	if !changed {
+		return "", "", nil
+	}
+	if plan.tempDir == "" {
+		if plan.tempDir, err = os.MkdirTemp("", "romwrangler-preflight-"); err != nil {
+			return "", "", err
+		}
+	}
+	tmp := filepath.Join(plan.tempDir, fmt.Sprintf("%d%s", len(plan.Rewritten), ext))
+	if err := os.WriteFile(tmp, rewritten, 0644); err != nil {
+		return "", "", err
+	}
+	plan.Rewritten = append(plan.Rewritten, item.RemotePath)
+	item.LocalPath = tmp
+	item.Size = int64(len(rewritten))
+	return "", "", nil
+}
+
+// Cleanup removes temporary files created while building the plan, such
+// as rewritten playlists. Call it once the plan has been executed.
+func (p *TransferPlan) Cleanup() {
+	if p.tempDir != "" {
+		os.RemoveAll(p.tempDir)
+	}
+	for _, dir := range p.tempDirs {
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,44 @@
+package transfer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// filesystemType returns the type of the filesystem mounted at or above
+// path, from the longest matching mount point in /proc/self/mounts.
+func filesystemType(path string) (string, error) {
+	data, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	return mountFSType(string(data), abs), nil
+}
+
+// mountFSType finds the filesystem type for path in /proc/mounts content.
+func mountFSType(mounts, path string) string {
+	best, bestType := "", ""
+	for _, line := range strings.Split(mounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		// Spaces in mount points are escaped as \040.
+		mnt := strings.ReplaceAll(fields[1], `\040`, " ")
+		if path != mnt && !strings.HasPrefix(path, strings.TrimSuffix(mnt, "/")+"/") {
+			continue
+		}
+		if len(mnt) >= len(best) {
+			best, bestType = mnt, fields[2]
+		}
+	}
+	return bestType
+}
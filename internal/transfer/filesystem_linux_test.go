@@ -0,0 +1,22 @@
+package transfer
+
+import "testing"
+
+func TestMountFSType(t *testing.T) {
+	mounts := `/dev/root / ext4 rw,relatime 0 0
+/dev/sda1 /run/media/user/REPLAY vfat rw,nosuid 0 0
+/dev/sdb1 /run/media/user/My\040Stick exfat rw 0 0
+`
+	tests := []struct{ path, want string }{
+		{"/run/media/user/REPLAY", "vfat"},
+		{"/run/media/user/REPLAY/roms", "vfat"},
+		{"/run/media/user/REPLAYX", "ext4"},
+		{"/run/media/user/My Stick", "exfat"},
+		{"/home/user", "ext4"},
+	}
+	for _, tt := range tests {
+		if got := mountFSType(mounts, tt.path); got != tt.want {
+			t.Errorf("mountFSType(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package transfer
+
+// filesystemType is not detected on this platform; set
+// transfer.usb_filesystem in the config instead.
+func filesystemType(_ string) (string, error) {
+	return "", nil
+}
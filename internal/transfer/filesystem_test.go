@@ -0,0 +1,125 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Zelda: Link's Awakening.gb", "Zelda - Link's Awakening.gb"},
+		{"Who Wants to Be a Millionaire?.chd", "Who Wants to Be a Millionaire.chd"},
+		{"Game v1.0.", "Game v1.0"},
+		{"Trailing ...  ", "Trailing"},
+		{`A<B>C|D*E"F\G.bin`, "A(B)C-D-E'F-G.bin"},
+		{"Plain Name (USA).sfc", "Plain Name (USA).sfc"},
+		{"...", "_"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeName(tt.in); got != tt.want {
+			t.Errorf("SanitizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteReferences(t *testing.T) {
+	m3u := "#EXTM3U\nGame: Part (Disc 1).chd\r\nGame: Part (Disc 2).chd\n"
+	got, changed := rewriteReferences([]byte(m3u), ".m3u")
+	want := "#EXTM3U\nGame - Part (Disc 1).chd\r\nGame - Part (Disc 2).chd\n"
+	if !changed || string(got) != want {
+		t.Errorf("m3u rewrite = %q, %v; want %q", got, changed, want)
+	}
+
+	cue := "FILE \"What? (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\nFILE plain.bin BINARY\n"
+	got, changed = rewriteReferences([]byte(cue), ".cue")
+	want = "FILE \"What (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\nFILE plain.bin BINARY\n"
+	if !changed || string(got) != want {
+		t.Errorf("cue rewrite = %q, %v; want %q", got, changed, want)
+	}
+
+	if _, changed := rewriteReferences([]byte("FILE \"ok.bin\" BINARY\n"), ".cue"); changed {
+		t.Error("clean cue reported as changed")
+	}
+}
+
+func TestFSInfoForType(t *testing.T) {
+	if fs := FSInfoForType("vfat"); fs.MaxFileSize != fat32MaxFileSize || !fs.RestrictedNames {
+		t.Errorf("vfat = %+v", fs)
+	}
+	if fs := FSInfoForType("exfat"); fs.MaxFileSize != 0 || !fs.RestrictedNames {
+		t.Errorf("exfat = %+v", fs)
+	}
+	if fs := FSInfoForType("ext4"); fs.Restricted() {
+		t.Errorf("ext4 = %+v, want unrestricted", fs)
+	}
+}
+
+func TestBuildTransferPlan_FATPreflight(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	psx := filepath.Join(local, "sony_psx")
+	writeFile(t, filepath.Join(psx, "Game: Part (Disc 1).chd"), "disc1")
+	writeFile(t, filepath.Join(psx, "Game: Part (Disc 2).chd"), "disc2")
+	writeFile(t, filepath.Join(psx, "Game: Part.m3u"), "Game: Part (Disc 1).chd\nGame: Part (Disc 2).chd\n")
+	writeFile(t, filepath.Join(psx, "Dupe?.chd"), "a")
+	writeFile(t, filepath.Join(psx, "Dupe.chd"), "b")
+
+	big := filepath.Join(psx, "Huge.iso")
+	f, err := os.Create(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(fat32MaxFileSize + 1); err != nil {
+		f.Close()
+		t.Skipf("cannot create sparse file: %v", err)
+	}
+	f.Close()
+
+	backend := NewUSBBackend(mount)
+	ctx := context.Background()
+	plan, err := BuildTransferPlanWithOptions(ctx, backend, local, "roms", PlanOptions{Filesystem: FSInfoForType("vfat")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plan.Cleanup()
+
+	reasons := map[string]string{}
+	for _, o := range plan.Omitted {
+		reasons[filepath.Base(o.LocalPath)] = o.Reason
+		if o.Reason == OmitTooLarge && o.Hint == "" {
+			t.Error("oversize file has no hint")
+		}
+	}
+	if reasons["Huge.iso"] != OmitTooLarge {
+		t.Errorf("Huge.iso reason = %q, want %q", reasons["Huge.iso"], OmitTooLarge)
+	}
+	// Walk order is lexical: "Dupe.chd" sorts before "Dupe?.chd".
+	if reasons["Dupe?.chd"] != OmitNameConflict {
+		t.Errorf("Dupe?.chd reason = %q, want %q", reasons["Dupe?.chd"], OmitNameConflict)
+	}
+	if len(plan.Renamed) != 3 || len(plan.Rewritten) != 1 {
+		t.Errorf("Renamed = %d, Rewritten = %d; want 3, 1", len(plan.Renamed), len(plan.Rewritten))
+	}
+
+	if err := Execute(ctx, backend, plan, 1, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(mount, "roms", "sony_psx", "Game - Part.m3u"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Game - Part (Disc 1).chd\nGame - Part (Disc 2).chd\n"; string(data) != want {
+		t.Errorf("m3u on device = %q, want %q", data, want)
+	}
+	if _, err := os.Stat(filepath.Join(mount, "roms", "sony_psx", "Game - Part (Disc 2).chd")); err != nil {
+		t.Errorf("renamed disc missing: %v", err)
+	}
+
+	tmp := plan.tempDir
+	plan.Cleanup()
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("Cleanup left %s behind", tmp)
+	}
+}
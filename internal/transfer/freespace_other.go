@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd
+
+package transfer
+
+import "fmt"
+
+// freeSpace is not implemented on this platform; set a capacity budget by
+// hand instead.
+func freeSpace(_ string) (int64, error) {
+	return 0, fmt.Errorf("free space detection is not supported on this platform")
+}
+
+func diskUsage(_ string) (DiskUsage, error) {
+	return DiskUsage{}, fmt.Errorf("disk usage is not supported on this platform")
+}
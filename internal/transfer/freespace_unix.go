@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+
+package transfer
+
+import "syscall"
+
+// freeSpace returns the bytes available to unprivileged users on the
+// filesystem holding path.
+func freeSpace(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+// diskUsage returns the size of the filesystem holding path, the space in
+// use and the space available to unprivileged users.
+func diskUsage(path string) (DiskUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return DiskUsage{}, err
+	}
+	bs := int64(st.Bsize)
+	return DiskUsage{
+		Total: int64(st.Blocks) * bs,
+		Used:  int64(st.Blocks-st.Bfree) * bs,
+		Free:  int64(st.Bavail) * bs,
+	}, nil
+}
@@ -0,0 +1,262 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+	"github.com/kurlmarx/romwrangler/internal/systems"
+)
+
+// romsBase is the device folder that holds the ReplayOS system folders.
+const romsBase = "roms"
+
+// InventoryEntry is one file in an inventory diff. Path is relative to the
+// system folder, e.g. "Disc Games/Game (Disc 1).chd".
+type InventoryEntry struct {
+	Path       string
+	LocalPath  string
+	LocalSize  int64
+	RemoteSize int64
+	Reason     string // for Different: "size" or "hash"
+}
+
+// SystemInventory compares one ReplayOS system folder.
+type SystemInventory struct {
+	Folder     string
+	OnlyLocal  []InventoryEntry
+	OnlyDevice []InventoryEntry
+	Different  []InventoryEntry
+	Same       int
+}
+
+// InventoryDiff compares the local library with the device, per system
+// folder. Systems are sorted by folder name.
+type InventoryDiff struct {
+	Systems []SystemInventory
+	Hashed  bool // same-size files were compared by SHA1
+}
+
+// DiffOptions controls DiffInventory.
+type DiffOptions struct {
+	// Hash compares files of equal size by SHA1. The backend must implement
+	// RemoteHasher. Without it, equal size counts as identical.
+	Hash bool
+	// ProgressFn is called before each file is hashed.
+	ProgressFn func(current, total int, filename string)
+}
+
+// DiffInventory lists the device's roms folder and compares it with the
+// scanned library. Local files map to roms/<ReplayOS folder>/<path within
+// their system directory>. Only files with a valid extension for the
+// folder's system are compared on either side, and the _favorites,
+// _recent, _autostart and _extra folders are ignored. The backend must
+// implement Lister.
+func DiffInventory(ctx context.Context, backend TransferBackend, scan *organizer.ScanResult, romDirs []string, opts DiffOptions) (*InventoryDiff, error) {
+	lister, ok := backend.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot list device files")
+	}
+	var hasher RemoteHasher
+	if opts.Hash {
+		if hasher, ok = backend.(RemoteHasher); !ok {
+			return nil, fmt.Errorf("transfer method does not support checksums")
+		}
+	}
+
+	// folder -> path within folder -> entry
+	local := make(map[string]map[string]InventoryEntry)
+	for _, f := range scan.Files {
+		folder, ok := systems.FolderForSystem(f.System)
+		if !ok {
+			continue
+		}
+		inner, ok := pathInSystemDir(f.Path, romDirs)
+		if !ok {
+			continue
+		}
+		size, err := fileSize(f.Path)
+		if err != nil {
+			continue
+		}
+		if local[folder] == nil {
+			local[folder] = make(map[string]InventoryEntry)
+		}
+		local[folder][inner] = InventoryEntry{Path: inner, LocalPath: f.Path, LocalSize: size}
+	}
+
+	files, err := lister.ListFiles(ctx, romsBase)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", romsBase, err)
+	}
+	remote := make(map[string]map[string]int64)
+	for _, rf := range files {
+		rel := strings.TrimPrefix(rf.Path, romsBase+"/")
+		folder, inner, ok := strings.Cut(rel, "/")
+		if !ok || isMirrorProtected(rel) || !validInFolder(folder, inner) {
+			continue
+		}
+		if remote[folder] == nil {
+			remote[folder] = make(map[string]int64)
+		}
+		remote[folder][inner] = rf.Size
+	}
+
+	folders := make(map[string]bool)
+	for f := range local {
+		folders[f] = true
+	}
+	for f := range remote {
+		folders[f] = true
+	}
+
+	diff := &InventoryDiff{Hashed: opts.Hash}
+	for folder := range folders {
+		sys := SystemInventory{Folder: folder}
+		for inner, e := range local[folder] {
+			remoteSize, onDevice := remote[folder][inner]
+			switch {
+			case !onDevice:
+				sys.OnlyLocal = append(sys.OnlyLocal, e)
+			case remoteSize != e.LocalSize:
+				e.RemoteSize = remoteSize
+				e.Reason = "size"
+				sys.Different = append(sys.Different, e)
+			default:
+				e.RemoteSize = remoteSize
+				sys.Same++
+				if hasher != nil {
+					// Counted as same until hashing proves otherwise; finish
+					// drops it from Different again if the SHA1s match.
+					e.Reason = "unhashed"
+					sys.Different = append(sys.Different, e)
+				}
+			}
+		}
+		for inner, size := range remote[folder] {
+			if _, ok := local[folder][inner]; !ok {
+				sys.OnlyDevice = append(sys.OnlyDevice, InventoryEntry{Path: inner, RemoteSize: size})
+			}
+		}
+		diff.Systems = append(diff.Systems, sys)
+	}
+	sort.Slice(diff.Systems, func(i, j int) bool { return diff.Systems[i].Folder < diff.Systems[j].Folder })
+
+	if hasher != nil {
+		if err := hashSameSize(ctx, hasher, diff, opts.ProgressFn); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range diff.Systems {
+		diff.Systems[i].finish()
+	}
+	return diff, nil
+}
+
+// finish drops entries that hashed identical from Different, corrects the
+// Same count and sorts every list by path.
+func (s *SystemInventory) finish() {
+	kept := s.Different[:0]
+	for _, e := range s.Different {
+		if e.Reason == "" {
+			continue
+		}
+		if e.Reason == "hash" {
+			s.Same--
+		}
+		kept = append(kept, e)
+	}
+	s.Different = kept
+	for _, list := range [][]InventoryEntry{s.OnlyLocal, s.OnlyDevice, s.Different} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	}
+}
+
+// Changes is the number of files that are not identical on both sides.
+func (s SystemInventory) Changes() int {
+	return len(s.OnlyLocal) + len(s.OnlyDevice) + len(s.Different)
+}
+
+// hashSameSize checks the "unhashed" placeholders in each system's
+// Different list, clearing Reason when the SHA1s match and setting it to
+// "hash" when they don't.
+func hashSameSize(ctx context.Context, hasher RemoteHasher, diff *InventoryDiff, progressFn func(current, total int, filename string)) error {
+	total := 0
+	for _, sys := range diff.Systems {
+		for _, e := range sys.Different {
+			if e.Reason == "unhashed" {
+				total++
+			}
+		}
+	}
+
+	n := 0
+	for i := range diff.Systems {
+		sys := &diff.Systems[i]
+		for j := range sys.Different {
+			e := &sys.Different[j]
+			if e.Reason != "unhashed" {
+				continue
+			}
+			n++
+			if progressFn != nil {
+				progressFn(n, total, path.Base(e.Path))
+			}
+			item := TransferItem{LocalPath: e.LocalPath, RemotePath: path.Join(romsBase, sys.Folder, e.Path)}
+			err := verifyItem(ctx, hasher, item, "")
+			switch {
+			case err == nil:
+				e.Reason = ""
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case errors.Is(err, ErrChecksumMismatch):
+				e.Reason = "hash"
+			default:
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pathInSystemDir returns p relative to its system directory (the first
+// component below whichever romDir contains it), in slash form.
+func pathInSystemDir(p string, romDirs []string) (string, bool) {
+	for _, dir := range romDirs {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		_, inner, ok := strings.Cut(filepath.ToSlash(rel), "/")
+		return inner, ok
+	}
+	return "", false
+}
+
+// validInFolder reports whether a device file belongs in a ReplayOS system
+// folder: the folder must be known and the extension valid for one of the
+// systems stored there.
+func validInFolder(folder, inner string) bool {
+	ext := strings.ToLower(path.Ext(inner))
+	for id, f := range systems.ReplayOSFolders {
+		if f == folder && systems.IsValidFormat(id, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func fileSize(p string) (int64, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,79 @@
+package transfer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+)
+
+func TestDiffInventory(t *testing.T) {
+	romDir := t.TempDir()
+	mount := t.TempDir()
+
+	snes := filepath.Join(romDir, "nintendo_snes")
+	writeFile(t, filepath.Join(snes, "OnlyLocal.sfc"), "local")
+	writeFile(t, filepath.Join(snes, "Same.sfc"), "same")
+	writeFile(t, filepath.Join(snes, "Bigger.sfc"), "bigger")
+	writeFile(t, filepath.Join(snes, "Hacked.sfc"), "clean")
+
+	dev := filepath.Join(mount, "roms", "nintendo_snes")
+	writeFile(t, filepath.Join(dev, "Same.sfc"), "same")
+	writeFile(t, filepath.Join(dev, "Bigger.sfc"), "big")
+	writeFile(t, filepath.Join(dev, "Hacked.sfc"), "dirty")
+	writeFile(t, filepath.Join(dev, "OnlyDevice.sfc"), "dev")
+	writeFile(t, filepath.Join(dev, "notes.txt"), "not a rom")
+	writeFile(t, filepath.Join(dev, "_extra", "Manual.sfc"), "extra")
+	writeFile(t, filepath.Join(mount, "roms", "_favorites", "Same.sfc"), "fav")
+	writeFile(t, filepath.Join(mount, "roms", "nintendo_n64", "Game.z64"), "n64")
+
+	scan := organizer.Scan([]string{romDir}, nil)
+	backend := NewUSBBackend(mount)
+
+	t.Run("size only", func(t *testing.T) {
+		diff, err := DiffInventory(context.Background(), backend, scan, []string{romDir}, DiffOptions{})
+		if err != nil {
+			t.Fatalf("DiffInventory: %v", err)
+		}
+		if len(diff.Systems) != 2 {
+			t.Fatalf("got %d systems, want 2: %+v", len(diff.Systems), diff.Systems)
+		}
+		n64, snes := diff.Systems[0], diff.Systems[1]
+		if n64.Folder != "nintendo_n64" || len(n64.OnlyDevice) != 1 {
+			t.Errorf("n64 = %+v", n64)
+		}
+		if snes.Folder != "nintendo_snes" {
+			t.Fatalf("second system = %s", snes.Folder)
+		}
+		if len(snes.OnlyLocal) != 1 || snes.OnlyLocal[0].Path != "OnlyLocal.sfc" {
+			t.Errorf("OnlyLocal = %+v", snes.OnlyLocal)
+		}
+		if len(snes.OnlyDevice) != 1 || snes.OnlyDevice[0].Path != "OnlyDevice.sfc" {
+			t.Errorf("OnlyDevice = %+v", snes.OnlyDevice)
+		}
+		if len(snes.Different) != 1 || snes.Different[0].Path != "Bigger.sfc" || snes.Different[0].Reason != "size" {
+			t.Errorf("Different = %+v", snes.Different)
+		}
+		if snes.Same != 2 {
+			t.Errorf("Same = %d, want 2", snes.Same)
+		}
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		diff, err := DiffInventory(context.Background(), backend, scan, []string{romDir}, DiffOptions{Hash: true})
+		if err != nil {
+			t.Fatalf("DiffInventory: %v", err)
+		}
+		snes := diff.Systems[1]
+		if len(snes.Different) != 2 {
+			t.Fatalf("Different = %+v, want Bigger and Hacked", snes.Different)
+		}
+		if snes.Different[1].Path != "Hacked.sfc" || snes.Different[1].Reason != "hash" {
+			t.Errorf("Different[1] = %+v, want Hacked.sfc by hash", snes.Different[1])
+		}
+		if snes.Same != 1 || snes.Changes() != 4 {
+			t.Errorf("Same = %d, Changes = %d; want 1, 4", snes.Same, snes.Changes())
+		}
+	})
+}
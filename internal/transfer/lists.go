@@ -0,0 +1,199 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/converter"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+)
+
+// ReplayOS list folders under roms/. Each holds copies of the games in the
+// list, by file name.
+const (
+	FavoritesFolder = "_favorites"
+	AutostartFolder = "_autostart"
+)
+
+// OmitNotInLibrary marks a list entry whose game is not in the library.
+const OmitNotInLibrary = "not in library"
+
+// AddListItems adds the games curated in lists to plan, copying each from
+// localRoms/<entry> (with its .cue or .gdi tracks) into roms/_favorites or
+// roms/_autostart. Entries missing from the library are listed in Omitted.
+// When the backend implements Lister, favorites an earlier transfer sent
+// (per opts.Manifest) that are no longer curated, nor in the library's own
+// roms/_favorites, and any other file in roms/_autostart are listed in
+// plan.Replaced. Favorites marked on the device are never removed, and
+// either folder is left alone while its list is empty.
+func AddListItems(ctx context.Context, backend TransferBackend, plan *TransferPlan, localRoms string, lists config.ListsConfig, opts PlanOptions) error {
+	have := make(map[string]bool, len(plan.Items))
+	for _, item := range plan.Items {
+		have[item.RemotePath] = true
+	}
+	seen := make(map[string]string)
+
+	add := func(folder, entry string) (map[string]bool, error) {
+		files, err := listEntryFiles(localRoms, entry)
+		remoteDir := path.Join(romsBase, folder)
+		if err != nil {
+			plan.omit(TransferItem{LocalPath: filepath.Join(localRoms, filepath.FromSlash(entry)), RemotePath: path.Join(remoteDir, path.Base(entry))}, OmitNotInLibrary, "remove it from the device's lists in config.yaml")
+			return nil, nil
+		}
+		sent := make(map[string]bool)
+		for _, f := range files {
+			info, err := os.Stat(f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", entry, err)
+			}
+			item := TransferItem{
+				LocalPath:  f,
+				RemotePath: path.Join(remoteDir, filepath.Base(f)),
+				Size:       info.Size(),
+				ModTime:    info.ModTime(),
+			}
+			sent[item.RemotePath] = true
+			if have[item.RemotePath] {
+				continue
+			}
+			have[item.RemotePath] = true
+			if err := plan.add(backend, item, opts, seen); err != nil {
+				return nil, err
+			}
+		}
+		return sent, nil
+	}
+
+	for _, entry := range lists.Favorites {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := add(FavoritesFolder, entry); err != nil {
+			return err
+		}
+	}
+	if len(lists.Favorites) > 0 && opts.Manifest != nil {
+		// Games dropped from the list leave _favorites. Anything the plan
+		// sends there or left out of it still belongs, under the name the
+		// destination filesystem gives it, and only files the manifest
+		// says were sent are ours to remove.
+		keep := make(map[string]bool, len(plan.Items)+len(plan.Omitted))
+		for _, item := range plan.Items {
+			keep[item.RemotePath] = true
+		}
+		for _, o := range plan.Omitted {
+			keep[o.RemotePath] = true
+			keep[sanitizePath(o.RemotePath)] = true
+		}
+		stale := func(p string) bool { return !keep[p] && opts.Manifest.Sent(p) }
+		if err := replaceStale(ctx, backend, plan, FavoritesFolder, stale); err != nil {
+			return err
+		}
+	}
+
+	if lists.Autostart == "" {
+		return nil
+	}
+	sent, err := add(AutostartFolder, lists.Autostart)
+	if err != nil || sent == nil {
+		return err
+	}
+	// ReplayOS boots the game in _autostart, so the curated one replaces
+	// whatever is there.
+	return replaceStale(ctx, backend, plan, AutostartFolder, func(p string) bool { return !sent[p] })
+}
+
+// replaceStale lists roms/<folder> on the device, when the backend
+// implements Lister, and adds every file stale reports to plan.Replaced.
+func replaceStale(ctx context.Context, backend TransferBackend, plan *TransferPlan, folder string, stale func(path string) bool) error {
+	lister, ok := backend.(Lister)
+	if !ok {
+		return nil
+	}
+	existing, err := lister.ListFiles(ctx, path.Join(romsBase, folder))
+	if err != nil {
+		return fmt.Errorf("list %s: %w", folder, err)
+	}
+	for _, f := range existing {
+		if stale(f.Path) {
+			plan.Replaced = append(plan.Replaced, f)
+		}
+	}
+	return nil
+}
+
+// LibraryGames returns the scanned games under romDir as sorted paths
+// relative to it, e.g. "nintendo_snes/Super Metroid (USA).sfc". Track files
+// of a cue sheet or GDI are left out; they follow their sheet.
+func LibraryGames(scan *organizer.ScanResult, romDir string) []string {
+	tracks := make(map[string]bool)
+	for _, f := range scan.Files {
+		ext := strings.ToLower(filepath.Ext(f.Path))
+		if ext != ".cue" && ext != ".gdi" {
+			continue
+		}
+		files, err := converter.CompanionFiles(f.Path)
+		if err != nil {
+			continue
+		}
+		for _, c := range files {
+			if c != f.Path {
+				tracks[c] = true
+			}
+		}
+	}
+
+	var games []string
+	for _, f := range scan.Files {
+		if tracks[f.Path] {
+			continue
+		}
+		rel, err := filepath.Rel(romDir, f.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		games = append(games, filepath.ToSlash(rel))
+	}
+	sort.Strings(games)
+	return games
+}
+
+// listEntryFiles returns the library files for a list entry: the game
+// itself, plus the tracks of a cue sheet or GDI.
+func listEntryFiles(localRoms, entry string) ([]string, error) {
+	p := filepath.Join(localRoms, filepath.FromSlash(entry))
+	if _, err := os.Stat(p); err != nil {
+		return nil, err
+	}
+	return converter.CompanionFiles(p)
+}
+
+// RemoveReplaced deletes the device files plan.Replaced lists and drops
+// them from manifests. Files already gone are not an error.
+func RemoveReplaced(ctx context.Context, backend TransferBackend, plan *TransferPlan, manifests Manifests) error {
+	if len(plan.Replaced) == 0 {
+		return nil
+	}
+	remover, ok := backend.(Remover)
+	if !ok {
+		return fmt.Errorf("transfer method cannot delete device files")
+	}
+	for _, f := range plan.Replaced {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := remover.RemoveFile(f.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", f.Path, err)
+		}
+		if m := manifests.For(f.Path); m != nil {
+			m.Remove(f.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+func TestPlanSync_Lists(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+
+	roms := filepath.Join(local, "roms")
+	writeFile(t, filepath.Join(roms, "snes", "Mario.sfc"), "mario")
+	writeFile(t, filepath.Join(roms, "snes", "Zelda.sfc"), "zelda")
+	writeFile(t, filepath.Join(roms, "psx", "Game.cue"), "FILE \"Game (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\n    INDEX 01 00:00:00\n")
+	writeFile(t, filepath.Join(roms, "psx", "Game (Track 1).bin"), "track")
+	writeFile(t, filepath.Join(mount, "roms", "_autostart", "Old.sfc"), "old")
+	writeFile(t, filepath.Join(roms, "_favorites", "Local.sfc"), "local")
+	writeFile(t, filepath.Join(mount, "roms", "_favorites", "Local.sfc"), "local")
+	writeFile(t, filepath.Join(mount, "roms", "_favorites", "Dropped.sfc"), "dropped")
+	writeFile(t, filepath.Join(mount, "roms", "_favorites", "Marked.sfc"), "marked")
+	// An earlier transfer sent Dropped.sfc; Marked.sfc was favorited on the
+	// device itself.
+	writeFile(t, filepath.Join(mount, "roms", ManifestName), `{"version":1,"files":{"_favorites/Dropped.sfc":{"size":7,"mtime":1}}}`)
+
+	backend := NewUSBBackend(mount)
+	ctx := context.Background()
+	if err := backend.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err := PlanSync(ctx, backend, SyncOptions{
+		LocalRoot: local,
+		Folders:   []string{"roms"},
+		Transfer:  config.TransferConfig{SyncMode: true, Manifest: true},
+		Lists: config.ListsConfig{
+			Favorites: []string{"snes/Mario.sfc", "psx/Game.cue", "snes/Missing.sfc"},
+			Autostart: "snes/Zelda.sfc",
+		},
+	})
+	if err != nil {
+		t.Fatalf("PlanSync: %v", err)
+	}
+	defer sp.Plan.Cleanup()
+
+	if len(sp.Plan.Omitted) != 1 || sp.Plan.Omitted[0].Reason != OmitNotInLibrary {
+		t.Errorf("Omitted = %+v, want snes/Missing.sfc", sp.Plan.Omitted)
+	}
+	var replaced []string
+	for _, f := range sp.Plan.Replaced {
+		replaced = append(replaced, f.Path)
+	}
+	sort.Strings(replaced)
+	if len(replaced) != 2 || replaced[0] != "roms/_autostart/Old.sfc" || replaced[1] != "roms/_favorites/Dropped.sfc" {
+		t.Errorf("Replaced = %v, want the old autostart game and the dropped favorite", replaced)
+	}
+
+	if err := Execute(ctx, backend, sp.Plan, 1, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, p := range []string{
+		"roms/snes/Mario.sfc",
+		"roms/_favorites/Mario.sfc",
+		"roms/_favorites/Game.cue",
+		"roms/_favorites/Game (Track 1).bin",
+		"roms/_favorites/Local.sfc",
+		"roms/_favorites/Marked.sfc",
+		"roms/_autostart/Zelda.sfc",
+	} {
+		if _, err := os.Stat(filepath.Join(mount, filepath.FromSlash(p))); err != nil {
+			t.Errorf("%s not on device: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(mount, "roms", "_autostart", "Old.sfc")); !os.IsNotExist(err) {
+		t.Errorf("previous autostart game still on device: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(mount, "roms", "_favorites", "Dropped.sfc")); !os.IsNotExist(err) {
+		t.Errorf("dropped favorite still on device: %v", err)
+	}
+}
+
+func TestAddListItems_NoFavoritesLeavesFolder(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(mount, "roms", "_favorites", "OnDevice.sfc"), "fav")
+
+	plan := &TransferPlan{}
+	err := AddListItems(context.Background(), NewUSBBackend(mount), plan, filepath.Join(local, "roms"), config.ListsConfig{}, PlanOptions{})
+	if err != nil {
+		t.Fatalf("AddListItems: %v", err)
+	}
+	if len(plan.Replaced) != 0 {
+		t.Errorf("Replaced = %+v, want none without curated favorites", plan.Replaced)
+	}
+}
+
+func TestAddListItems_NoManifestKeepsFavorites(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	roms := filepath.Join(local, "roms")
+	writeFile(t, filepath.Join(roms, "snes", "Mario.sfc"), "mario")
+	writeFile(t, filepath.Join(mount, "roms", "_favorites", "Marked.sfc"), "marked")
+
+	// Without a manifest nothing shows which favorites RomWrangler sent.
+	plan := &TransferPlan{}
+	lists := config.ListsConfig{Favorites: []string{"snes/Mario.sfc"}}
+	if err := AddListItems(context.Background(), NewUSBBackend(mount), plan, roms, lists, PlanOptions{}); err != nil {
+		t.Fatalf("AddListItems: %v", err)
+	}
+	if len(plan.Replaced) != 0 {
+		t.Errorf("Replaced = %+v, want the device's own favorite kept", plan.Replaced)
+	}
+}
@@ -2,12 +2,18 @@ package transfer
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // TransferBackend is the interface for transfer methods.
@@ -19,10 +25,32 @@ type TransferBackend interface {
 	Upload(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) error
 }
 
+// SHA1Uploader is implemented by backends that can hash the local file as
+// they upload it, so verification and the manifest do not read it twice.
+// It returns the lowercase hex SHA1 of the local file.
+type SHA1Uploader interface {
+	UploadSHA1(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) (string, error)
+}
+
+// RemoteHasher is implemented by backends that can checksum a file on the
+// destination. It returns the lowercase hex SHA1 of the file at path.
+type RemoteHasher interface {
+	RemoteSHA1(ctx context.Context, path string) (string, error)
+}
+
+// ErrChecksumMismatch is returned when a destination file's SHA1 does not
+// match the local file after all retries.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// defaultVerifyRetries is how many times a file is re-sent after a checksum
+// mismatch when ExecuteOptions.VerifyRetries is zero.
+const defaultVerifyRetries = 2
+
 // FolderMapping maps a local directory to a remote directory for bulk transfers.
 type FolderMapping struct {
 	LocalDir  string
 	RemoteDir string
+	Only      []string // limit the transfer to these top-level entries of LocalDir; empty = all
 }
 
 // BulkTransferBackend is the interface for transfer methods that operate on
@@ -42,7 +70,8 @@ type TransferItem struct {
 	LocalPath  string
 	RemotePath string
 	Size       int64
-	Skip       bool // set by sync mode
+	ModTime    time.Time // of the local source, recorded in the manifest
+	Skip       bool      // set by sync mode
 }
 
 // TransferPlan is a list of files to transfer.
@@ -50,6 +79,88 @@ type TransferPlan struct {
 	Items     []TransferItem
 	TotalSize int64
 	SkipCount int
+
+	// Deletions lists destination files with no local counterpart, found in
+	// mirror mode. They are only removed by ExecuteDeletions.
+	Deletions  []RemoteFile
+	DeleteSize int64
+
+	// Replaced lists device files in a curated list folder that the list
+	// replaces, e.g. the previous autostart game or a game dropped from the
+	// favorites. ExecuteWithOptions removes them before uploading.
+	Replaced []RemoteFile
+
+	// Omitted lists files left out by include/exclude rules or by
+	// FitTransferPlan.
+	Omitted     []OmittedItem
+	OmittedSize int64
+
+	// Preflight for restricted filesystems (PlanOptions.Filesystem):
+	// destination paths changed to be valid, and playlists or cue sheets
+	// rewritten to match. Call Cleanup after executing the plan.
+	Renamed   []RenamedItem
+	Rewritten []string
+	tempDir   string
+	tempDirs  []string // from merged plans
+}
+
+// omit records item as left out of the plan.
+func (p *TransferPlan) omit(item TransferItem, reason, hint string) {
+	p.Omitted = append(p.Omitted, OmittedItem{TransferItem: item, System: planSystem(item.RemotePath), Reason: reason, Hint: hint})
+	p.OmittedSize += item.Size
+}
+
+// PlanOptions controls how a transfer plan is built.
+type PlanOptions struct {
+	// SyncMode skips files that already exist on the destination.
+	SyncMode bool
+	// Mirror lists destination files under each local system folder that
+	// no longer exist locally as Deletions. The backend must implement
+	// Lister.
+	Mirror bool
+	// MirrorKeep are further local folders laid out like the plan's local
+	// folder, such as roms/ in other library roots. In mirror mode, device
+	// files that exist in any of them are kept.
+	MirrorKeep []string
+
+	// Include and Exclude are path.Match rules (see matchesRule) applied
+	// to destination paths; files they rule out are listed in
+	// TransferPlan.Omitted.
+	Include []string
+	Exclude []string
+	// Budget, when > 0, fits the plan to this many bytes of new data using
+	// Priorities (system folder -> priority, higher first). To fit several
+	// folders together, merge their plans and call FitTransferPlan instead.
+	Budget     int64
+	Priorities map[string]int
+
+	// Filesystem, when restricted, drops files too large for it, renames
+	// destination paths it cannot store and rewrites .m3u/.cue references
+	// to match.
+	Filesystem FSInfo
+
+	// Manifest, in sync mode, decides which files are already on the
+	// device from the device-side manifest instead of one FileExists call
+	// per file.
+	Manifest *Manifest
+
+	// Systems limits the plan to these top-level folders of localDir (the
+	// system folders, for roms/). Folders starting with "_" are always
+	// kept. Empty plans every folder.
+	Systems []string
+}
+
+// folderSelected reports whether the top-level folder name is planned.
+func (o PlanOptions) folderSelected(name string) bool {
+	if len(o.Systems) == 0 || strings.HasPrefix(name, "_") {
+		return true
+	}
+	for _, s := range o.Systems {
+		if s == name {
+			return true
+		}
+	}
+	return false
 }
 
 // TransferProgress reports progress of a transfer operation.
@@ -63,15 +174,50 @@ type TransferProgress struct {
 	TotalSize   int64
 	Done        bool
 	Err         error
+
+	// Verify mode: Verified is set on Done when the destination checksum
+	// matched. Mismatch reports a failed check; the file is re-sent and
+	// Attempt counts the retries so far.
+	Verified bool
+	Mismatch bool
+	Attempt  int
+}
+
+// ExecuteOptions controls how a transfer plan is executed.
+type ExecuteOptions struct {
+	Concurrency int // 1 for sequential execution
+
+	// Verify hashes every uploaded file on the destination and compares it
+	// with the local SHA1. The backend must implement RemoteHasher.
+	Verify bool
+	// VerifyRetries is how many times a mismatched file is re-sent before
+	// giving up. Zero uses defaultVerifyRetries.
+	VerifyRetries int
+
+	// Manifests, when set, record every file sent (with its SHA1) in the
+	// manifest of its folder. The caller saves them afterwards.
+	Manifests Manifests
 }
 
 // BuildTransferPlan builds a list of files to transfer.
 // In sync mode, it checks if files already exist on the destination.
 func BuildTransferPlan(ctx context.Context, backend TransferBackend, localDir, remoteBase string, syncMode bool) (*TransferPlan, error) {
+	return BuildTransferPlanWithOptions(ctx, backend, localDir, remoteBase, PlanOptions{SyncMode: syncMode})
+}
+
+// BuildTransferPlanWithOptions builds a transfer plan, optionally listing
+// destination files to delete in mirror mode.
+func BuildTransferPlanWithOptions(ctx context.Context, backend TransferBackend, localDir, remoteBase string, opts PlanOptions) (*TransferPlan, error) {
 	plan := &TransferPlan{}
+	seen := make(map[string]string) // sanitized remote path -> local path
 
 	err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			// Mirror mode deletes what the walk did not see, so a folder
+			// it could not read must not look empty.
+			if opts.Mirror && !(path == localDir && os.IsNotExist(err)) {
+				return err
+			}
 			return nil
 		}
 
@@ -84,48 +230,192 @@ func BuildTransferPlan(ctx context.Context, backend TransferBackend, localDir, r
 		if info.IsDir() && info.Name() == "_archive" {
 			return filepath.SkipDir
 		}
+		if info.IsDir() && filepath.Dir(path) == filepath.Clean(localDir) && !opts.folderSelected(info.Name()) {
+			return filepath.SkipDir
+		}
 		if info.IsDir() {
 			return nil
 		}
 
 		relPath, _ := filepath.Rel(localDir, path)
-		remotePath := filepath.ToSlash(filepath.Join(remoteBase, relPath))
-
 		item := TransferItem{
 			LocalPath:  path,
-			RemotePath: remotePath,
+			RemotePath: filepath.ToSlash(filepath.Join(remoteBase, relPath)),
 			Size:       info.Size(),
+			ModTime:    info.ModTime(),
 		}
 
-		if syncMode && backend != nil {
-			exists, err := backend.FileExists(remotePath, info.Size())
-			if err == nil && exists {
-				item.Skip = true
-				plan.SkipCount++
-			}
+		return plan.add(backend, item, opts, seen)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transfer plan: %w", err)
+	}
+
+	if opts.Mirror {
+		if err := planDeletions(ctx, backend, localDir, remoteBase, plan, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Budget > 0 {
+		FitTransferPlan(plan, opts.Budget, opts.Priorities)
+	}
+
+	return plan, nil
+}
+
+// add applies include/exclude rules, filesystem preflight and the sync
+// check to item and appends it to the plan. seen tracks sanitized
+// destination paths across calls.
+func (p *TransferPlan) add(backend TransferBackend, item TransferItem, opts PlanOptions, seen map[string]string) error {
+	if excludedByRules(item.RemotePath, opts.Include, opts.Exclude) {
+		p.omit(item, OmitExcluded, "")
+		return nil
+	}
+
+	if opts.Filesystem.Restricted() {
+		reason, hint, err := preflightItem(p, opts.Filesystem, &item, seen)
+		if err != nil {
+			return err
 		}
+		if reason != "" {
+			p.omit(item, reason, hint)
+			return nil
+		}
+	}
+
+	if opts.SyncMode && opts.Manifest != nil {
+		if opts.Manifest.Has(item) {
+			item.Skip = true
+			p.SkipCount++
+		}
+	} else if opts.SyncMode && backend != nil {
+		exists, err := backend.FileExists(item.RemotePath, item.Size)
+		if err == nil && exists {
+			item.Skip = true
+			p.SkipCount++
+		}
+	}
 
+	if !item.Skip {
+		p.TotalSize += item.Size
+	}
+	p.Items = append(p.Items, item)
+	return nil
+}
+
+// VerifyExisting checksums the files a sync-mode plan skipped because they
+// already exist with the right size. Files whose destination SHA1 differs
+// from the local one are re-queued. Returns the number of re-queued items.
+func VerifyExisting(ctx context.Context, backend TransferBackend, plan *TransferPlan) (int, error) {
+	hasher, ok := backend.(RemoteHasher)
+	if !ok {
+		return 0, fmt.Errorf("transfer method does not support checksum verification")
+	}
+
+	requeued := 0
+	for i := range plan.Items {
+		item := &plan.Items[i]
 		if !item.Skip {
-			plan.TotalSize += info.Size()
+			continue
 		}
-		plan.Items = append(plan.Items, item)
-		return nil
-	})
+		if err := ctx.Err(); err != nil {
+			return requeued, err
+		}
+
+		err := verifyItem(ctx, hasher, *item, "")
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrChecksumMismatch) {
+			return requeued, err
+		}
+		item.Skip = false
+		plan.SkipCount--
+		plan.TotalSize += item.Size
+		requeued++
+	}
+	return requeued, nil
+}
 
+// verifyItem compares the destination SHA1 of item with localSHA1, hashing
+// the local file first when localSHA1 is empty.
+func verifyItem(ctx context.Context, hasher RemoteHasher, item TransferItem, localSHA1 string) error {
+	if localSHA1 == "" {
+		sum, err := fileSHA1(ctx, item.LocalPath)
+		if err != nil {
+			return fmt.Errorf("hash %s: %w", item.LocalPath, err)
+		}
+		localSHA1 = sum
+	}
+	remoteSHA1, err := hasher.RemoteSHA1(ctx, item.RemotePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build transfer plan: %w", err)
+		return fmt.Errorf("hash remote %s: %w", item.RemotePath, err)
 	}
+	if remoteSHA1 != localSHA1 {
+		return fmt.Errorf("%s: %w", item.RemotePath, ErrChecksumMismatch)
+	}
+	return nil
+}
 
-	return plan, nil
+// fileSHA1 returns the lowercase hex SHA1 of the file at path.
+func fileSHA1(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return readerSHA1(ctx, f)
+}
+
+// readerSHA1 returns the lowercase hex SHA1 of everything r yields.
+func readerSHA1(ctx context.Context, r io.Reader) (string, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, &contextReader{ctx: ctx, r: r}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // Execute runs the transfer plan with the given concurrency level.
 // Use concurrency=1 for sequential execution.
 func Execute(ctx context.Context, backend TransferBackend, plan *TransferPlan, concurrency int, progressCh chan<- TransferProgress) error {
+	return ExecuteWithOptions(ctx, backend, plan, ExecuteOptions{Concurrency: concurrency}, progressCh)
+}
+
+// ExecuteWithOptions runs the transfer plan, optionally verifying each
+// uploaded file and re-sending it on a checksum mismatch. progressCh, when
+// set, must be read until it is closed: byte progress may be dropped for a
+// slow reader, but finished files, failures and mismatches are not.
+func ExecuteWithOptions(ctx context.Context, backend TransferBackend, plan *TransferPlan, opts ExecuteOptions, progressCh chan<- TransferProgress) error {
+	concurrency := opts.Concurrency
 	if concurrency < 1 {
 		concurrency = 1
 	}
 
+	var hasher RemoteHasher
+	if opts.Verify {
+		h, ok := backend.(RemoteHasher)
+		if !ok {
+			if progressCh != nil {
+				close(progressCh)
+			}
+			return fmt.Errorf("transfer method does not support checksum verification")
+		}
+		hasher = h
+	}
+	retries := opts.VerifyRetries
+	if retries <= 0 {
+		retries = defaultVerifyRetries
+	}
+
+	if err := RemoveReplaced(ctx, backend, plan, opts.Manifests); err != nil {
+		if progressCh != nil {
+			close(progressCh)
+		}
+		return err
+	}
+
 	// Collect non-skipped items
 	var items []TransferItem
 	for _, item := range plan.Items {
@@ -168,7 +458,7 @@ func Execute(ctx context.Context, backend TransferBackend, plan *TransferPlan, c
 			// Ensure remote directory exists
 			remoteDir := filepath.ToSlash(filepath.Dir(item.RemotePath))
 			if err := backend.MkdirAll(remoteDir); err != nil {
-				sendProgressNonBlocking(progressCh, TransferProgress{
+				sendProgress(progressCh, TransferProgress{
 					FileIndex: fileIdx, TotalFiles: totalFiles,
 					Filename: filepath.Base(item.LocalPath), Err: err,
 				})
@@ -184,27 +474,72 @@ func Execute(ctx context.Context, backend TransferBackend, plan *TransferPlan, c
 				TotalSize:  plan.TotalSize,
 			})
 
-			err := backend.Upload(ctx, item.LocalPath, item.RemotePath, func(written int64) {
-				sendProgressNonBlocking(progressCh, TransferProgress{
+			manifest := opts.Manifests.For(item.RemotePath)
+
+			// The local SHA1 is needed to verify the upload and for the
+			// manifest. Backends that can hash the upload stream provide it;
+			// otherwise the file is hashed once after the first upload.
+			wantSHA1 := hasher != nil || manifest != nil
+			uploader, _ := backend.(SHA1Uploader)
+
+			var localSHA1 string
+			var err error
+			for attempt := 0; ; attempt++ {
+				progressFn := func(written int64) {
+					sendProgressNonBlocking(progressCh, TransferProgress{
+						FileIndex:  fileIdx,
+						TotalFiles: totalFiles,
+						Filename:   filepath.Base(item.LocalPath),
+						BytesSent:  written,
+						FileSize:   item.Size,
+						TotalSent:  totalSent.Load() + written,
+						TotalSize:  plan.TotalSize,
+						Attempt:    attempt,
+					})
+				}
+				if wantSHA1 && uploader != nil {
+					localSHA1, err = uploader.UploadSHA1(ctx, item.LocalPath, item.RemotePath, progressFn)
+				} else {
+					err = backend.Upload(ctx, item.LocalPath, item.RemotePath, progressFn)
+					if err == nil && wantSHA1 && localSHA1 == "" {
+						localSHA1, err = fileSHA1(ctx, item.LocalPath)
+					}
+				}
+				if err != nil || hasher == nil {
+					break
+				}
+
+				err = verifyItem(ctx, hasher, item, localSHA1)
+				if err == nil || !errors.Is(err, ErrChecksumMismatch) || attempt >= retries {
+					break
+				}
+
+				// Re-queue: report the mismatch and send the file again.
+				sendProgress(progressCh, TransferProgress{
 					FileIndex:  fileIdx,
 					TotalFiles: totalFiles,
 					Filename:   filepath.Base(item.LocalPath),
-					BytesSent:  written,
 					FileSize:   item.Size,
-					TotalSent:  totalSent.Load() + written,
+					TotalSent:  totalSent.Load(),
 					TotalSize:  plan.TotalSize,
+					Mismatch:   true,
+					Attempt:    attempt + 1,
 				})
-			})
+			}
 
 			if err != nil {
 				firstErr.CompareAndSwap(nil, err)
-				sendProgressNonBlocking(progressCh, TransferProgress{
+				sendProgress(progressCh, TransferProgress{
 					FileIndex: fileIdx, TotalFiles: totalFiles,
 					Filename: filepath.Base(item.LocalPath), Err: err,
+					Mismatch: errors.Is(err, ErrChecksumMismatch),
 				})
 			} else {
+				if manifest != nil {
+					manifest.Record(item, localSHA1)
+				}
 				totalSent.Add(item.Size)
-				sendProgressNonBlocking(progressCh, TransferProgress{
+				sendProgress(progressCh, TransferProgress{
 					FileIndex:  fileIdx,
 					TotalFiles: totalFiles,
 					Filename:   filepath.Base(item.LocalPath),
@@ -213,6 +548,7 @@ func Execute(ctx context.Context, backend TransferBackend, plan *TransferPlan, c
 					TotalSent:  totalSent.Load(),
 					TotalSize:  plan.TotalSize,
 					Done:       true,
+					Verified:   hasher != nil,
 				})
 			}
 		}(idx, item)
@@ -239,11 +575,38 @@ func MergeTransferPlans(plans ...*TransferPlan) *TransferPlan {
 		merged.Items = append(merged.Items, p.Items...)
 		merged.TotalSize += p.TotalSize
 		merged.SkipCount += p.SkipCount
+		merged.Deletions = append(merged.Deletions, p.Deletions...)
+		merged.DeleteSize += p.DeleteSize
+		merged.Replaced = append(merged.Replaced, p.Replaced...)
+		merged.Omitted = append(merged.Omitted, p.Omitted...)
+		merged.OmittedSize += p.OmittedSize
+		merged.Renamed = append(merged.Renamed, p.Renamed...)
+		merged.Rewritten = append(merged.Rewritten, p.Rewritten...)
+		merged.tempDirs = append(merged.tempDirs, p.tempDirs...)
+		if p.tempDir != "" {
+			merged.tempDirs = append(merged.tempDirs, p.tempDir)
+		}
 	}
 	return merged
 }
 
-// sendProgressNonBlocking sends progress without blocking if the consumer is slow.
+// sendProgress sends an event the consumer must not miss (a finished file,
+// a failure or a checksum mismatch), waiting for room on the channel.
+// Callers drain progress channels until they are closed.
+func sendProgress(ch chan<- TransferProgress, p TransferProgress) {
+	if ch != nil {
+		ch <- p
+	}
+}
+
+// isEvent reports whether p must reach the consumer rather than being a
+// byte-progress update that may be dropped.
+func (p TransferProgress) isEvent() bool {
+	return p.Done || p.Err != nil || p.Mismatch
+}
+
+// sendProgressNonBlocking sends byte progress without blocking if the
+// consumer is slow.
 func sendProgressNonBlocking(ch chan<- TransferProgress, p TransferProgress) {
 	if ch == nil {
 		return
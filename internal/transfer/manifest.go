@@ -0,0 +1,252 @@
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ManifestName is the file, inside each transferred top-level folder (e.g.
+// roms/.romwrangler-manifest.json), that records what has been sent there.
+const ManifestName = ".romwrangler-manifest.json"
+
+// manifestVersion is bumped when the manifest format changes; manifests
+// with another version are rebuilt.
+const manifestVersion = 1
+
+// ManifestEntry records one file on the device. ModTime is the Unix time
+// of the local source when it was sent; zero when the entry was rebuilt
+// from a device listing and the source is not known yet.
+type ManifestEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime,omitempty"`
+	SHA1    string `json:"sha1,omitempty"`
+}
+
+// Manifest is the device-side record of one folder. It lets sync mode
+// decide which files to send from a single read instead of one FileExists
+// round trip per file. Files is keyed by path relative to Folder.
+type Manifest struct {
+	Version int                      `json:"version"`
+	Updated time.Time                `json:"updated"`
+	Files   map[string]ManifestEntry `json:"files"`
+
+	Folder string `json:"-"`
+
+	mu    sync.Mutex
+	dirty bool
+}
+
+func newManifest(folder string) *Manifest {
+	return &Manifest{Version: manifestVersion, Files: make(map[string]ManifestEntry), Folder: folder}
+}
+
+// LoadManifest reads folder's manifest from the device. When there is none
+// yet, or it is unreadable, the manifest is rebuilt from a device listing.
+// The backend must implement Downloader and Lister.
+func LoadManifest(ctx context.Context, backend TransferBackend, folder string) (*Manifest, error) {
+	downloader, ok := backend.(Downloader)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot read a device manifest")
+	}
+
+	tmp, err := os.MkdirTemp("", "romwrangler-manifest-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	local := filepath.Join(tmp, ManifestName)
+	if err := downloader.Download(ctx, path.Join(folder, ManifestName), local, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return RebuildManifest(ctx, backend, folder)
+	}
+
+	data, err := os.ReadFile(local)
+	if err != nil {
+		return nil, err
+	}
+	m := newManifest(folder)
+	if err := json.Unmarshal(data, m); err != nil || m.Version != manifestVersion || m.Files == nil {
+		return RebuildManifest(ctx, backend, folder)
+	}
+	return m, nil
+}
+
+// RebuildManifest discards what the device's manifest says and records the
+// files actually present in folder, by size only. Use it after the device
+// was changed by hand. The backend must implement Lister.
+func RebuildManifest(ctx context.Context, backend TransferBackend, folder string) (*Manifest, error) {
+	lister, ok := backend.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("transfer method cannot list device files")
+	}
+	files, err := lister.ListFiles(ctx, folder)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", folder, err)
+	}
+
+	m := newManifest(folder)
+	for _, f := range files {
+		if rel, ok := m.rel(f.Path); ok {
+			m.Files[rel] = ManifestEntry{Size: f.Size}
+		}
+	}
+	m.dirty = true
+	return m, nil
+}
+
+// rel converts a destination path to a manifest key.
+func (m *Manifest) rel(remotePath string) (string, bool) {
+	rel, ok := strings.CutPrefix(remotePath, m.Folder+"/")
+	return rel, ok && rel != ManifestName
+}
+
+// Has reports whether item is already on the device: same size and, when
+// the manifest knows it, the same source modification time. An entry
+// rebuilt from a listing adopts item's time so later syncs check it.
+func (m *Manifest) Has(item TransferItem) bool {
+	rel, ok := m.rel(item.RemotePath)
+	if !ok {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.Files[rel]
+	if !ok || e.Size != item.Size {
+		return false
+	}
+	mtime := item.ModTime.Unix()
+	if e.ModTime == 0 {
+		e.ModTime = mtime
+		m.Files[rel] = e
+		m.dirty = true
+		return true
+	}
+	return e.ModTime == mtime
+}
+
+// Record notes that item was sent with the given SHA1 (may be empty).
+func (m *Manifest) Record(item TransferItem, sha1 string) {
+	rel, ok := m.rel(item.RemotePath)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Files[rel] = ManifestEntry{Size: item.Size, ModTime: item.ModTime.Unix(), SHA1: sha1}
+	m.dirty = true
+}
+
+// Sent reports whether remotePath was sent by a transfer, as opposed to
+// found in a device listing when the manifest was rebuilt.
+func (m *Manifest) Sent(remotePath string) bool {
+	rel, ok := m.rel(remotePath)
+	if !ok {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.Files[rel]
+	return ok && e.ModTime != 0
+}
+
+// Remove forgets a file deleted from the device.
+func (m *Manifest) Remove(remotePath string) {
+	rel, ok := m.rel(remotePath)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.Files[rel]; ok {
+		delete(m.Files, rel)
+		m.dirty = true
+	}
+}
+
+// Save writes the manifest back to the device if it changed.
+func (m *Manifest) Save(ctx context.Context, backend TransferBackend) error {
+	m.mu.Lock()
+	if !m.dirty {
+		m.mu.Unlock()
+		return nil
+	}
+	m.Updated = time.Now().UTC()
+	data, err := json.Marshal(m)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "romwrangler-manifest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	local := filepath.Join(tmp, ManifestName)
+	if err := os.WriteFile(local, data, 0644); err != nil {
+		return err
+	}
+	if err := backend.MkdirAll(m.Folder); err != nil {
+		return err
+	}
+	if err := backend.Upload(withoutThrottle(ctx), local, path.Join(m.Folder, ManifestName), nil); err != nil {
+		return fmt.Errorf("save manifest: %w", err)
+	}
+
+	m.mu.Lock()
+	m.dirty = false
+	m.mu.Unlock()
+	return nil
+}
+
+// Manifests holds the manifests of several folders, keyed by folder.
+type Manifests map[string]*Manifest
+
+// For returns the manifest covering remotePath, or nil.
+func (ms Manifests) For(remotePath string) *Manifest {
+	folder, _, _ := strings.Cut(remotePath, "/")
+	return ms[folder]
+}
+
+// Save writes every changed manifest back to the device.
+func (ms Manifests) Save(ctx context.Context, backend TransferBackend) error {
+	var firstErr error
+	for _, m := range ms {
+		if err := m.Save(ctx, backend); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// LoadManifests loads the manifest of each folder, or rebuilds them all
+// from device listings when rebuild is set.
+func LoadManifests(ctx context.Context, backend TransferBackend, folders []string, rebuild bool) (Manifests, error) {
+	ms := make(Manifests, len(folders))
+	for _, folder := range folders {
+		var m *Manifest
+		var err error
+		if rebuild {
+			m, err = RebuildManifest(ctx, backend, folder)
+		} else {
+			m, err = LoadManifest(ctx, backend, folder)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ms[folder] = m
+	}
+	return ms, nil
+}
@@ -0,0 +1,123 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingBackend wraps a USBBackend and counts FileExists round trips.
+type countingBackend struct {
+	*USBBackend
+	exists atomic.Int32
+}
+
+func (c *countingBackend) FileExists(path string, size int64) (bool, error) {
+	c.exists.Add(1)
+	return c.USBBackend.FileExists(path, size)
+}
+
+func syncWithManifest(t *testing.T, backend TransferBackend, local string, rebuild bool) *TransferPlan {
+	t.Helper()
+	ctx := context.Background()
+	ms, err := LoadManifests(ctx, backend, []string{"roms"}, rebuild)
+	if err != nil {
+		t.Fatalf("LoadManifests: %v", err)
+	}
+	plan, err := BuildTransferPlanWithOptions(ctx, backend, local, "roms", PlanOptions{SyncMode: true, Manifest: ms["roms"]})
+	if err != nil {
+		t.Fatalf("BuildTransferPlanWithOptions: %v", err)
+	}
+	if err := ExecuteWithOptions(ctx, backend, plan, ExecuteOptions{Manifests: ms}, make(chan TransferProgress, 100)); err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	if err := ms.Save(ctx, backend); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return plan
+}
+
+func TestManifest_Sync(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(local, "snes", "A.sfc"), "aaaa")
+	writeFile(t, filepath.Join(local, "snes", "B.sfc"), "bbbb")
+
+	backend := &countingBackend{USBBackend: NewUSBBackend(mount)}
+
+	plan := syncWithManifest(t, backend, local, false)
+	if plan.SkipCount != 0 || backend.exists.Load() != 0 {
+		t.Fatalf("first sync: SkipCount = %d, FileExists calls = %d", plan.SkipCount, backend.exists.Load())
+	}
+
+	m, err := LoadManifest(context.Background(), backend, "roms")
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	e, ok := m.Files["snes/A.sfc"]
+	if !ok || e.Size != 4 || e.SHA1 == "" || e.ModTime == 0 {
+		t.Fatalf("manifest entry for snes/A.sfc = %+v, %v", e, ok)
+	}
+
+	plan = syncWithManifest(t, backend, local, false)
+	if plan.SkipCount != 2 || backend.exists.Load() != 0 {
+		t.Fatalf("second sync: SkipCount = %d, FileExists calls = %d", plan.SkipCount, backend.exists.Load())
+	}
+
+	// A touched source file is sent again even though the size matches.
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(local, "snes", "B.sfc"), later, later)
+	plan = syncWithManifest(t, backend, local, false)
+	if plan.SkipCount != 1 {
+		t.Errorf("after touch: SkipCount = %d, want 1", plan.SkipCount)
+	}
+}
+
+func TestManifest_Rebuild(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(local, "snes", "A.sfc"), "aaaa")
+	writeFile(t, filepath.Join(local, "snes", "B.sfc"), "bbbb")
+	backend := NewUSBBackend(mount)
+
+	syncWithManifest(t, backend, local, false)
+
+	// The device is changed by hand: the manifest still lists B.
+	os.Remove(filepath.Join(mount, "roms", "snes", "B.sfc"))
+	plan := syncWithManifest(t, backend, local, false)
+	if plan.SkipCount != 2 {
+		t.Fatalf("stale manifest: SkipCount = %d, want 2", plan.SkipCount)
+	}
+
+	os.Remove(filepath.Join(mount, "roms", "snes", "B.sfc"))
+	plan = syncWithManifest(t, backend, local, true)
+	if plan.SkipCount != 1 {
+		t.Errorf("rebuilt manifest: SkipCount = %d, want 1", plan.SkipCount)
+	}
+	if _, err := os.Stat(filepath.Join(mount, "roms", "snes", "B.sfc")); err != nil {
+		t.Errorf("B.sfc not re-sent: %v", err)
+	}
+}
+
+func TestManifest_NotListed(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(local, "snes", "A.sfc"), "aaaa")
+	backend := NewUSBBackend(mount)
+
+	syncWithManifest(t, backend, local, false)
+	if _, err := os.Stat(filepath.Join(mount, "roms", ManifestName)); err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+
+	files, err := backend.ListFiles(context.Background(), "roms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "roms/snes/A.sfc" {
+		t.Errorf("ListFiles = %+v, want only roms/snes/A.sfc", files)
+	}
+}
@@ -0,0 +1,158 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// mirrorProtected are ReplayOS folders that mirror mode never deletes from,
+// at any depth: they hold device-managed lists and user extras that have no
+// local counterpart by design.
+var mirrorProtected = map[string]bool{
+	"_favorites": true,
+	"_recent":    true,
+	"_autostart": true,
+	"_extra":     true,
+}
+
+// Remover is implemented by backends that can delete files on the
+// destination.
+type Remover interface {
+	RemoveFile(path string) error
+}
+
+// planDeletions lists destination files under each local system folder of
+// localDir that have no counterpart in plan.Items or in opts.MirrorKeep.
+// Only system folders that exist locally are considered, so systems the
+// library does not manage are left alone, as are loose files directly
+// under remoteBase and folders opts.Systems leaves out.
+func planDeletions(ctx context.Context, backend TransferBackend, localDir, remoteBase string, plan *TransferPlan, opts PlanOptions) error {
+	lister, ok := backend.(Lister)
+	if !ok {
+		return fmt.Errorf("transfer method cannot list device files for mirror mode")
+	}
+
+	entries, err := os.ReadDir(localDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Files left out of this transfer still have a local counterpart.
+	local := make(map[string]bool, len(plan.Items)+len(plan.Omitted))
+	for _, item := range plan.Items {
+		local[item.RemotePath] = true
+	}
+	for _, o := range plan.Omitted {
+		// Excluded files were never renamed for the filesystem.
+		local[o.RemotePath] = true
+		local[sanitizePath(o.RemotePath)] = true
+	}
+	for _, dir := range opts.MirrorKeep {
+		if err := addLocalPaths(ctx, local, dir, remoteBase); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() || mirrorProtected[e.Name()] || e.Name() == "_archive" || !opts.folderSelected(e.Name()) {
+			continue
+		}
+		remoteDir := path.Join(remoteBase, e.Name())
+		files, err := lister.ListFiles(ctx, remoteDir)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", remoteDir, err)
+		}
+		for _, f := range files {
+			if local[f.Path] || isMirrorProtected(f.Path) {
+				continue
+			}
+			plan.Deletions = append(plan.Deletions, f)
+			plan.DeleteSize += f.Size
+		}
+	}
+	return nil
+}
+
+// addLocalPaths adds the destination path of every file under dir, as it
+// would be sent to remoteBase, to local.
+func addLocalPaths(ctx context.Context, local map[string]bool, dir, remoteBase string) error {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		remote := path.Join(remoteBase, filepath.ToSlash(rel))
+		local[remote] = true
+		local[sanitizePath(remote)] = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list %s: %w", dir, err)
+	}
+	return nil
+}
+
+// isMirrorProtected reports whether any component of p is a protected
+// folder.
+func isMirrorProtected(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		if mirrorProtected[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecuteDeletions removes the plan's Deletions from the destination and
+// from manifests, if set. It keeps going after individual failures and
+// returns the number of files removed along with every error.
+func ExecuteDeletions(ctx context.Context, backend TransferBackend, plan *TransferPlan, manifests Manifests) (int, []error) {
+	if len(plan.Deletions) == 0 {
+		return 0, nil
+	}
+	remover, ok := backend.(Remover)
+	if !ok {
+		return 0, []error{fmt.Errorf("transfer method cannot delete device files")}
+	}
+
+	removed := 0
+	var errs []error
+	for _, f := range plan.Deletions {
+		if err := ctx.Err(); err != nil {
+			return removed, append(errs, err)
+		}
+		// Re-check at delete time: the plan may be stale.
+		if isMirrorProtected(f.Path) {
+			continue
+		}
+		if err := remover.RemoveFile(f.Path); err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", f.Path, err))
+			continue
+		}
+		if m := manifests.For(f.Path); m != nil {
+			m.Remove(f.Path)
+		}
+		removed++
+	}
+	return removed, errs
+}
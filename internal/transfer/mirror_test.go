@@ -0,0 +1,153 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+func writeFile(t *testing.T, p, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildTransferPlan_Mirror(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+
+	writeFile(t, filepath.Join(local, "snes", "Keep.sfc"), "keep")
+	writeFile(t, filepath.Join(local, "_favorites", "x.sfc"), "fav")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "Keep.sfc"), "keep")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "Pruned.sfc"), "gone!")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "_extra", "manual.pdf"), "pdf")
+	writeFile(t, filepath.Join(mount, "roms", "_favorites", "Other.sfc"), "fav")
+	writeFile(t, filepath.Join(mount, "roms", "_recent", "Other.sfc"), "rec")
+	writeFile(t, filepath.Join(mount, "roms", "_autostart", "Other.sfc"), "auto")
+	writeFile(t, filepath.Join(mount, "roms", "n64", "NotManaged.z64"), "n64")
+	writeFile(t, filepath.Join(mount, "roms", "loose.txt"), "loose")
+
+	backend := NewUSBBackend(mount)
+	ctx := context.Background()
+
+	plan, err := BuildTransferPlanWithOptions(ctx, backend, local, "roms", PlanOptions{SyncMode: true, Mirror: true})
+	if err != nil {
+		t.Fatalf("BuildTransferPlanWithOptions: %v", err)
+	}
+	if len(plan.Deletions) != 1 || plan.Deletions[0].Path != "roms/snes/Pruned.sfc" {
+		t.Fatalf("Deletions = %+v, want only roms/snes/Pruned.sfc", plan.Deletions)
+	}
+	if plan.DeleteSize != 5 {
+		t.Errorf("DeleteSize = %d, want 5", plan.DeleteSize)
+	}
+
+	removed, errs := ExecuteDeletions(ctx, backend, plan, nil)
+	if removed != 1 || len(errs) != 0 {
+		t.Fatalf("ExecuteDeletions = %d, %v", removed, errs)
+	}
+
+	var remaining []string
+	filepath.Walk(mount, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(mount, p)
+			remaining = append(remaining, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(remaining)
+	want := []string{
+		"roms/_autostart/Other.sfc",
+		"roms/_favorites/Other.sfc",
+		"roms/_recent/Other.sfc",
+		"roms/loose.txt",
+		"roms/n64/NotManaged.z64",
+		"roms/snes/Keep.sfc",
+		"roms/snes/_extra/manual.pdf",
+	}
+	if len(remaining) != len(want) {
+		t.Fatalf("remaining = %v, want %v", remaining, want)
+	}
+	for i := range want {
+		if remaining[i] != want[i] {
+			t.Errorf("remaining[%d] = %s, want %s", i, remaining[i], want[i])
+		}
+	}
+}
+
+func TestPlanSync_MirrorKeepsOtherRoots(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	mount := t.TempDir()
+
+	writeFile(t, filepath.Join(first, "roms", "snes", "A.sfc"), "a")
+	writeFile(t, filepath.Join(second, "roms", "snes", "B.sfc"), "b")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "A.sfc"), "a")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "B.sfc"), "b")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "Gone.sfc"), "gone")
+
+	sp, err := PlanSync(context.Background(), NewUSBBackend(mount), SyncOptions{
+		LocalRoot:  first,
+		OtherRoots: []string{second},
+		Folders:    []string{"roms"},
+		Transfer:   config.TransferConfig{SyncMode: true, Mirror: true},
+		Mirror:     true,
+	})
+	if err != nil {
+		t.Fatalf("PlanSync: %v", err)
+	}
+	defer sp.Plan.Cleanup()
+	if len(sp.Plan.Deletions) != 1 || sp.Plan.Deletions[0].Path != "roms/snes/Gone.sfc" {
+		t.Errorf("Deletions = %+v, want only roms/snes/Gone.sfc", sp.Plan.Deletions)
+	}
+}
+
+func TestBuildTransferPlan_MirrorUnreadableFolder(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any folder")
+	}
+	local := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(local, "snes", "Keep.sfc"), "keep")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "Keep.sfc"), "keep")
+	if err := os.Chmod(filepath.Join(local, "snes"), 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filepath.Join(local, "snes"), 0755)
+
+	_, err := BuildTransferPlanWithOptions(context.Background(), NewUSBBackend(mount), local, "roms", PlanOptions{SyncMode: true, Mirror: true})
+	if err == nil {
+		t.Fatal("mirror plan built from an unreadable folder, want an error")
+	}
+}
+
+func TestBuildTransferPlan_NoMirrorByDefault(t *testing.T) {
+	local := t.TempDir()
+	mount := t.TempDir()
+	writeFile(t, filepath.Join(local, "snes", "Keep.sfc"), "keep")
+	writeFile(t, filepath.Join(mount, "roms", "snes", "Pruned.sfc"), "gone!")
+
+	plan, err := BuildTransferPlan(context.Background(), NewUSBBackend(mount), local, "roms", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Deletions) != 0 {
+		t.Errorf("Deletions = %+v, want none without mirror mode", plan.Deletions)
+	}
+}
+
+func TestMergeTransferPlans_Deletions(t *testing.T) {
+	a := &TransferPlan{Deletions: []RemoteFile{{Path: "roms/a", Size: 1}}, DeleteSize: 1}
+	b := &TransferPlan{Deletions: []RemoteFile{{Path: "roms/b", Size: 2}}, DeleteSize: 2}
+	m := MergeTransferPlans(a, b)
+	if len(m.Deletions) != 2 || m.DeleteSize != 3 {
+		t.Errorf("merged deletions = %+v size %d", m.Deletions, m.DeleteSize)
+	}
+}
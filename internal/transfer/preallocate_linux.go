@@ -5,8 +5,12 @@ import (
 	"syscall"
 )
 
+// fallocKeepSize is FALLOC_FL_KEEP_SIZE: allocate blocks without changing
+// the file size, so a partial upload's size still reflects bytes written.
+const fallocKeepSize = 0x01
+
 // preallocate uses fallocate on Linux to pre-allocate disk space,
 // reducing fragmentation for large file copies.
 func preallocate(f *os.File, size int64) error {
-	return syscall.Fallocate(int(f.Fd()), 0, 0, size)
+	return syscall.Fallocate(int(f.Fd()), fallocKeepSize, 0, size)
 }
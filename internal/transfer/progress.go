@@ -1,6 +1,9 @@
 package transfer
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 // progressReportInterval controls how often progress callbacks fire.
 // Reporting every 256KB avoids channel thrashing while still updating
@@ -8,18 +11,39 @@ import "io"
 const progressReportInterval = 256 * 1024
 
 // ProgressWriter wraps an io.Writer and reports bytes written via a callback.
+// With a Throttle set, each write first waits for its rate limit.
 type ProgressWriter struct {
 	Writer       io.Writer
 	OnWrite      func(n int64)
 	written      int64
 	lastReported int64
+
+	throttle *Throttle
+	ctx      context.Context
 }
 
 func NewProgressWriter(w io.Writer, onWrite func(n int64)) *ProgressWriter {
 	return &ProgressWriter{Writer: w, OnWrite: onWrite}
 }
 
+// NewProgressWriterAt is like NewProgressWriter but starts counting at
+// offset, so a resumed upload reports progress for the whole file.
+func NewProgressWriterAt(w io.Writer, offset int64, onWrite func(n int64)) *ProgressWriter {
+	return &ProgressWriter{Writer: w, OnWrite: onWrite, written: offset, lastReported: offset}
+}
+
+// SetThrottle makes writes wait for t; ctx cancels the wait.
+func (pw *ProgressWriter) SetThrottle(ctx context.Context, t *Throttle) {
+	pw.ctx = ctx
+	pw.throttle = t
+}
+
 func (pw *ProgressWriter) Write(p []byte) (int, error) {
+	if pw.throttle != nil {
+		if err := pw.throttle.Wait(pw.ctx, len(p)); err != nil {
+			return 0, err
+		}
+	}
 	n, err := pw.Writer.Write(p)
 	pw.written += int64(n)
 	if pw.OnWrite != nil && pw.written-pw.lastReported >= progressReportInterval {
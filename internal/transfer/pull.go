@@ -0,0 +1,82 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RemoteFile describes a file on the destination. Path uses the same
+// slash-separated form as TransferItem.RemotePath.
+type RemoteFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Lister is implemented by backends that can list files on the destination.
+// ListFiles walks dir recursively; a missing dir yields no files and no
+// error. In-progress part files are never listed.
+type Lister interface {
+	ListFiles(ctx context.Context, dir string) ([]RemoteFile, error)
+}
+
+// Downloader is implemented by backends that can copy files back from the
+// destination. The local file's modification time is set to the remote one.
+type Downloader interface {
+	Download(ctx context.Context, remotePath, localPath string, progressFn func(read int64)) error
+}
+
+// downloadTo copies src into localPath via a temporary file and preserves
+// src's modification time, so an interrupted download never leaves a
+// truncated file under the final name.
+func downloadTo(ctx context.Context, src remoteSource, localPath string, buf []byte, progressFn func(read int64)) error {
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("stat source: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	tmpPath := filepath.Join(filepath.Dir(localPath), partName(filepath.Base(localPath)))
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create dest: %w", err)
+	}
+	defer os.Remove(tmpPath) // no-op after a successful rename
+	defer dst.Close()
+
+	var writer io.Writer = dst
+	var pw *ProgressWriter
+	if progressFn != nil {
+		pw = NewProgressWriter(dst, progressFn)
+		writer = pw
+	}
+	if _, err := io.CopyBuffer(writer, &contextReader{ctx: ctx, r: src}, buf); err != nil {
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	if pw != nil {
+		pw.Flush()
+	}
+
+	if err := dst.Sync(); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmpPath, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, localPath)
+}
+
+// remoteSource is an open file on the destination (*os.File or *sftp.File).
+type remoteSource interface {
+	io.Reader
+	Stat() (os.FileInfo, error)
+}
@@ -0,0 +1,80 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestUSBBackend_ListFilesAndDownload(t *testing.T) {
+	mount := t.TempDir()
+	mtime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for name, data := range map[string]string{
+		"saves/snes/Mario.srm":     "save",
+		"saves/snes/.Zelda.rwpart": "partial",
+		"captures/shot.png":        "png!",
+	} {
+		p := filepath.Join(mount, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte(data), 0644)
+		os.Chtimes(p, mtime, mtime)
+	}
+
+	backend := NewUSBBackend(mount)
+	files, err := backend.ListFiles(context.Background(), "saves")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "saves/snes/Mario.srm" || files[0].Size != 4 {
+		t.Fatalf("ListFiles = %+v, want only saves/snes/Mario.srm", files)
+	}
+
+	missing, err := backend.ListFiles(context.Background(), "config")
+	if err != nil || len(missing) != 0 {
+		t.Errorf("ListFiles(missing) = %v, %v; want no files, no error", missing, err)
+	}
+
+	local := filepath.Join(t.TempDir(), "out", "Mario.srm")
+	if err := backend.Download(context.Background(), files[0].Path, local, nil); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), mtime)
+	}
+}
+
+func TestSFTPBackend_ListFilesAndDownload(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "saves", "psx"), 0755)
+	os.WriteFile(filepath.Join(root, "saves", "psx", "a.mcd"), []byte("card-a"), 0644)
+	os.WriteFile(filepath.Join(root, "saves", "b.srm"), []byte("b"), 0644)
+
+	backend := newTestSFTPBackend(t, root)
+	files, err := backend.ListFiles(context.Background(), "saves")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	sort.Strings(paths)
+	if len(paths) != 2 || paths[0] != "saves/b.srm" || paths[1] != "saves/psx/a.mcd" {
+		t.Fatalf("ListFiles paths = %v", paths)
+	}
+
+	local := filepath.Join(t.TempDir(), "a.mcd")
+	if err := backend.Download(context.Background(), "saves/psx/a.mcd", local, nil); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if data, _ := os.ReadFile(local); string(data) != "card-a" {
+		t.Errorf("downloaded %q, want %q", data, "card-a")
+	}
+}
@@ -0,0 +1,74 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+)
+
+// partSuffix marks an incomplete upload on the destination. Uploads are
+// written to a hidden part file and renamed into place once complete, so an
+// interrupted session never leaves a half-written ROM that ReplayOS lists.
+const partSuffix = ".rwpart"
+
+// resumeOverlap is how much of an existing part file is re-sent when
+// resuming. The tail of a file written just before a disconnect or unplug
+// may not have reached the disk intact, so it is never trusted.
+const resumeOverlap = 1024 * 1024
+
+// partName returns the temporary file name used while uploading base.
+func partName(base string) string {
+	return "." + base + partSuffix
+}
+
+// IsPartFile reports whether name is an in-progress upload.
+func IsPartFile(name string) bool {
+	return len(name) > len(partSuffix) && name[0] == '.' && name[len(name)-len(partSuffix):] == partSuffix
+}
+
+// resumeOffset returns the source offset to resume an upload from, given
+// the current part file and source file. It returns 0 (start over) when the
+// part is larger than the source or older than the source's last change.
+func resumeOffset(partSize, srcSize int64, partMod, srcMod time.Time) int64 {
+	if partSize <= 0 || partSize > srcSize || srcMod.After(partMod) {
+		return 0
+	}
+	offset := partSize - resumeOverlap
+	if offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// contextReader aborts a copy between reads once ctx is cancelled. It also
+// hides the source's WriterTo so io.CopyBuffer uses the pooled buffer.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// uploadReader returns the reader an upload copies from src, which is
+// positioned at offset. When h is set it also receives the whole file: the
+// part a resumed upload skips is hashed first, then the rest as it is read.
+func uploadReader(ctx context.Context, src *os.File, offset int64, h hash.Hash) (io.Reader, error) {
+	r := io.Reader(&contextReader{ctx: ctx, r: src})
+	if h == nil {
+		return r, nil
+	}
+	if offset > 0 {
+		if _, err := io.Copy(h, &contextReader{ctx: ctx, r: io.NewSectionReader(src, 0, offset)}); err != nil {
+			return nil, fmt.Errorf("hash source: %w", err)
+		}
+	}
+	return io.TeeReader(r, h), nil
+}
@@ -0,0 +1,189 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResumeOffset(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	mb := int64(1024 * 1024)
+
+	tests := []struct {
+		name     string
+		partSize int64
+		srcSize  int64
+		partMod  time.Time
+		srcMod   time.Time
+		want     int64
+	}{
+		{"no part", 0, 10 * mb, now, earlier, 0},
+		{"part smaller than overlap", mb / 2, 10 * mb, now, earlier, 0},
+		{"resume minus overlap", 4 * mb, 10 * mb, now, earlier, 3 * mb},
+		{"part larger than source", 11 * mb, 10 * mb, now, earlier, 0},
+		{"source changed since part", 4 * mb, 10 * mb, earlier, now, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumeOffset(tt.partSize, tt.srcSize, tt.partMod, tt.srcMod); got != tt.want {
+				t.Errorf("resumeOffset = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPartFile(t *testing.T) {
+	if !IsPartFile(partName("game.chd")) {
+		t.Error("expected part name to be detected")
+	}
+	for _, name := range []string{"game.chd", ".rwpart", "game.chd.rwpart"} {
+		if IsPartFile(name) {
+			t.Errorf("%q should not be a part file", name)
+		}
+	}
+}
+
+// makeResumeFixture writes a 3MB source file and a 2MB part file holding its
+// prefix, with the part newer than the source.
+func makeResumeFixture(t *testing.T, srcDir, partPath string) ([]byte, string) {
+	t.Helper()
+	content := make([]byte, 3*1024*1024)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	srcFile := filepath.Join(srcDir, "game.chd")
+	os.WriteFile(srcFile, content, 0644)
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(srcFile, past, past)
+
+	os.MkdirAll(filepath.Dir(partPath), 0755)
+	os.WriteFile(partPath, content[:2*1024*1024], 0644)
+	return content, srcFile
+}
+
+func TestUSBBackend_Upload_Resume(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	partPath := filepath.Join(dstDir, "roms", partName("game.chd"))
+	content, srcFile := makeResumeFixture(t, srcDir, partPath)
+
+	backend := NewUSBBackend(dstDir)
+	var first int64 = -1
+	err := backend.Upload(context.Background(), srcFile, "roms/game.chd", func(written int64) {
+		if first < 0 {
+			first = written
+		}
+	})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// Resuming from 1MB (2MB part minus overlap) means the first report is
+	// past the data a fresh upload would have sent by then.
+	if first <= 1024*1024 {
+		t.Errorf("expected progress to start after resume offset, first report %d", first)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dstDir, "roms", "game.chd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Error("resumed file content mismatch")
+	}
+	if _, err := os.Stat(partPath); !os.IsNotExist(err) {
+		t.Error("expected part file to be renamed away")
+	}
+}
+
+func TestUSBBackend_UploadSHA1_Resume(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	partPath := filepath.Join(dstDir, "roms", partName("game.chd"))
+	content, srcFile := makeResumeFixture(t, srcDir, partPath)
+
+	sum, err := NewUSBBackend(dstDir).UploadSHA1(context.Background(), srcFile, "roms/game.chd", nil)
+	if err != nil {
+		t.Fatalf("UploadSHA1 failed: %v", err)
+	}
+	// The part already on the device is hashed too, not just what was sent.
+	if want := fmt.Sprintf("%x", sha1.Sum(content)); sum != want {
+		t.Errorf("SHA1 = %s, want %s", sum, want)
+	}
+}
+
+func TestUSBBackend_Upload_StalePartRestarts(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	partPath := filepath.Join(dstDir, "roms", partName("game.chd"))
+	content, srcFile := makeResumeFixture(t, srcDir, partPath)
+
+	// Corrupt the part and make it older than the source: it must not be reused.
+	os.WriteFile(partPath, bytes.Repeat([]byte{'X'}, 2*1024*1024), 0644)
+	past := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(partPath, past, past)
+
+	backend := NewUSBBackend(dstDir)
+	if err := backend.Upload(context.Background(), srcFile, "roms/game.chd", nil); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dstDir, "roms", "game.chd"))
+	if !bytes.Equal(data, content) {
+		t.Error("stale part file was resumed")
+	}
+}
+
+func TestUSBBackend_Upload_CancelledLeavesNoDest(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "game.chd")
+	os.WriteFile(srcFile, make([]byte, 4*1024*1024), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	backend := NewUSBBackend(dstDir)
+	err := backend.Upload(ctx, srcFile, "roms/game.chd", func(written int64) {
+		cancel() // cancel after the first chunk lands
+	})
+	if err == nil {
+		t.Fatal("expected cancellation error")
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "roms", "game.chd")); !os.IsNotExist(err) {
+		t.Error("interrupted upload must not leave a file under the final name")
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "roms", partName("game.chd"))); err != nil {
+		t.Error("expected part file to remain for resuming")
+	}
+}
+
+func TestSFTPBackend_Upload_Resume(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	partPath := filepath.Join(dstDir, "roms", partName("game.chd"))
+	content, srcFile := makeResumeFixture(t, srcDir, partPath)
+
+	// An existing complete file is replaced by the rename.
+	os.WriteFile(filepath.Join(dstDir, "roms", "game.chd"), []byte("old"), 0644)
+
+	backend := newTestSFTPBackend(t, dstDir)
+	if err := backend.Upload(context.Background(), srcFile, "roms/game.chd", nil); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dstDir, "roms", "game.chd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Error("resumed file content mismatch")
+	}
+	if _, err := os.Stat(partPath); !os.IsNotExist(err) {
+		t.Error("expected part file to be renamed away")
+	}
+}
@@ -21,23 +21,26 @@ import (
 var rsyncProgressRegex = regexp.MustCompile(`(\d+)%\s+([\d.]+\S+/s)`)
 
 // RsyncBackend implements BulkTransferBackend using rsync over SSH.
-// Requires sshpass for password-based authentication.
+// Requires sshpass for password-based authentication; key and agent
+// authentication use ssh directly.
 // Multiple folders are transferred in parallel — one rsync process per folder,
 // limited by Concurrency (default: min(folders, NumCPU, 4)).
 type RsyncBackend struct {
 	Host        string
 	User        string
-	Password    string
+	Auth        SSHAuth
 	Port        int
-	Concurrency int // 0 = auto
+	Concurrency int  // 0 = auto
+	Checksum    bool // compare files by checksum instead of size and mtime
+	BWLimit     int  // KiB/s shared by all rsync processes; 0 = unlimited
 }
 
-func NewRsyncBackend(host string, port int, user, password string) *RsyncBackend {
+func NewRsyncBackend(host string, port int, user string, auth SSHAuth) *RsyncBackend {
 	return &RsyncBackend{
-		Host:     host,
-		Port:     port,
-		User:     user,
-		Password: password,
+		Host: host,
+		Port: port,
+		User: user,
+		Auth: auth,
 	}
 }
 
@@ -70,6 +73,7 @@ func (r *RsyncBackend) TransferFolders(ctx context.Context, folders []FolderMapp
 	}
 
 	concurrency := r.effectiveConcurrency(len(folders))
+	bwlimit := bwlimitPerProcess(r.BWLimit, concurrency)
 	totalFolders := len(folders)
 
 	var completed atomic.Int64
@@ -92,7 +96,7 @@ func (r *RsyncBackend) TransferFolders(ctx context.Context, folders []FolderMapp
 			defer wg.Done()
 			defer func() { <-sem }() // release slot
 
-			err := r.transferFolder(ctx, f, &completed, totalFolders, progressCh)
+			err := r.transferFolder(ctx, f, bwlimit, &completed, totalFolders, progressCh)
 			if err != nil {
 				firstErr.CompareAndSwap(nil, err)
 			}
@@ -107,13 +111,25 @@ func (r *RsyncBackend) TransferFolders(ctx context.Context, folders []FolderMapp
 	return nil
 }
 
-func (r *RsyncBackend) transferFolder(ctx context.Context, folder FolderMapping, completed *atomic.Int64, totalFolders int, progressCh chan<- TransferProgress) error {
+// bwlimitPerProcess splits a total KiB/s limit evenly between concurrent
+// rsync processes, leaving each at least 1 KiB/s.
+func bwlimitPerProcess(total, processes int) int {
+	if total <= 0 {
+		return 0
+	}
+	limit := total / processes
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+func (r *RsyncBackend) transferFolder(ctx context.Context, folder FolderMapping, bwlimit int, completed *atomic.Int64, totalFolders int, progressCh chan<- TransferProgress) error {
 	// Trailing slash on source makes rsync copy the contents, not the directory itself.
 	localDir := strings.TrimRight(folder.LocalDir, "/") + "/"
 	remoteDest := fmt.Sprintf("%s@%s:%s/", r.User, r.Host, folder.RemoteDir)
 
-	// sshpass reads the password from the SSHPASS environment variable (-e flag).
-	sshCmd := fmt.Sprintf("sshpass -e ssh -p %d -o StrictHostKeyChecking=no", r.Port)
+	sshCmd, sshEnv := r.Auth.sshCommand(r.Port)
 
 	args := []string{
 		"-a",
@@ -122,12 +138,26 @@ func (r *RsyncBackend) transferFolder(ctx context.Context, folder FolderMapping,
 		"--no-owner",
 		"--no-group",
 		"-e", sshCmd,
-		localDir,
-		remoteDest,
 	}
+	if r.Checksum {
+		// rsync verifies every transferred file with a whole-file checksum
+		// already; -c additionally re-checks files that look unchanged.
+		args = append(args, "--checksum")
+	}
+	if bwlimit > 0 {
+		args = append(args, fmt.Sprintf("--bwlimit=%d", bwlimit))
+	}
+	if len(folder.Only) > 0 {
+		// "***" matches the directory and everything below it.
+		for _, name := range folder.Only {
+			args = append(args, "--include=/"+name+"/***")
+		}
+		args = append(args, "--exclude=/*")
+	}
+	args = append(args, localDir, remoteDest)
 
 	cmd := exec.CommandContext(ctx, "rsync", args...)
-	cmd.Env = append(cmd.Environ(), "SSHPASS="+r.Password)
+	cmd.Env = append(cmd.Environ(), sshEnv...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -156,7 +186,7 @@ func (r *RsyncBackend) transferFolder(ctx context.Context, folder FolderMapping,
 
 	if cmdErr == nil {
 		done := completed.Add(1)
-		sendProgressNonBlocking(progressCh, TransferProgress{
+		sendProgress(progressCh, TransferProgress{
 			FileIndex:  int(done) - 1,
 			TotalFiles: totalFolders,
 			Filename:   filepath.Base(folder.LocalDir),
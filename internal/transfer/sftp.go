@@ -0,0 +1,435 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sftpBufSize is the buffer size for SFTP uploads (256KB). Each write is
+	// split into packets that are sent concurrently by the SFTP client.
+	sftpBufSize = 256 * 1024
+
+	// sftpMaxPacket is the SFTP payload size. OpenSSH's sftp-server accepts
+	// packets well above the 32KB minimum every server must support.
+	sftpMaxPacket = 64 * 1024
+
+	// sftpConcurrentRequests is the number of in-flight requests per file.
+	sftpConcurrentRequests = 64
+)
+
+// SFTPBackend implements TransferBackend using a native SFTP client over SSH.
+// No external tools are required. Remote paths are resolved relative to
+// RootPath, mirroring how USBBackend resolves them against MountPath.
+type SFTPBackend struct {
+	Host     string
+	Port     int
+	User     string
+	Auth     SSHAuth
+	RootPath string
+	Throttle *Throttle // bandwidth cap and time window for uploads; nil = none
+
+	sshClient  *ssh.Client
+	client     *sftp.Client
+	closeAgent func()
+	bufferPool sync.Pool
+}
+
+func NewSFTPBackend(host string, port int, user string, auth SSHAuth, rootPath string) *SFTPBackend {
+	return &SFTPBackend{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		Auth:     auth,
+		RootPath: rootPath,
+		bufferPool: sync.Pool{
+			New: func() interface{} {
+				b := make([]byte, sftpBufSize)
+				return &b
+			},
+		},
+	}
+}
+
+func (s *SFTPBackend) Connect(ctx context.Context) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	sshConfig, closeAgent, err := s.Auth.clientConfig(s.User)
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: sshDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		closeAgent()
+		return fmt.Errorf("connect to %s: %w", addr, err)
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	if err != nil {
+		conn.Close()
+		closeAgent()
+		return fmt.Errorf("ssh handshake with %s: %w", addr, err)
+	}
+	sshClient := ssh.NewClient(c, chans, reqs)
+
+	client, err := sftp.NewClient(sshClient,
+		sftp.MaxPacketUnchecked(sftpMaxPacket),
+		sftp.MaxConcurrentRequestsPerFile(sftpConcurrentRequests),
+		sftp.UseConcurrentWrites(true),
+		sftp.UseConcurrentReads(true),
+	)
+	if err != nil {
+		sshClient.Close()
+		closeAgent()
+		return fmt.Errorf("start sftp session: %w", err)
+	}
+
+	s.sshClient = sshClient
+	s.client = client
+	s.closeAgent = closeAgent
+	return nil
+}
+
+func (s *SFTPBackend) Close() error {
+	var err error
+	if s.client != nil {
+		err = s.client.Close()
+		s.client = nil
+	}
+	if s.sshClient != nil {
+		if cerr := s.sshClient.Close(); err == nil {
+			err = cerr
+		}
+		s.sshClient = nil
+	}
+	if s.closeAgent != nil {
+		s.closeAgent()
+		s.closeAgent = nil
+	}
+	return err
+}
+
+// remotePath resolves a plan path against RootPath.
+func (s *SFTPBackend) remotePath(p string) string {
+	return path.Join("/", s.RootPath, p)
+}
+
+func (s *SFTPBackend) MkdirAll(p string) error {
+	if s.client == nil {
+		return fmt.Errorf("sftp: not connected")
+	}
+	return s.client.MkdirAll(s.remotePath(p))
+}
+
+func (s *SFTPBackend) FileExists(p string, expectedSize int64) (bool, error) {
+	if s.client == nil {
+		return false, fmt.Errorf("sftp: not connected")
+	}
+	info, err := s.client.Stat(s.remotePath(p))
+	if err != nil {
+		return false, nil
+	}
+	return info.Size() == expectedSize, nil
+}
+
+func (s *SFTPBackend) Upload(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) error {
+	return s.upload(ctx, localPath, remotePath, progressFn, nil)
+}
+
+// UploadSHA1 uploads like Upload and returns the SHA1 of the local file,
+// hashed as it is read for the upload.
+func (s *SFTPBackend) UploadSHA1(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) (string, error) {
+	h := sha1.New()
+	if err := s.upload(ctx, localPath, remotePath, progressFn, h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *SFTPBackend) upload(ctx context.Context, localPath, remotePath string, progressFn func(written int64), h hash.Hash) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if s.client == nil {
+		return fmt.Errorf("sftp: not connected")
+	}
+	if err := s.Throttle.WaitWindow(ctx); err != nil {
+		return err
+	}
+
+	destPath := s.remotePath(remotePath)
+	if err := s.client.MkdirAll(path.Dir(destPath)); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("stat source: %w", err)
+	}
+
+	// Write to a hidden part file, resuming an earlier interrupted upload
+	// when one is present, and rename it into place once complete.
+	partPath := path.Join(path.Dir(destPath), partName(path.Base(destPath)))
+	var offset int64
+	if partInfo, err := s.client.Stat(partPath); err == nil {
+		offset = resumeOffset(partInfo.Size(), srcInfo.Size(), partInfo.ModTime(), srcInfo.ModTime())
+	}
+
+	dst, err := s.client.OpenFile(partPath, os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		return fmt.Errorf("create dest: %w", err)
+	}
+	defer dst.Close()
+
+	if err := dst.Truncate(offset); err != nil {
+		return fmt.Errorf("truncate dest: %w", err)
+	}
+	if offset > 0 {
+		if _, err := src.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("seek source: %w", err)
+		}
+		if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("seek dest: %w", err)
+		}
+	}
+
+	var writer io.Writer = dst
+	var pw *ProgressWriter
+	if progressFn != nil || s.Throttle != nil {
+		pw = NewProgressWriterAt(dst, offset, progressFn)
+		pw.SetThrottle(ctx, s.Throttle)
+		writer = pw
+	}
+
+	bufp := s.bufferPool.Get().(*[]byte)
+	defer s.bufferPool.Put(bufp)
+
+	r, err := uploadReader(ctx, src, offset, h)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyBuffer(writer, r, *bufp); err != nil {
+		return fmt.Errorf("copy failed: %w", err)
+	}
+
+	if pw != nil {
+		pw.Flush()
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return s.rename(partPath, destPath)
+}
+
+// rename moves a completed part file over destPath. posix-rename replaces
+// the target atomically; servers without it need the target removed first.
+func (s *SFTPBackend) rename(partPath, destPath string) error {
+	if err := s.client.PosixRename(partPath, destPath); err == nil {
+		return nil
+	}
+	s.client.Remove(destPath)
+	return s.client.Rename(partPath, destPath)
+}
+
+// RemoteSHA1 runs sha1sum on the device. If the command is unavailable, the
+// file is streamed back over SFTP and hashed locally instead.
+func (s *SFTPBackend) RemoteSHA1(ctx context.Context, p string) (string, error) {
+	if s.client == nil {
+		return "", fmt.Errorf("sftp: not connected")
+	}
+	remote := s.remotePath(p)
+
+	if sum, err := s.runSHA1Sum(ctx, remote); err == nil {
+		return sum, nil
+	} else if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	f, err := s.client.Open(remote)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, &contextReader{ctx: ctx, r: f}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// output runs cmd on the device over the SSH connection and returns its
+// standard output.
+func (s *SFTPBackend) output(ctx context.Context, cmd string) ([]byte, error) {
+	session, err := s.sshClient.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	// Closing the session aborts the remote command on cancellation.
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	return session.Output(cmd)
+}
+
+func (s *SFTPBackend) runSHA1Sum(ctx context.Context, remote string) (string, error) {
+	out, err := s.output(ctx, "sha1sum -- "+shellQuote(remote))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 || len(fields[0]) != sha1.Size*2 {
+		return "", fmt.Errorf("unexpected sha1sum output: %q", out)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+func (s *SFTPBackend) ListFiles(ctx context.Context, dir string) ([]RemoteFile, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("sftp: not connected")
+	}
+	root := s.remotePath(dir)
+	if _, err := s.client.Stat(root); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []RemoteFile
+	walker := s.client.Walk(root)
+	for walker.Step() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := walker.Err(); err != nil {
+			return nil, err
+		}
+		info := walker.Stat()
+		if info.IsDir() || IsPartFile(info.Name()) || info.Name() == ManifestName {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), root), "/")
+		files = append(files, RemoteFile{
+			Path:    path.Join(dir, rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+func (s *SFTPBackend) Download(ctx context.Context, remotePath, localPath string, progressFn func(read int64)) error {
+	if s.client == nil {
+		return fmt.Errorf("sftp: not connected")
+	}
+	src, err := s.client.Open(s.remotePath(remotePath))
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+
+	bufp := s.bufferPool.Get().(*[]byte)
+	defer s.bufferPool.Put(bufp)
+
+	return downloadTo(ctx, src, localPath, *bufp, progressFn)
+}
+
+func (s *SFTPBackend) RemoveFile(p string) error {
+	if s.client == nil {
+		return fmt.Errorf("sftp: not connected")
+	}
+	return s.client.Remove(s.remotePath(p))
+}
+
+// FreeSpace reports the space available under RootPath, from `df` on the
+// device or, when no shell is available, the SFTP statvfs extension.
+func (s *SFTPBackend) FreeSpace(ctx context.Context) (int64, error) {
+	if s.client == nil {
+		return 0, fmt.Errorf("sftp: not connected")
+	}
+	root := s.remotePath("")
+
+	if out, err := s.output(ctx, "df -Pk -- "+shellQuote(root)); err == nil {
+		if free, err := parseDFAvailable(out); err == nil {
+			return free, nil
+		}
+	} else if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	st, err := s.client.StatVFS(root)
+	if err != nil {
+		return 0, fmt.Errorf("free space of %s: %w", root, err)
+	}
+	return int64(st.Frsize * st.Bavail), nil
+}
+
+// DiskUsage reports the size and use of the filesystem holding RootPath,
+// like FreeSpace.
+func (s *SFTPBackend) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	if s.client == nil {
+		return DiskUsage{}, fmt.Errorf("sftp: not connected")
+	}
+	root := s.remotePath("")
+
+	if out, err := s.output(ctx, "df -Pk -- "+shellQuote(root)); err == nil {
+		if u, err := parseDF(out); err == nil {
+			return u, nil
+		}
+	} else if ctx.Err() != nil {
+		return DiskUsage{}, ctx.Err()
+	}
+
+	st, err := s.client.StatVFS(root)
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("disk usage of %s: %w", root, err)
+	}
+	return DiskUsage{
+		Total: int64(st.Frsize * st.Blocks),
+		Used:  int64(st.Frsize * (st.Blocks - st.Bfree)),
+		Free:  int64(st.Frsize * st.Bavail),
+	}, nil
+}
+
+// ReadSystemFile reads an absolute path on the device, outside RootPath.
+// /proc files report a size of zero, so the file is read to EOF rather
+// than by its size.
+func (s *SFTPBackend) ReadSystemFile(ctx context.Context, p string) ([]byte, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("sftp: not connected")
+	}
+	f, err := s.client.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(&contextReader{ctx: ctx, r: f}, maxSystemFile))
+}
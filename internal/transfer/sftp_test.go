@@ -0,0 +1,242 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	testSSHUser     = "root"
+	testSSHPassword = "replayos"
+)
+
+// testSSHServer is an in-process SSH server with an SFTP subsystem.
+type testSSHServer struct {
+	host    string
+	port    int
+	hostKey ssh.PublicKey
+}
+
+// startTestSFTPServer runs an in-process SSH server with an SFTP subsystem
+// rooted at the real filesystem. It accepts the test password and, when
+// authorized is non-nil, that public key.
+func startTestSFTPServer(t *testing.T, authorized ssh.PublicKey) *testSSHServer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == testSSHUser && string(pass) == testSSHPassword {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if authorized != nil && c.User() == testSSHUser && bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, serverConfig)
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return &testSSHServer{host: host, port: port, hostKey: signer.PublicKey()}
+}
+
+func serveTestSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unsupported channel")
+			continue
+		}
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(ch)
+				if err != nil {
+					ch.Close()
+					return
+				}
+				server.Serve()
+				server.Close()
+				return
+			}
+		}()
+	}
+}
+
+func newTestSFTPBackend(t *testing.T, root string) *SFTPBackend {
+	t.Helper()
+	srv := startTestSFTPServer(t, nil)
+	backend := NewSFTPBackend(srv.host, srv.port, testSSHUser, SSHAuth{Password: testSSHPassword, InsecureHostKey: true}, root)
+	if err := backend.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { backend.Close() })
+	return backend
+}
+
+func TestSFTPBackend_Connect_BadPassword(t *testing.T) {
+	srv := startTestSFTPServer(t, nil)
+	backend := NewSFTPBackend(srv.host, srv.port, testSSHUser, SSHAuth{Password: "wrong", InsecureHostKey: true}, t.TempDir())
+	if err := backend.Connect(context.Background()); err == nil {
+		backend.Close()
+		t.Error("expected authentication error")
+	}
+}
+
+func TestSFTPBackend_Upload(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+
+	// Larger than one buffer so the copy loop runs more than once.
+	content := make([]byte, sftpBufSize*3+123)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	srcFile := filepath.Join(srcDir, "game.chd")
+	os.WriteFile(srcFile, content, 0644)
+
+	backend := newTestSFTPBackend(t, dstDir)
+
+	var lastWritten int64
+	err := backend.Upload(context.Background(), srcFile, "roms/sega_dc/game.chd", func(written int64) {
+		lastWritten = written
+	})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if lastWritten != int64(len(content)) {
+		t.Errorf("expected %d bytes written, got %d", len(content), lastWritten)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dstDir, "roms", "sega_dc", "game.chd"))
+	if err != nil {
+		t.Fatalf("failed to read dest file: %v", err)
+	}
+	if string(data) != string(content) {
+		t.Error("content mismatch")
+	}
+}
+
+func TestSFTPBackend_FileExistsAndMkdirAll(t *testing.T) {
+	dstDir := t.TempDir()
+	backend := newTestSFTPBackend(t, dstDir)
+
+	if err := backend.MkdirAll("roms/nintendo_nes"); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dstDir, "roms", "nintendo_nes")); err != nil || !info.IsDir() {
+		t.Fatal("expected remote directory to be created")
+	}
+
+	exists, _ := backend.FileExists("roms/nintendo_nes/game.nes", 5)
+	if exists {
+		t.Error("expected file to not exist")
+	}
+
+	os.WriteFile(filepath.Join(dstDir, "roms", "nintendo_nes", "game.nes"), []byte("hello"), 0644)
+
+	exists, _ = backend.FileExists("roms/nintendo_nes/game.nes", 5)
+	if !exists {
+		t.Error("expected file to exist with correct size")
+	}
+	exists, _ = backend.FileExists("roms/nintendo_nes/game.nes", 999)
+	if exists {
+		t.Error("expected file to not match with wrong size")
+	}
+}
+
+func TestSFTPBackend_ExecutePlan(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(srcDir, "nintendo_nes"), 0755)
+	os.WriteFile(filepath.Join(srcDir, "nintendo_nes", "a.nes"), []byte("A"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "nintendo_nes", "b.nes"), []byte("BB"), 0644)
+
+	backend := newTestSFTPBackend(t, dstDir)
+
+	plan, err := BuildTransferPlan(context.Background(), backend, srcDir, "roms", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Execute(context.Background(), backend, plan, 2, nil); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// A second sync pass should skip everything.
+	plan, err = BuildTransferPlan(context.Background(), backend, srcDir, "roms", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.SkipCount != 2 {
+		t.Errorf("expected 2 skips after upload, got %d", plan.SkipCount)
+	}
+}
+
+func TestSFTPBackend_Upload_Cancelled(t *testing.T) {
+	srcDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "test.bin")
+	os.WriteFile(srcFile, []byte("data"), 0644)
+
+	backend := newTestSFTPBackend(t, t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := backend.Upload(ctx, srcFile, "roms/test.bin", nil); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
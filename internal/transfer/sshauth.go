@@ -0,0 +1,216 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshDialTimeout bounds the TCP connect and SSH handshake.
+const sshDialTimeout = 15 * time.Second
+
+// SSHAuth holds the credentials and host verification settings shared by the
+// SSH-based backends (SFTP and rsync).
+type SSHAuth struct {
+	Password       string
+	IdentityFile   string // private key path; must not be passphrase-protected
+	UseAgent       bool   // use the ssh-agent at $SSH_AUTH_SOCK
+	KnownHostsFile string // empty uses ~/.ssh/known_hosts
+	// InsecureHostKey skips host key verification when KnownHostsFile is
+	// empty. Every connection made this way logs a warning.
+	InsecureHostKey bool
+}
+
+// SSHAuthFromDevice builds SSHAuth from the device section of the config.
+func SSHAuthFromDevice(dev config.DeviceConfig) SSHAuth {
+	return SSHAuth{
+		Password:        dev.Password,
+		IdentityFile:    dev.IdentityFile,
+		UseAgent:        dev.UseAgent,
+		KnownHostsFile:  dev.KnownHostsFile,
+		InsecureHostKey: dev.InsecureHostKey,
+	}
+}
+
+// UsesKeys reports whether key-based authentication is configured.
+func (a SSHAuth) UsesKeys() bool {
+	return a.IdentityFile != "" || a.UseAgent
+}
+
+// NeedsSSHPass reports whether the rsync path has to feed a password to ssh
+// through sshpass.
+func (a SSHAuth) NeedsSSHPass() bool {
+	return a.Password != ""
+}
+
+// clientConfig builds an ssh.ClientConfig for user. The returned cleanup
+// function releases the agent connection, if one was opened, and must be
+// called once the SSH connection is closed.
+func (a SSHAuth) clientConfig(user string) (*ssh.ClientConfig, func(), error) {
+	cleanup := func() {}
+	var methods []ssh.AuthMethod
+
+	if a.IdentityFile != "" {
+		signer, err := loadIdentity(a.IdentityFile)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if a.UseAgent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, cleanup, fmt.Errorf("ssh-agent requested but SSH_AUTH_SOCK is not set")
+		}
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("connect to ssh-agent: %w", err)
+		}
+		cleanup = func() { conn.Close() }
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	if a.Password != "" {
+		password := a.Password
+		methods = append(methods,
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+
+	if len(methods) == 0 {
+		return nil, cleanup, fmt.Errorf("no SSH credentials configured (set a password, identity file, or ssh-agent)")
+	}
+
+	hostKeyCallback, err := a.hostKeyCallback()
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	}, cleanup, nil
+}
+
+// errNoKnownHosts is returned when there is nothing to check host keys
+// against and insecure mode was not asked for.
+var errNoKnownHosts = errors.New("no known_hosts file to verify the device's host key: ssh into the device once, set known_hosts_file, or set insecure_host_key")
+
+// hostKeyCallback verifies host keys against KnownHostsFile, or
+// ~/.ssh/known_hosts when that is empty, unless InsecureHostKey is set.
+func (a SSHAuth) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	path := a.KnownHostsFile
+	if path == "" && a.InsecureHostKey {
+		return func(hostname string, _ net.Addr, _ ssh.PublicKey) error {
+			log.Printf("warning: not verifying the host key of %s (insecure_host_key is set)", hostname)
+			return nil
+		}, nil
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errNoKnownHosts
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+		if _, err := os.Stat(path); err != nil {
+			return nil, errNoKnownHosts
+		}
+	}
+
+	cb, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("load known_hosts: %w", err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := cb(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return fmt.Errorf("host key of %s is not in %s; ssh into the device once to add it", hostname, path)
+		}
+		return err
+	}, nil
+}
+
+func loadIdentity(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read identity file: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			return nil, fmt.Errorf("identity file %s is passphrase-protected; add it to ssh-agent and enable use_agent", path)
+		}
+		return nil, fmt.Errorf("parse identity file: %w", err)
+	}
+	return signer, nil
+}
+
+// sshCommand returns the ssh invocation rsync should use via -e, plus any
+// extra environment variables it needs.
+func (a SSHAuth) sshCommand(port int) (string, []string) {
+	args := []string{"ssh", "-p", fmt.Sprint(port)}
+
+	if a.IdentityFile != "" {
+		args = append(args, "-i", shellQuote(a.IdentityFile))
+		if !a.UseAgent {
+			args = append(args, "-o", "IdentitiesOnly=yes")
+		}
+	}
+
+	switch {
+	case a.KnownHostsFile != "":
+		args = append(args,
+			"-o", "StrictHostKeyChecking=yes",
+			"-o", "UserKnownHostsFile="+shellQuote(a.KnownHostsFile))
+	case a.InsecureHostKey:
+		log.Printf("warning: not verifying the host key for rsync (insecure_host_key is set)")
+		args = append(args, "-o", "StrictHostKeyChecking=no")
+	default:
+		// ssh checks ~/.ssh/known_hosts itself.
+		args = append(args, "-o", "StrictHostKeyChecking=yes")
+	}
+
+	if !a.NeedsSSHPass() {
+		// Never fall back to an interactive password prompt nobody can answer.
+		args = append(args, "-o", "BatchMode=yes")
+		return strings.Join(args, " "), nil
+	}
+
+	// sshpass reads the password from the SSHPASS environment variable (-e flag).
+	return "sshpass -e " + strings.Join(args, " "), []string{"SSHPASS=" + a.Password}
+}
+
+// shellSafe matches strings that need no quoting in a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./=:@%+,-]+$`)
+
+// shellQuote quotes s for a POSIX shell, such as the one rsync uses to split
+// its -e command or the remote shell running an SSH exec request.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
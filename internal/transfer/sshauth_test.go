@@ -0,0 +1,176 @@
+package transfer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// writeTestIdentity generates an ed25519 key, writes it in OpenSSH format
+// and returns its path and public key.
+func writeTestIdentity(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, sshPub
+}
+
+func writeKnownHosts(t *testing.T, srv *testSSHServer, key ssh.PublicKey) string {
+	t.Helper()
+	addr := net.JoinHostPort(srv.host, strconv.Itoa(srv.port))
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSFTPBackend_Connect_IdentityFile(t *testing.T) {
+	keyPath, pub := writeTestIdentity(t)
+	srv := startTestSFTPServer(t, pub)
+
+	backend := NewSFTPBackend(srv.host, srv.port, testSSHUser, SSHAuth{IdentityFile: keyPath, InsecureHostKey: true}, t.TempDir())
+	if err := backend.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect with identity file failed: %v", err)
+	}
+	backend.Close()
+}
+
+func TestSFTPBackend_Connect_UnauthorizedKey(t *testing.T) {
+	keyPath, _ := writeTestIdentity(t)
+	_, otherPub := writeTestIdentity(t)
+	srv := startTestSFTPServer(t, otherPub)
+
+	backend := NewSFTPBackend(srv.host, srv.port, testSSHUser, SSHAuth{IdentityFile: keyPath, InsecureHostKey: true}, t.TempDir())
+	if err := backend.Connect(context.Background()); err == nil {
+		backend.Close()
+		t.Error("expected unauthorized key to be rejected")
+	}
+}
+
+func TestSFTPBackend_Connect_KnownHosts(t *testing.T) {
+	srv := startTestSFTPServer(t, nil)
+
+	auth := SSHAuth{Password: testSSHPassword, KnownHostsFile: writeKnownHosts(t, srv, srv.hostKey)}
+	backend := NewSFTPBackend(srv.host, srv.port, testSSHUser, auth, t.TempDir())
+	if err := backend.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect with matching known_hosts failed: %v", err)
+	}
+	backend.Close()
+
+	// A different host key for the same address must be refused.
+	_, otherPub := writeTestIdentity(t)
+	auth.KnownHostsFile = writeKnownHosts(t, srv, otherPub)
+	backend = NewSFTPBackend(srv.host, srv.port, testSSHUser, auth, t.TempDir())
+	if err := backend.Connect(context.Background()); err == nil {
+		backend.Close()
+		t.Error("expected host key mismatch to be rejected")
+	}
+}
+
+func TestSFTPBackend_Connect_DefaultKnownHosts(t *testing.T) {
+	srv := startTestSFTPServer(t, nil)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Without any known_hosts file the connection is refused.
+	auth := SSHAuth{Password: testSSHPassword}
+	backend := NewSFTPBackend(srv.host, srv.port, testSSHUser, auth, t.TempDir())
+	if err := backend.Connect(context.Background()); err == nil {
+		backend.Close()
+		t.Fatal("expected connect without known_hosts to be refused")
+	}
+
+	// ~/.ssh/known_hosts is used when no file is configured.
+	os.MkdirAll(filepath.Join(home, ".ssh"), 0700)
+	os.Rename(writeKnownHosts(t, srv, srv.hostKey), filepath.Join(home, ".ssh", "known_hosts"))
+	backend = NewSFTPBackend(srv.host, srv.port, testSSHUser, auth, t.TempDir())
+	if err := backend.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect with ~/.ssh/known_hosts failed: %v", err)
+	}
+	backend.Close()
+}
+
+func TestSSHAuth_NoCredentials(t *testing.T) {
+	if _, _, err := (SSHAuth{}).clientConfig("root"); err == nil {
+		t.Error("expected error when no credentials are configured")
+	}
+}
+
+func TestSSHAuth_SSHCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		auth        SSHAuth
+		wantPrefix  string
+		contains    []string
+		wantSSHPass bool
+	}{
+		{
+			name:        "password",
+			auth:        SSHAuth{Password: "replayos"},
+			wantPrefix:  "sshpass -e ssh -p 22",
+			contains:    []string{"StrictHostKeyChecking=yes"},
+			wantSSHPass: true,
+		},
+		{
+			name:       "insecure host key",
+			auth:       SSHAuth{UseAgent: true, InsecureHostKey: true},
+			wantPrefix: "ssh -p 22",
+			contains:   []string{"StrictHostKeyChecking=no"},
+		},
+		{
+			name:       "identity file",
+			auth:       SSHAuth{IdentityFile: "/home/me/.ssh/id_ed25519"},
+			wantPrefix: "ssh -p 22",
+			contains:   []string{"-i /home/me/.ssh/id_ed25519", "IdentitiesOnly=yes", "BatchMode=yes"},
+		},
+		{
+			name:       "agent with known hosts",
+			auth:       SSHAuth{UseAgent: true, KnownHostsFile: "/home/me/my hosts"},
+			wantPrefix: "ssh -p 22",
+			contains:   []string{"StrictHostKeyChecking=yes", "UserKnownHostsFile='/home/me/my hosts'", "BatchMode=yes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, env := tt.auth.sshCommand(22)
+			if !strings.HasPrefix(cmd, tt.wantPrefix) {
+				t.Errorf("command %q does not start with %q", cmd, tt.wantPrefix)
+			}
+			for _, c := range tt.contains {
+				if !strings.Contains(cmd, c) {
+					t.Errorf("command %q missing %q", cmd, c)
+				}
+			}
+			if got := len(env) > 0; got != tt.wantSSHPass {
+				t.Errorf("SSHPASS env set = %v, want %v", got, tt.wantSSHPass)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+)
+
+// biosBase is the device folder that holds BIOS files.
+const biosBase = "bios"
+
+// maxSystemFile bounds how much of a system file ReadSystemFile returns.
+const maxSystemFile = 1 << 20
+
+// DiskUsage is the size and use of the filesystem holding the device root.
+type DiskUsage struct {
+	Total int64
+	Used  int64
+	Free  int64 // available to unprivileged users; may be less than Total-Used
+}
+
+// UsageReporter is implemented by backends that can report the size and use
+// of the destination filesystem.
+type UsageReporter interface {
+	DiskUsage(ctx context.Context) (DiskUsage, error)
+}
+
+// SystemFileReader is implemented by backends that can read files outside
+// the device root, such as /proc entries.
+type SystemFileReader interface {
+	ReadSystemFile(ctx context.Context, path string) ([]byte, error)
+}
+
+// SystemUsage is the space used by one roms/<system> folder.
+type SystemUsage struct {
+	Folder string
+	Files  int
+	Bytes  int64
+}
+
+// BIOSGroup is the BIOS completeness of one folder under bios/, checked
+// against the BIOS files the organizer knows. Names are lower case.
+type BIOSGroup struct {
+	Folder  string // relative to bios/; "" is bios/ itself
+	Present []string
+	Missing []string
+}
+
+// DeviceInfo describes the device's hardware and system.
+type DeviceInfo struct {
+	Model  string // e.g. "Raspberry Pi 5 Model B Rev 1.0"
+	OS     string // PRETTY_NAME from /etc/os-release
+	Kernel string
+	Memory int64 // total RAM in bytes
+}
+
+// DeviceStatus is a snapshot of what is on the device.
+type DeviceStatus struct {
+	Disk        *DiskUsage    // nil if the backend cannot report it
+	Systems     []SystemUsage // largest first
+	ROMBytes    int64
+	BIOS        []BIOSGroup
+	BIOSPresent int
+	BIOSTotal   int
+	Info        *DeviceInfo // nil if the backend cannot read system files
+
+	// Errors lists the parts that could not be read; the rest of the
+	// status is still filled in.
+	Errors []error
+}
+
+// ReadDeviceStatus collects disk usage, per-system usage of roms/, BIOS
+// completeness and system information from a connected backend. Each part
+// needs its optional interface (UsageReporter, Lister, SystemFileReader)
+// and is left empty when the backend lacks it.
+func ReadDeviceStatus(ctx context.Context, backend TransferBackend) (*DeviceStatus, error) {
+	st := &DeviceStatus{}
+
+	if r, ok := backend.(UsageReporter); ok {
+		if u, err := r.DiskUsage(ctx); err == nil {
+			st.Disk = &u
+		} else {
+			st.Errors = append(st.Errors, fmt.Errorf("disk usage: %w", err))
+		}
+	}
+
+	if lister, ok := backend.(Lister); ok {
+		if files, err := lister.ListFiles(ctx, romsBase); err == nil {
+			st.Systems, st.ROMBytes = systemUsage(files)
+		} else {
+			st.Errors = append(st.Errors, fmt.Errorf("list %s: %w", romsBase, err))
+		}
+		if files, err := lister.ListFiles(ctx, biosBase); err == nil {
+			st.BIOS = biosCompleteness(files)
+			for _, g := range st.BIOS {
+				st.BIOSPresent += len(g.Present)
+				st.BIOSTotal += len(g.Present) + len(g.Missing)
+			}
+		} else {
+			st.Errors = append(st.Errors, fmt.Errorf("list %s: %w", biosBase, err))
+		}
+	}
+
+	if r, ok := backend.(SystemFileReader); ok {
+		st.Info = readDeviceInfo(ctx, r)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// systemUsage totals device files per roms/<system> folder.
+func systemUsage(files []RemoteFile) ([]SystemUsage, int64) {
+	byFolder := make(map[string]*SystemUsage)
+	var total int64
+	for _, f := range files {
+		rel := strings.TrimPrefix(f.Path, romsBase+"/")
+		folder, _, ok := strings.Cut(rel, "/")
+		if !ok {
+			continue
+		}
+		u := byFolder[folder]
+		if u == nil {
+			u = &SystemUsage{Folder: folder}
+			byFolder[folder] = u
+		}
+		u.Files++
+		u.Bytes += f.Size
+		total += f.Size
+	}
+
+	usage := make([]SystemUsage, 0, len(byFolder))
+	for _, u := range byFolder {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Bytes != usage[j].Bytes {
+			return usage[i].Bytes > usage[j].Bytes
+		}
+		return usage[i].Folder < usage[j].Folder
+	})
+	return usage, total
+}
+
+// biosCompleteness checks the device's bios/ listing for every known BIOS
+// file, ignoring case.
+func biosCompleteness(files []RemoteFile) []BIOSGroup {
+	have := make(map[string]bool, len(files))
+	for _, f := range files {
+		have[strings.ToLower(strings.TrimPrefix(f.Path, biosBase+"/"))] = true
+	}
+
+	var groups []BIOSGroup
+	for _, known := range organizer.KnownBIOSFiles() {
+		if len(groups) == 0 || groups[len(groups)-1].Folder != known.TargetDir {
+			groups = append(groups, BIOSGroup{Folder: known.TargetDir})
+		}
+		g := &groups[len(groups)-1]
+		if have[strings.ToLower(path.Join(known.TargetDir, known.Filename))] {
+			g.Present = append(g.Present, known.Filename)
+		} else {
+			g.Missing = append(g.Missing, known.Filename)
+		}
+	}
+	return groups
+}
+
+// readDeviceInfo reads what it can of the device's model, OS, kernel and
+// memory; missing files leave their fields empty.
+func readDeviceInfo(ctx context.Context, r SystemFileReader) *DeviceInfo {
+	info := &DeviceInfo{}
+	read := func(p string) []byte {
+		b, err := r.ReadSystemFile(ctx, p)
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+
+	// The device tree model is NUL-terminated; older kernels only have
+	// the Model line in cpuinfo.
+	info.Model = strings.TrimSpace(strings.TrimRight(string(read("/proc/device-tree/model")), "\x00"))
+	if info.Model == "" {
+		info.Model = procField(read("/proc/cpuinfo"), "Model")
+	}
+	info.OS = parseOSRelease(read("/etc/os-release"))
+	info.Kernel = strings.TrimSpace(string(read("/proc/sys/kernel/osrelease")))
+	if kb, err := strconv.ParseInt(strings.TrimSuffix(procField(read("/proc/meminfo"), "MemTotal"), " kB"), 10, 64); err == nil {
+		info.Memory = kb * 1024
+	}
+	return info
+}
+
+// procField returns the value of a "Key: value" line in a /proc file.
+func procField(data []byte, key string) string {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), ":")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// parseOSRelease returns PRETTY_NAME from os-release, or NAME and VERSION
+// when it is missing.
+func parseOSRelease(data []byte) string {
+	fields := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if !ok || strings.HasPrefix(k, "#") {
+			continue
+		}
+		if uq, err := strconv.Unquote(v); err == nil {
+			v = uq
+		} else {
+			v = strings.Trim(v, `'"`)
+		}
+		fields[k] = v
+	}
+	if p := fields["PRETTY_NAME"]; p != "" {
+		return p
+	}
+	return strings.TrimSpace(fields["NAME"] + " " + fields["VERSION"])
+}
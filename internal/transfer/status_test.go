@@ -0,0 +1,117 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+)
+
+// procBackend is a USB backend that also serves system files from a map.
+type procBackend struct {
+	*USBBackend
+	files map[string]string
+}
+
+func (p procBackend) ReadSystemFile(_ context.Context, path string) ([]byte, error) {
+	data, ok := p.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(data), nil
+}
+
+func TestReadDeviceStatus(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel string, size int) {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("roms/nintendo_snes/a.sfc", 300)
+	write("roms/nintendo_snes/b.sfc", 200)
+	write("roms/sega_smd/c.md", 100)
+	write("roms/readme.txt", 5000) // not in a system folder
+	write("bios/SCPH5501.BIN", 1)
+	write("bios/dc/dc_boot.bin", 1)
+
+	backend := procBackend{
+		USBBackend: NewUSBBackend(root),
+		files: map[string]string{
+			"/proc/device-tree/model":    "Raspberry Pi 5 Model B Rev 1.0\x00",
+			"/proc/meminfo":              "MemTotal:        8245616 kB\nMemFree:         7000000 kB\n",
+			"/proc/sys/kernel/osrelease": "6.6.31-v8+\n",
+			"/etc/os-release":            "NAME=\"ReplayOS\"\nVERSION=\"1.2\"\n",
+		},
+	}
+
+	st, err := ReadDeviceStatus(context.Background(), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Errors) != 0 {
+		t.Errorf("errors = %v", st.Errors)
+	}
+
+	if len(st.Systems) != 2 || st.Systems[0].Folder != "nintendo_snes" || st.Systems[0].Files != 2 || st.Systems[0].Bytes != 500 {
+		t.Errorf("systems = %+v", st.Systems)
+	}
+	if st.ROMBytes != 600 {
+		t.Errorf("ROMBytes = %d, want 600", st.ROMBytes)
+	}
+
+	if st.BIOSPresent != 2 || st.BIOSTotal != len(organizer.KnownBIOSFiles()) {
+		t.Errorf("BIOS %d/%d, want 2/%d", st.BIOSPresent, st.BIOSTotal, len(organizer.KnownBIOSFiles()))
+	}
+	for _, g := range st.BIOS {
+		if g.Folder == "dc" && (len(g.Present) != 1 || g.Present[0] != "dc_boot.bin") {
+			t.Errorf("dc group = %+v", g)
+		}
+	}
+
+	if st.Info == nil {
+		t.Fatal("no device info")
+	}
+	want := DeviceInfo{Model: "Raspberry Pi 5 Model B Rev 1.0", OS: "ReplayOS 1.2", Kernel: "6.6.31-v8+", Memory: 8245616 * 1024}
+	if *st.Info != want {
+		t.Errorf("info = %+v, want %+v", *st.Info, want)
+	}
+}
+
+func TestReadDeviceStatus_CPUInfoModel(t *testing.T) {
+	backend := procBackend{
+		USBBackend: NewUSBBackend(t.TempDir()),
+		files: map[string]string{
+			"/proc/cpuinfo":   "processor\t: 0\nHardware\t: BCM2835\nModel\t\t: Raspberry Pi 3 Model B Plus Rev 1.3\n",
+			"/etc/os-release": "PRETTY_NAME='ReplayOS 1.0 (beta)'\n",
+		},
+	}
+	st, err := ReadDeviceStatus(context.Background(), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Info.Model != "Raspberry Pi 3 Model B Plus Rev 1.3" || st.Info.OS != "ReplayOS 1.0 (beta)" {
+		t.Errorf("info = %+v", *st.Info)
+	}
+	if st.Disk == nil || st.Disk.Total <= 0 {
+		t.Errorf("disk = %+v", st.Disk)
+	}
+}
+
+func TestParseDF(t *testing.T) {
+	out := []byte("Filesystem     1024-blocks     Used Available Capacity Mounted on\n/dev/mmcblk0p2    60000000 20000000  38000000      35% /\n")
+	got, err := parseDF(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DiskUsage{Total: 60000000 * 1024, Used: 20000000 * 1024, Free: 38000000 * 1024}
+	if got != want {
+		t.Errorf("parseDF = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package transfer
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+// SyncOptions describes sending library folders to one destination.
+type SyncOptions struct {
+	LocalRoot string   // library root; each folder is LocalRoot/<folder>
+	Folders   []string // "roms", "bios", "saves", "config"
+	Transfer  config.TransferConfig
+	// OtherRoots are the library's further source roots. They are not
+	// sent, but mirror mode keeps device ROMs that exist in them.
+	OtherRoots []string
+
+	// Systems limits roms/ to these system folders; empty sends all.
+	Systems []string
+	// Mirror lists device ROMs missing locally as deletions, when
+	// Transfer.Mirror is also set. Saves and config are never mirrored.
+	Mirror bool
+	// RebuildManifest ignores the device manifest and rebuilds it from a
+	// device listing.
+	RebuildManifest bool
+	// Lists adds the device's curated favorites and autostart game to
+	// roms/_favorites and roms/_autostart when roms is sent.
+	Lists config.ListsConfig
+}
+
+// SyncPlan is the merged plan for one destination and what was learned
+// while building it.
+type SyncPlan struct {
+	Plan       *TransferPlan
+	Manifests  Manifests // nil when sync decisions came from FileExists
+	Requeued   int       // sync-skipped files re-queued after a checksum mismatch
+	Budget     int64     // capacity budget, when Limited
+	Limited    bool
+	Filesystem FSInfo
+}
+
+// PlanSync builds the plan for sending opts.Folders to backend: FAT/exFAT
+// preflight, device manifest, sync and verify checks, include/exclude rules
+// and capacity fitting, the same way for every caller. The backend must be
+// connected. Call Plan.Cleanup when done with it.
+func PlanSync(ctx context.Context, backend TransferBackend, opts SyncOptions) (*SyncPlan, error) {
+	tc := opts.Transfer
+	sp := &SyncPlan{}
+
+	// FAT and exFAT sticks need names and sizes checked up front.
+	if d, ok := backend.(FilesystemDetector); ok {
+		if info, err := d.Filesystem(); err == nil {
+			sp.Filesystem = info
+		}
+	}
+
+	// One manifest read per folder replaces a FileExists call per file.
+	// Without one the plan falls back to checking each file.
+	if tc.SyncMode && (tc.Manifest || opts.RebuildManifest) {
+		if ms, err := LoadManifests(ctx, backend, opts.Folders, opts.RebuildManifest); err == nil {
+			sp.Manifests = ms
+		}
+	}
+
+	var plans []*TransferPlan
+	for _, folder := range opts.Folders {
+		po := PlanOptions{
+			SyncMode:   tc.SyncMode,
+			Mirror:     opts.Mirror && tc.Mirror && folder == "roms",
+			Include:    tc.Capacity.Include,
+			Exclude:    tc.Capacity.Exclude,
+			Filesystem: sp.Filesystem,
+			Manifest:   sp.Manifests[folder],
+		}
+		if folder == "roms" {
+			po.Systems = opts.Systems
+			if po.Mirror {
+				for _, root := range opts.OtherRoots {
+					po.MirrorKeep = append(po.MirrorKeep, filepath.Join(root, folder))
+				}
+			}
+		}
+		// Destination paths are relative to the USB mount or SFTP root path.
+		plan, err := BuildTransferPlanWithOptions(ctx, backend, filepath.Join(opts.LocalRoot, folder), folder, po)
+		if err != nil {
+			MergeTransferPlans(plans...).Cleanup()
+			return nil, err
+		}
+		if folder == "roms" {
+			if err := AddListItems(ctx, backend, plan, filepath.Join(opts.LocalRoot, folder), opts.Lists, po); err != nil {
+				MergeTransferPlans(append(plans, plan)...).Cleanup()
+				return nil, err
+			}
+		}
+		plans = append(plans, plan)
+	}
+	sp.Plan = MergeTransferPlans(plans...)
+
+	// Size matches are not proof of identical content; re-check them.
+	if tc.Verify && tc.SyncMode && sp.Plan.SkipCount > 0 {
+		n, err := VerifyExisting(ctx, backend, sp.Plan)
+		if err != nil {
+			sp.Plan.Cleanup()
+			return nil, err
+		}
+		sp.Requeued = n
+	}
+
+	// Fit all folders into one budget, after re-queuing so re-sent files
+	// are counted.
+	budget, limited, err := CapacityBudget(ctx, backend, tc.Capacity)
+	if err != nil {
+		sp.Plan.Cleanup()
+		return nil, err
+	}
+	if limited {
+		FitTransferPlan(sp.Plan, budget, tc.Capacity.Priorities)
+	}
+	sp.Budget, sp.Limited = budget, limited
+	return sp, nil
+}
+
+// DeviceFolderMappings maps library folders to a device's root path for
+// bulk backends, limiting roms/ to systems (plus "_" folders) when set.
+func DeviceFolderMappings(localRoot, rootPath string, folders, systems []string) []FolderMapping {
+	var mappings []FolderMapping
+	for _, folder := range folders {
+		m := FolderMapping{
+			LocalDir:  filepath.Join(localRoot, folder),
+			RemoteDir: path.Join(rootPath, folder),
+		}
+		if folder == "roms" && len(systems) > 0 {
+			m.Only = append(append(m.Only, systems...), "_*")
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings
+}
@@ -0,0 +1,187 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+// Window is a daily time range in which transfers may run, e.g.
+// "01:00-07:00". A window whose end is before its start spans midnight.
+type Window struct {
+	Start, End time.Duration // offsets from local midnight
+}
+
+// ParseWindow parses "HH:MM-HH:MM".
+func ParseWindow(s string) (Window, error) {
+	from, to, ok := strings.Cut(strings.ReplaceAll(s, " ", ""), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("transfer window %q: want HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return Window{}, fmt.Errorf("transfer window %q: %w", s, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return Window{}, fmt.Errorf("transfer window %q: %w", s, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("transfer window %q is empty", s)
+	}
+	return Window{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (w Window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.Start) + "-" + clock(w.End)
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	now := t.Sub(midnight)
+	if w.Start < w.End {
+		return now >= w.Start && now < w.End
+	}
+	return now >= w.Start || now < w.End
+}
+
+// NextStart returns the next time at or after t when the window opens.
+func (w Window) NextStart(t time.Time) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(w.Start)
+	if start.Before(t) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start
+}
+
+// Throttle caps the combined upload rate of every writer that shares it
+// and holds new uploads while outside the allowed time window; a file
+// already being sent finishes. A nil Throttle does nothing.
+type Throttle struct {
+	BytesPerSec int64   // 0 = unlimited
+	Window      *Window // nil = any time
+
+	// OnPause, if set, is called once each time uploads are held for the
+	// window, with the time they resume.
+	OnPause func(until time.Time)
+
+	mu     sync.Mutex
+	next   time.Time // when the next write may start
+	paused bool
+}
+
+// ThrottleFromConfig builds the throttle for tc, or nil when neither a
+// bandwidth limit nor a window is configured.
+func ThrottleFromConfig(tc config.TransferConfig) (*Throttle, error) {
+	var t Throttle
+	if tc.BandwidthLimit > 0 {
+		t.BytesPerSec = int64(tc.BandwidthLimit) * 1024
+	}
+	if tc.Window != "" {
+		w, err := ParseWindow(tc.Window)
+		if err != nil {
+			return nil, err
+		}
+		t.Window = &w
+	}
+	if t.BytesPerSec == 0 && t.Window == nil {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+type noThrottleKey struct{}
+
+// withoutThrottle marks ctx so small bookkeeping uploads such as the
+// manifest are never held back.
+func withoutThrottle(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noThrottleKey{}, true)
+}
+
+// Wait blocks until n more bytes may be written within the rate limit.
+// It returns early with ctx's error.
+func (t *Throttle) Wait(ctx context.Context, n int) error {
+	if t == nil || t.BytesPerSec <= 0 || ctx.Value(noThrottleKey{}) != nil {
+		return nil
+	}
+
+	// Reserve a slot after every earlier writer's; the first write goes
+	// through at once and later ones are spaced to keep the average rate.
+	t.mu.Lock()
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(time.Duration(float64(n) / float64(t.BytesPerSec) * float64(time.Second)))
+	t.mu.Unlock()
+
+	return sleepUntil(ctx, start)
+}
+
+// WaitWindow blocks until the time window is open. Uploads call it before
+// each file rather than mid-file, so a closing window never leaves a
+// connection idle with a file half sent.
+func (t *Throttle) WaitWindow(ctx context.Context) error {
+	if t == nil || t.Window == nil || ctx.Value(noThrottleKey{}) != nil {
+		return nil
+	}
+	for {
+		now := time.Now()
+		if t.Window.Contains(now) {
+			t.mu.Lock()
+			t.paused = false
+			t.mu.Unlock()
+			return nil
+		}
+		until := t.Window.NextStart(now)
+
+		t.mu.Lock()
+		announce := !t.paused && t.OnPause != nil
+		t.paused = true
+		t.mu.Unlock()
+		if announce {
+			t.OnPause(until)
+		}
+
+		if err := sleepUntil(ctx, until); err != nil {
+			return err
+		}
+	}
+}
+
+// Paused reports whether uploads are currently held for the window.
+func (t *Throttle) Paused() bool {
+	return t != nil && t.Window != nil && !t.Window.Contains(time.Now())
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
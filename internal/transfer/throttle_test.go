@@ -0,0 +1,173 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kurlmarx/romwrangler/internal/config"
+)
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("23:30 - 06:00")
+	if err != nil {
+		t.Fatalf("ParseWindow: %v", err)
+	}
+	if w.Start != 23*time.Hour+30*time.Minute || w.End != 6*time.Hour {
+		t.Errorf("ParseWindow = %+v", w)
+	}
+	if w.String() != "23:30-06:00" {
+		t.Errorf("String = %q", w.String())
+	}
+
+	for _, bad := range []string{"", "01:00", "25:00-02:00", "01:00-01:00", "1am-6am"} {
+		if _, err := ParseWindow(bad); err == nil {
+			t.Errorf("ParseWindow(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 10, h, m, 0, 0, time.Local) }
+
+	day, _ := ParseWindow("09:00-17:00")
+	night, _ := ParseWindow("23:00-06:00")
+	tests := []struct {
+		w    Window
+		t    time.Time
+		want bool
+	}{
+		{day, at(9, 0), true},
+		{day, at(16, 59), true},
+		{day, at(17, 0), false},
+		{day, at(3, 0), false},
+		{night, at(23, 30), true},
+		{night, at(2, 0), true},
+		{night, at(6, 0), false},
+		{night, at(12, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.w.Contains(tt.t); got != tt.want {
+			t.Errorf("%s.Contains(%s) = %v, want %v", tt.w, tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+
+	if got := night.NextStart(at(12, 0)); !got.Equal(at(23, 0)) {
+		t.Errorf("NextStart(12:00) = %s", got)
+	}
+	if got := day.NextStart(at(18, 0)); !got.Equal(at(9, 0).AddDate(0, 0, 1)) {
+		t.Errorf("NextStart(18:00) = %s", got)
+	}
+}
+
+func TestThrottle_Rate(t *testing.T) {
+	th := &Throttle{BytesPerSec: 100_000}
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := th.Wait(ctx, 10_000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first write is free; the next two wait 100ms each.
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("3 x 10kB at 100kB/s took %s, want >= 200ms", elapsed)
+	}
+
+	// Bookkeeping uploads bypass the throttle.
+	start = time.Now()
+	if err := th.Wait(withoutThrottle(ctx), 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("unthrottled wait took %s", elapsed)
+	}
+}
+
+func TestThrottle_WindowPauses(t *testing.T) {
+	// A one-minute window that is never now.
+	now := time.Now()
+	offset := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	closed := Window{Start: (offset + 2*time.Hour) % (24 * time.Hour), End: (offset + 2*time.Hour + time.Minute) % (24 * time.Hour)}
+
+	var paused time.Time
+	th := &Throttle{Window: &closed, OnPause: func(until time.Time) { paused = until }}
+	if !th.Paused() {
+		t.Fatal("Paused = false outside the window")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := th.WaitWindow(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitWindow outside window = %v, want deadline exceeded", err)
+	}
+	if paused.IsZero() || !closed.Contains(paused) {
+		t.Errorf("OnPause until = %s, want the window start", paused)
+	}
+
+	// A file already being sent is not held mid-way, and bookkeeping
+	// uploads are never held.
+	if err := th.Wait(ctx, 1); err != nil {
+		t.Errorf("Wait outside window = %v, want nil", err)
+	}
+	if err := th.WaitWindow(withoutThrottle(context.Background())); err != nil {
+		t.Errorf("unthrottled WaitWindow = %v, want nil", err)
+	}
+}
+
+func TestThrottleFromConfig(t *testing.T) {
+	if th, err := ThrottleFromConfig(config.TransferConfig{}); th != nil || err != nil {
+		t.Errorf("empty config = %v, %v; want nil", th, err)
+	}
+	th, err := ThrottleFromConfig(config.TransferConfig{BandwidthLimit: 512, Window: "01:00-07:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.BytesPerSec != 512*1024 || th.Window == nil {
+		t.Errorf("throttle = %+v", th)
+	}
+	if _, err := ThrottleFromConfig(config.TransferConfig{Window: "soon"}); err == nil {
+		t.Error("bad window accepted")
+	}
+}
+
+func TestBwlimitPerProcess(t *testing.T) {
+	tests := []struct{ total, procs, want int }{
+		{0, 4, 0},
+		{1000, 1, 1000},
+		{1000, 4, 250},
+		{2, 4, 1},
+	}
+	for _, tt := range tests {
+		if got := bwlimitPerProcess(tt.total, tt.procs); got != tt.want {
+			t.Errorf("bwlimitPerProcess(%d, %d) = %d, want %d", tt.total, tt.procs, got, tt.want)
+		}
+	}
+}
+
+func TestUSBBackend_Upload_Throttled(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	data := make([]byte, 3*usbBufSize)
+	os.WriteFile(filepath.Join(srcDir, "game.iso"), data, 0644)
+
+	b := NewUSBBackend(dstDir)
+	// Three buffer-sized writes: the last two wait 50ms each.
+	b.Throttle = &Throttle{BytesPerSec: int64(usbBufSize) * 20}
+
+	start := time.Now()
+	if err := b.Upload(context.Background(), filepath.Join(srcDir, "game.iso"), "game.iso", nil); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("throttled upload took %s, want >= 100ms", elapsed)
+	}
+	info, err := os.Stat(filepath.Join(dstDir, "game.iso"))
+	if err != nil || info.Size() != int64(len(data)) {
+		t.Errorf("uploaded file = %v, %v", info, err)
+	}
+}
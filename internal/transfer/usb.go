@@ -2,9 +2,13 @@ package transfer
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"sync"
 )
@@ -16,6 +20,8 @@ const usbBufSize = 1024 * 1024
 // USBBackend implements TransferBackend for local USB/SD card copy.
 type USBBackend struct {
 	MountPath  string
+	FSType     string    // filesystem type override; empty to detect
+	Throttle   *Throttle // bandwidth cap and time window for uploads; nil = none
 	bufferPool sync.Pool
 }
 
@@ -67,12 +73,29 @@ func (u *USBBackend) FileExists(path string, expectedSize int64) (bool, error) {
 }
 
 func (u *USBBackend) Upload(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) error {
+	return u.upload(ctx, localPath, remotePath, progressFn, nil)
+}
+
+// UploadSHA1 uploads like Upload and returns the SHA1 of the local file,
+// hashed as it is read for the upload.
+func (u *USBBackend) UploadSHA1(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) (string, error) {
+	h := sha1.New()
+	if err := u.upload(ctx, localPath, remotePath, progressFn, h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (u *USBBackend) upload(ctx context.Context, localPath, remotePath string, progressFn func(written int64), h hash.Hash) error {
 	// Check context before starting
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
+	if err := u.Throttle.WaitWindow(ctx); err != nil {
+		return err
+	}
 
 	destPath := filepath.Join(u.MountPath, remotePath)
 
@@ -92,21 +115,43 @@ func (u *USBBackend) Upload(ctx context.Context, localPath, remotePath string, p
 		return fmt.Errorf("stat source: %w", err)
 	}
 
-	dst, err := os.Create(destPath)
+	// Write to a hidden part file, resuming an earlier interrupted upload
+	// when one is present, and rename it into place once complete.
+	partPath := filepath.Join(destDir, partName(filepath.Base(destPath)))
+	var offset int64
+	if partInfo, err := os.Stat(partPath); err == nil {
+		offset = resumeOffset(partInfo.Size(), srcInfo.Size(), partInfo.ModTime(), srcInfo.ModTime())
+	}
+
+	dst, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
 		return fmt.Errorf("create dest: %w", err)
 	}
 	defer dst.Close()
 
+	if err := dst.Truncate(offset); err != nil {
+		return fmt.Errorf("truncate dest: %w", err)
+	}
+
 	// Pre-allocate space on destination for large files
-	if srcInfo.Size() > 0 {
+	if srcInfo.Size() > offset {
 		preallocate(dst, srcInfo.Size()) // best-effort, ignore error
 	}
 
+	if offset > 0 {
+		if _, err := src.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("seek source: %w", err)
+		}
+		if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("seek dest: %w", err)
+		}
+	}
+
 	var writer io.Writer = dst
 	var pw *ProgressWriter
-	if progressFn != nil {
-		pw = NewProgressWriter(dst, progressFn)
+	if progressFn != nil || u.Throttle != nil {
+		pw = NewProgressWriterAt(dst, offset, progressFn)
+		pw.SetThrottle(ctx, u.Throttle)
 		writer = pw
 	}
 
@@ -114,7 +159,11 @@ func (u *USBBackend) Upload(ctx context.Context, localPath, remotePath string, p
 	bufp := u.bufferPool.Get().(*[]byte)
 	defer u.bufferPool.Put(bufp)
 
-	if _, err := io.CopyBuffer(writer, src, *bufp); err != nil {
+	r, err := uploadReader(ctx, src, offset, h)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyBuffer(writer, r, *bufp); err != nil {
 		return fmt.Errorf("copy failed: %w", err)
 	}
 
@@ -122,5 +171,95 @@ func (u *USBBackend) Upload(ctx context.Context, localPath, remotePath string, p
 		pw.Flush()
 	}
 
-	return dst.Sync()
+	if err := dst.Sync(); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partPath, destPath)
+}
+
+// RemoteSHA1 reads the copied file back from the USB device and hashes it.
+// On Linux the file's cached pages are dropped first, so the hash is of
+// what is on the medium; elsewhere it may be of the page cache.
+func (u *USBBackend) RemoteSHA1(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(filepath.Join(u.MountPath, path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := dropCache(f); err != nil {
+		return "", fmt.Errorf("drop cache: %w", err)
+	}
+	return readerSHA1(ctx, f)
+}
+
+func (u *USBBackend) ListFiles(ctx context.Context, dir string) ([]RemoteFile, error) {
+	root := filepath.Join(u.MountPath, dir)
+	var files []RemoteFile
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == root && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if info.IsDir() || IsPartFile(info.Name()) || info.Name() == ManifestName {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		files = append(files, RemoteFile{
+			Path:    path.Join(filepath.ToSlash(dir), filepath.ToSlash(rel)),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (u *USBBackend) Download(ctx context.Context, remotePath, localPath string, progressFn func(read int64)) error {
+	srcPath := filepath.Join(u.MountPath, remotePath)
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+
+	bufp := u.bufferPool.Get().(*[]byte)
+	defer u.bufferPool.Put(bufp)
+
+	return downloadTo(ctx, src, localPath, *bufp, progressFn)
+}
+
+func (u *USBBackend) RemoveFile(remotePath string) error {
+	return os.Remove(filepath.Join(u.MountPath, remotePath))
+}
+
+func (u *USBBackend) FreeSpace(ctx context.Context) (int64, error) {
+	return freeSpace(u.MountPath)
+}
+
+func (u *USBBackend) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	return diskUsage(u.MountPath)
+}
+
+// Filesystem detects the mount's filesystem. FSType, when set, overrides
+// detection (e.g. "exfat" for a fuseblk mount).
+func (u *USBBackend) Filesystem() (FSInfo, error) {
+	if u.FSType != "" {
+		return FSInfoForType(u.FSType), nil
+	}
+	t, err := filesystemType(u.MountPath)
+	if err != nil {
+		return FSInfo{}, err
+	}
+	return FSInfoForType(t), nil
 }
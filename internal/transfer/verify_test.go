@@ -0,0 +1,193 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// corruptingBackend wraps a USBBackend and flips a byte in the first
+// `corrupt` uploads to simulate a damaged copy of the right size.
+type corruptingBackend struct {
+	*USBBackend
+	corrupt atomic.Int32
+}
+
+func (c *corruptingBackend) Upload(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) error {
+	if err := c.USBBackend.Upload(ctx, localPath, remotePath, progressFn); err != nil {
+		return err
+	}
+	c.damage(remotePath)
+	return nil
+}
+
+func (c *corruptingBackend) UploadSHA1(ctx context.Context, localPath, remotePath string, progressFn func(written int64)) (string, error) {
+	sum, err := c.USBBackend.UploadSHA1(ctx, localPath, remotePath, progressFn)
+	if err != nil {
+		return "", err
+	}
+	c.damage(remotePath)
+	return sum, nil
+}
+
+func (c *corruptingBackend) damage(remotePath string) {
+	if c.corrupt.Add(-1) >= 0 {
+		dest := filepath.Join(c.MountPath, remotePath)
+		data, _ := os.ReadFile(dest)
+		data[0] ^= 0xFF
+		os.WriteFile(dest, data, 0644)
+	}
+}
+
+func TestExecuteWithOptions_VerifyRequeuesMismatch(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	os.WriteFile(filepath.Join(srcDir, "game.nes"), []byte("NES ROM data"), 0644)
+
+	backend := &corruptingBackend{USBBackend: NewUSBBackend(dstDir)}
+	backend.corrupt.Store(1)
+
+	plan, err := BuildTransferPlan(context.Background(), backend, srcDir, "roms", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	progressCh := make(chan TransferProgress, 100)
+	err = ExecuteWithOptions(context.Background(), backend, plan, ExecuteOptions{Concurrency: 1, Verify: true}, progressCh)
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+
+	var mismatches, verified int
+	for p := range progressCh {
+		if p.Mismatch {
+			mismatches++
+		}
+		if p.Done && p.Verified {
+			verified++
+		}
+	}
+	if mismatches != 1 {
+		t.Errorf("expected 1 mismatch report, got %d", mismatches)
+	}
+	if verified != 1 {
+		t.Errorf("expected 1 verified file, got %d", verified)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dstDir, "roms", "game.nes"))
+	if string(data) != "NES ROM data" {
+		t.Error("destination was not repaired by the retry")
+	}
+}
+
+func TestExecuteWithOptions_VerifyGivesUp(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	os.WriteFile(filepath.Join(srcDir, "game.nes"), []byte("NES ROM data"), 0644)
+
+	backend := &corruptingBackend{USBBackend: NewUSBBackend(dstDir)}
+	backend.corrupt.Store(10)
+
+	plan, _ := BuildTransferPlan(context.Background(), backend, srcDir, "roms", false)
+	err := ExecuteWithOptions(context.Background(), backend, plan, ExecuteOptions{Verify: true, VerifyRetries: 1}, nil)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestVerifyExisting(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(srcDir, "good.nes"), []byte("good"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "bad.nes"), []byte("rev1"), 0644)
+
+	// Same size on the destination, but bad.nes is a different revision.
+	os.MkdirAll(filepath.Join(dstDir, "roms"), 0755)
+	os.WriteFile(filepath.Join(dstDir, "roms", "good.nes"), []byte("good"), 0644)
+	os.WriteFile(filepath.Join(dstDir, "roms", "bad.nes"), []byte("rev0"), 0644)
+
+	backend := NewUSBBackend(dstDir)
+	plan, err := BuildTransferPlan(context.Background(), backend, srcDir, "roms", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.SkipCount != 2 {
+		t.Fatalf("expected size-based sync to skip 2 files, got %d", plan.SkipCount)
+	}
+
+	requeued, err := VerifyExisting(context.Background(), backend, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requeued != 1 || plan.SkipCount != 1 {
+		t.Errorf("expected 1 re-queued and 1 skipped, got %d re-queued, %d skipped", requeued, plan.SkipCount)
+	}
+	if plan.TotalSize != 4 {
+		t.Errorf("expected total size 4, got %d", plan.TotalSize)
+	}
+}
+
+func TestSFTPBackend_RemoteSHA1(t *testing.T) {
+	dstDir := t.TempDir()
+	os.WriteFile(filepath.Join(dstDir, "game.nes"), []byte("Hello, ROM Wrangler!"), 0644)
+
+	// The test server has no exec support, so this covers the streaming fallback.
+	backend := newTestSFTPBackend(t, dstDir)
+	sum, err := backend.RemoteSHA1(context.Background(), "game.nes")
+	if err != nil {
+		t.Fatalf("RemoteSHA1 failed: %v", err)
+	}
+	usbSum, _ := NewUSBBackend(dstDir).RemoteSHA1(context.Background(), "game.nes")
+	if sum != usbSum {
+		t.Errorf("sftp sha1 %s != local sha1 %s", sum, usbSum)
+	}
+}
+
+func TestExecuteWithOptions_SlowReaderGetsEveryMismatch(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	const files = 20
+	for i := 0; i < files; i++ {
+		os.WriteFile(filepath.Join(srcDir, fmt.Sprintf("game%02d.nes", i)), []byte("NES ROM data"), 0644)
+	}
+
+	// The first `files` uploads are damaged; retries resend until one is intact.
+	backend := &corruptingBackend{USBBackend: NewUSBBackend(dstDir)}
+	backend.corrupt.Store(files)
+
+	plan, err := BuildTransferPlan(context.Background(), backend, srcDir, "roms", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	progressCh := make(chan TransferProgress)
+	var mismatches, done int
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for p := range progressCh {
+			time.Sleep(time.Millisecond)
+			if p.Mismatch {
+				mismatches++
+			}
+			if p.Done {
+				done++
+			}
+		}
+	}()
+	opts := ExecuteOptions{Concurrency: 1, Verify: true, VerifyRetries: files}
+	err = ExecuteWithOptions(context.Background(), backend, plan, opts, progressCh)
+	<-drained
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mismatches != files || done != files {
+		t.Errorf("got %d mismatches and %d done, want %d of each", mismatches, done, files)
+	}
+}
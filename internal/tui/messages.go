@@ -45,4 +45,12 @@ const (
 	ScreenReplayOS
 	ScreenBIOS
 	ScreenM3U
+	ScreenBackup
+	ScreenInventory
+	ScreenFanout
+	ScreenDeviceStatus
+	ScreenReplayCfg
+	ScreenLists
+	ScreenOverrides
+	ScreenProvision
 )
@@ -0,0 +1,286 @@
+package screens
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/backup"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type backupPhase int
+
+const (
+	backupPhaseSelect backupPhase = iota
+	backupPhaseWorking
+	backupPhaseResults
+)
+
+type backupSnapshotsMsg struct {
+	snapshots []backup.Snapshot
+	err       error
+}
+
+type backupProgressMsg struct {
+	current, total int
+	filename       string
+}
+
+type backupDoneMsg struct {
+	pull     *backup.PullResult
+	restored int
+	err      error
+}
+
+// BackupScreen pulls saves, captures and config from the device into
+// versioned local snapshots and restores them.
+type BackupScreen struct {
+	cfg           *config.Config
+	width, height int
+	phase         backupPhase
+
+	// Selection: item 0 is "pull", the rest are snapshots newest first.
+	snapshots []backup.Snapshot
+	cursor    int
+	listErr   error
+
+	restoring  bool
+	progressCh chan backupProgressMsg
+	progress   backupProgressMsg
+	cancel     context.CancelFunc
+
+	// Results
+	pullResult *backup.PullResult
+	restored   int
+	err        error
+}
+
+func NewBackupScreen(cfg *config.Config, width, height int) *BackupScreen {
+	return &BackupScreen{cfg: cfg, width: width, height: height}
+}
+
+func (b *BackupScreen) Init() tea.Cmd {
+	root := b.cfg.BackupRoot()
+	return func() tea.Msg {
+		snaps, err := backup.ListSnapshots(root)
+		// Newest first.
+		for i, j := 0, len(snaps)-1; i < j; i, j = i+1, j-1 {
+			snaps[i], snaps[j] = snaps[j], snaps[i]
+		}
+		return backupSnapshotsMsg{snapshots: snaps, err: err}
+	}
+}
+
+func (b *BackupScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width = msg.Width
+		b.height = msg.Height
+
+	case backupSnapshotsMsg:
+		b.snapshots = msg.snapshots
+		b.listErr = msg.err
+
+	case backupProgressMsg:
+		b.progress = msg
+		return b, listenBackupProgress(b.progressCh)
+
+	case backupDoneMsg:
+		b.pullResult = msg.pull
+		b.restored = msg.restored
+		b.err = msg.err
+		b.cancel = nil
+		b.phase = backupPhaseResults
+
+	case tea.KeyMsg:
+		switch b.phase {
+		case backupPhaseSelect:
+			return b.updateSelect(msg)
+		case backupPhaseWorking:
+			if key.Matches(msg, tui.Keys.Back) && b.cancel != nil {
+				b.cancel()
+			}
+		case backupPhaseResults:
+			if key.Matches(msg, tui.Keys.Back) || key.Matches(msg, tui.Keys.Enter) {
+				return b, func() tea.Msg { return tui.NavigateBackMsg{} }
+			}
+		}
+	}
+	return b, nil
+}
+
+func (b *BackupScreen) updateSelect(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		return b, func() tea.Msg { return tui.NavigateBackMsg{} }
+	case key.Matches(msg, tui.Keys.Up):
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if b.cursor < len(b.snapshots) {
+			b.cursor++
+		}
+	case key.Matches(msg, tui.Keys.Enter):
+		b.phase = backupPhaseWorking
+		if b.cursor == 0 {
+			b.restoring = false
+			return b, b.start(nil)
+		}
+		b.restoring = true
+		snap := b.snapshots[b.cursor-1]
+		return b, b.start(&snap)
+	}
+	return b, nil
+}
+
+// start connects to the device and either pulls a new snapshot or, when
+// snap is set, restores it.
+func (b *BackupScreen) start(snap *backup.Snapshot) tea.Cmd {
+	cfg := b.cfg
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	progressCh := make(chan backupProgressMsg, 100)
+	b.progressCh = progressCh
+	report := func(current, total int, name string) {
+		select {
+		case progressCh <- backupProgressMsg{current: current, total: total, filename: name}:
+		default:
+		}
+	}
+
+	done := func() tea.Msg {
+		defer close(progressCh)
+
+		backend, err := transfer.NewBackend(cfg, "")
+		if err != nil {
+			return backupDoneMsg{err: err}
+		}
+		if err := backend.Connect(ctx); err != nil {
+			return backupDoneMsg{err: fmt.Errorf("connect: %w", err)}
+		}
+		defer backend.Close()
+
+		if snap == nil {
+			result, err := backup.Pull(ctx, backend, cfg.BackupRoot(), backup.DefaultFolders, report)
+			return backupDoneMsg{pull: result, err: err}
+		}
+
+		plan, err := backup.BuildRestorePlan(ctx, backend, *snap, backup.DefaultFolders)
+		if err != nil {
+			return backupDoneMsg{err: err}
+		}
+		ch := make(chan transfer.TransferProgress, 100)
+		drained := make(chan struct{})
+		go func() {
+			defer close(drained)
+			for p := range ch {
+				report(p.FileIndex+1, p.TotalFiles, p.Filename)
+			}
+		}()
+		opts := transfer.ExecuteOptions{Concurrency: cfg.Transfer.Concurrency, Verify: cfg.Transfer.Verify}
+		err = transfer.ExecuteWithOptions(ctx, backend, plan, opts, ch)
+		<-drained
+		return backupDoneMsg{restored: len(plan.Items) - plan.SkipCount, err: err}
+	}
+
+	return tea.Batch(listenBackupProgress(progressCh), done)
+}
+
+func listenBackupProgress(ch <-chan backupProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return p
+	}
+}
+
+func (b *BackupScreen) View() string {
+	switch b.phase {
+	case backupPhaseWorking:
+		return b.viewWorking()
+	case backupPhaseResults:
+		return b.viewResults()
+	}
+	return b.viewSelect()
+}
+
+func (b *BackupScreen) viewSelect() string {
+	s := tui.StyleSubtitle.Render("Backup & Restore") + "\n\n"
+	s += tui.StyleDim.Render("Backups: "+b.cfg.BackupRoot()) + "\n\n"
+
+	items := []string{"Pull backup from device (saves, captures, config)"}
+	for _, snap := range b.snapshots {
+		items = append(items, "Restore "+snap.Time.Format("2006-01-02 15:04:05"))
+	}
+	for i, item := range items {
+		cursor := "  "
+		style := tui.StyleNormal
+		if i == b.cursor {
+			cursor = tui.StyleMenuCursor.String()
+			style = tui.StyleSelected
+		}
+		s += cursor + style.Render(item) + "\n"
+	}
+
+	if b.listErr != nil {
+		s += "\n" + tui.StyleError.Render("Could not read backups: "+b.listErr.Error()) + "\n"
+	} else if len(b.snapshots) == 0 {
+		s += "\n" + tui.StyleDim.Render("No snapshots yet.") + "\n"
+	}
+
+	s += "\n" + tui.StyleDim.Render("enter: select  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
+func (b *BackupScreen) viewWorking() string {
+	title := "Pulling backup..."
+	if b.restoring {
+		title = "Restoring snapshot..."
+	}
+	s := tui.StyleSubtitle.Render(title) + "\n\n"
+
+	p := b.progress
+	if p.total > 0 {
+		s += fmt.Sprintf("File %d / %d: %s\n", p.current, p.total, p.filename)
+		s += renderProgressBar(float64(p.current)/float64(p.total)*100, 40) + "\n"
+	} else {
+		s += tui.StyleDim.Render("Connecting...") + "\n"
+	}
+
+	s += "\n" + tui.StyleDim.Render("esc: cancel")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
+func (b *BackupScreen) viewResults() string {
+	s := tui.StyleSubtitle.Render("Backup & Restore") + "\n\n"
+
+	if r := b.pullResult; r != nil {
+		s += fmt.Sprintf("%s Snapshot saved to %s\n", tui.StyleSuccess.Render("OK"), r.Snapshot.Path)
+		s += fmt.Sprintf("   %d files downloaded (%s), %d unchanged\n", r.Downloaded, formatBytes(r.BytesCopied), r.Linked)
+		for _, e := range r.Errors {
+			s += tui.StyleError.Render("  "+e.Error()) + "\n"
+		}
+	} else if b.restoring && b.err == nil {
+		s += fmt.Sprintf("%s %d files restored\n", tui.StyleSuccess.Render("OK"), b.restored)
+	}
+
+	if b.err != nil {
+		s += tui.StyleError.Render("Error: "+b.err.Error()) + "\n"
+	}
+
+	s += "\n" + tui.StyleDim.Render("enter/esc: done")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
+func (b *BackupScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Enter, tui.Keys.Back}
+}
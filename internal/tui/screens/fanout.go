@@ -0,0 +1,584 @@
+package screens
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type fanoutPhase int
+
+const (
+	fanoutPhaseSelect fanoutPhase = iota
+	fanoutPhasePlan
+	fanoutPhaseProgress
+	fanoutPhaseResults
+)
+
+type fanoutPlanMsg struct {
+	jobs   []transfer.DeviceTransfer
+	failed []transfer.DeviceResult // devices that could not be connected or planned
+	err    error
+}
+
+type fanoutProgressMsg struct {
+	progress transfer.DeviceProgress
+}
+
+type fanoutDoneMsg struct {
+	results []transfer.DeviceResult
+}
+
+// fanoutDevice is one selectable device and its live progress.
+type fanoutDevice struct {
+	cfg      config.DeviceConfig
+	selected bool
+	progress transfer.TransferProgress
+	failed   bool // could not be connected or planned
+}
+
+// FanoutScreen pushes the library to several configured devices at once,
+// with a progress panel and result line per device.
+type FanoutScreen struct {
+	cfg           *config.Config
+	width, height int
+	phase         fanoutPhase
+
+	// Selection: devices first, then folders.
+	devices []fanoutDevice
+	folders []transferFolder
+	cursor  int
+	rsync   bool
+	err     error
+
+	// Plan (SFTP)
+	jobs   []transfer.DeviceTransfer
+	failed []transfer.DeviceResult
+
+	// One throttle for all devices: the bandwidth limit is for the network.
+	throttle    *transfer.Throttle
+	pauseCh     <-chan time.Time
+	pausedUntil time.Time
+
+	progressCh <-chan transfer.DeviceProgress
+	cancel     context.CancelFunc
+	results    []transfer.DeviceResult
+}
+
+func NewFanoutScreen(cfg *config.Config, width, height int) *FanoutScreen {
+	f := &FanoutScreen{
+		cfg:    cfg,
+		width:  width,
+		height: height,
+		rsync:  cfg.Transfer.Method == "rsync",
+		folders: []transferFolder{
+			{label: "ROMs", dirName: "roms", selected: true},
+			{label: "BIOS", dirName: "bios", selected: false},
+			{label: "Saves", dirName: "saves", selected: false},
+			{label: "Config", dirName: "config", selected: false},
+		},
+	}
+	for _, d := range cfg.AllDevices() {
+		f.devices = append(f.devices, fanoutDevice{cfg: d, selected: true})
+	}
+	return f
+}
+
+func (f *FanoutScreen) Init() tea.Cmd { return nil }
+
+func (f *FanoutScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.width = msg.Width
+		f.height = msg.Height
+
+	case fanoutPlanMsg:
+		if msg.err != nil {
+			f.err = msg.err
+			f.phase = fanoutPhaseSelect
+			return f, nil
+		}
+		f.jobs = msg.jobs
+		f.failed = msg.failed
+		for _, r := range msg.failed {
+			if d := f.device(r.Device); d != nil {
+				d.failed = true
+			}
+		}
+		if f.phase != fanoutPhasePlan {
+			// Backed out while planning.
+			f.cleanup()
+		}
+
+	case transferPausedMsg:
+		f.pausedUntil = msg.until
+		return f, listenTransferPause(f.pauseCh)
+
+	case fanoutProgressMsg:
+		if d := f.device(msg.progress.Device); d != nil {
+			d.progress = msg.progress.TransferProgress
+		}
+		if !f.throttle.Paused() {
+			f.pausedUntil = time.Time{}
+		}
+		return f, listenFanoutProgress(f.progressCh)
+
+	case fanoutDoneMsg:
+		f.cleanup()
+		f.results = append(msg.results, f.failed...)
+		f.cancel = nil
+		f.phase = fanoutPhaseResults
+
+	case tea.KeyMsg:
+		switch f.phase {
+		case fanoutPhaseSelect:
+			return f.updateSelect(msg)
+		case fanoutPhasePlan:
+			switch {
+			case key.Matches(msg, tui.Keys.Back):
+				f.cleanup()
+				f.phase = fanoutPhaseSelect
+			case key.Matches(msg, tui.Keys.Enter):
+				if len(f.jobs) > 0 {
+					f.phase = fanoutPhaseProgress
+					return f, f.startTransfer()
+				}
+			}
+		case fanoutPhaseProgress:
+			if key.Matches(msg, tui.Keys.Back) && f.cancel != nil {
+				f.cancel()
+			}
+		case fanoutPhaseResults:
+			if key.Matches(msg, tui.Keys.Back) || key.Matches(msg, tui.Keys.Enter) {
+				return f, func() tea.Msg { return tui.NavigateBackMsg{} }
+			}
+		}
+	}
+	return f, nil
+}
+
+func (f *FanoutScreen) device(name string) *fanoutDevice {
+	for i := range f.devices {
+		if f.devices[i].cfg.Label() == name {
+			return &f.devices[i]
+		}
+	}
+	return nil
+}
+
+// cleanup closes the connections and temp files of a built plan.
+func (f *FanoutScreen) cleanup() {
+	for _, j := range f.jobs {
+		j.Plan.Cleanup()
+		j.Backend.Close()
+	}
+	f.jobs = nil
+}
+
+func (f *FanoutScreen) updateSelect(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	total := len(f.devices) + len(f.folders)
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		return f, func() tea.Msg { return tui.NavigateBackMsg{} }
+	case key.Matches(msg, tui.Keys.Up):
+		if f.cursor > 0 {
+			f.cursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if f.cursor < total-1 {
+			f.cursor++
+		}
+	case msg.Type == tea.KeySpace:
+		if f.cursor < len(f.devices) {
+			f.devices[f.cursor].selected = !f.devices[f.cursor].selected
+		} else {
+			fo := &f.folders[f.cursor-len(f.devices)]
+			fo.selected = !fo.selected
+		}
+	case msg.String() == "m":
+		f.rsync = !f.rsync
+	case key.Matches(msg, tui.Keys.Enter):
+		f.err = nil
+		for i := range f.devices {
+			f.devices[i].failed = false
+			f.devices[i].progress = transfer.TransferProgress{}
+		}
+		devices := f.selectedDevices()
+		folders := f.selectedFolders()
+		if len(devices) == 0 || len(folders) == 0 {
+			return f, nil
+		}
+		throttle, err := transfer.ThrottleFromConfig(f.cfg.Transfer)
+		if err != nil {
+			f.err = err
+			return f, nil
+		}
+		f.throttle = throttle
+		f.pausedUntil = time.Time{}
+		if f.rsync {
+			cmd, err := f.startBulkTransfer(devices, folders)
+			if err != nil {
+				f.err = err
+				return f, nil
+			}
+			f.phase = fanoutPhaseProgress
+			return f, cmd
+		}
+		f.phase = fanoutPhasePlan
+		return f, f.buildPlans(devices, folders)
+	}
+	return f, nil
+}
+
+func (f *FanoutScreen) selectedDevices() []config.DeviceConfig {
+	var out []config.DeviceConfig
+	for _, d := range f.devices {
+		if d.selected {
+			out = append(out, d.cfg)
+		}
+	}
+	return out
+}
+
+func (f *FanoutScreen) selectedFolders() []string {
+	var out []string
+	for _, fo := range f.folders {
+		if fo.selected {
+			out = append(out, fo.dirName)
+		}
+	}
+	return out
+}
+
+func (f *FanoutScreen) localRoot() string {
+	if len(f.cfg.SourceDirs) > 0 {
+		return f.cfg.SourceDirs[0]
+	}
+	return ""
+}
+
+// buildPlans connects to every device and plans its transfer concurrently.
+// Mirror mode is not offered here: deletions need a per-device review.
+func (f *FanoutScreen) buildPlans(devices []config.DeviceConfig, folders []string) tea.Cmd {
+	cfg := f.cfg
+	root := f.localRoot()
+	throttle := f.throttle
+	return func() tea.Msg {
+		ctx := context.Background()
+		jobs := make([]*transfer.DeviceTransfer, len(devices))
+		errs := make([]error, len(devices))
+		var wg sync.WaitGroup
+		for i, dev := range devices {
+			wg.Add(1)
+			go func(i int, dev config.DeviceConfig) {
+				defer wg.Done()
+				backend, err := transfer.NewDeviceBackend(cfg, dev, "sftp", throttle)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				if err := backend.Connect(ctx); err != nil {
+					errs[i] = fmt.Errorf("connect: %w", err)
+					return
+				}
+				sp, err := transfer.PlanSync(ctx, backend, transfer.SyncOptions{
+					LocalRoot: root,
+					Folders:   folders,
+					Transfer:  cfg.Transfer,
+					Systems:   dev.Systems,
+					Lists:     dev.Lists,
+				})
+				if err != nil {
+					backend.Close()
+					errs[i] = err
+					return
+				}
+				jobs[i] = &transfer.DeviceTransfer{Device: dev.Label(), Backend: backend, Plan: sp.Plan, Manifests: sp.Manifests}
+			}(i, dev)
+		}
+		wg.Wait()
+
+		var msg fanoutPlanMsg
+		for i, dev := range devices {
+			if errs[i] != nil {
+				msg.failed = append(msg.failed, transfer.DeviceResult{Device: dev.Label(), Err: errs[i]})
+				continue
+			}
+			msg.jobs = append(msg.jobs, *jobs[i])
+		}
+		return msg
+	}
+}
+
+func (f *FanoutScreen) startTransfer() tea.Cmd {
+	jobs := f.jobs
+	opts := transfer.ExecuteOptions{
+		Concurrency: f.cfg.Transfer.Concurrency,
+		Verify:      f.cfg.Transfer.Verify,
+	}
+
+	kiosk := make(map[string]*bool)
+	for _, dev := range f.cfg.AllDevices() {
+		kiosk[dev.Label()] = dev.Lists.Kiosk
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+
+	progressCh := make(chan transfer.DeviceProgress, 100)
+	f.progressCh = progressCh
+	pauseCh := f.listenPause()
+
+	return tea.Batch(
+		listenFanoutProgress(progressCh),
+		listenTransferPause(pauseCh),
+		func() tea.Msg {
+			results := transfer.ExecuteDevices(ctx, jobs, opts, progressCh)
+			for i, j := range jobs {
+				on := kiosk[j.Device]
+				if on == nil || results[i].Err != nil {
+					continue
+				}
+				if _, err := replaycfg.SetKiosk(ctx, j.Backend, *on); err != nil {
+					results[i].Err = fmt.Errorf("kiosk mode: %w", err)
+				}
+			}
+			// The kiosk push is throttled too and may report a pause.
+			close(pauseCh)
+			return fanoutDoneMsg{results: results}
+		},
+	)
+}
+
+// startBulkTransfer runs one set of rsync processes per device.
+func (f *FanoutScreen) startBulkTransfer(devices []config.DeviceConfig, folders []string) (tea.Cmd, error) {
+	if _, err := transfer.FindTool("rsync"); err != nil {
+		return nil, fmt.Errorf("requires 'rsync' — install: sudo pacman -S rsync")
+	}
+
+	var targets []transfer.BulkDeviceTransfer
+	for _, dev := range devices {
+		auth := transfer.SSHAuthFromDevice(dev)
+		if auth.NeedsSSHPass() {
+			if _, err := transfer.FindTool("sshpass"); err != nil {
+				return nil, fmt.Errorf("%s: password login requires 'sshpass' (or configure an SSH key)", dev.Label())
+			}
+		}
+		backend := transfer.NewRsyncBackend(dev.Host, dev.Port, dev.User, auth)
+		backend.Concurrency = f.cfg.Transfer.Concurrency
+		backend.Checksum = f.cfg.Transfer.Verify
+		// Split the bandwidth limit between devices as well as folders.
+		backend.BWLimit = f.cfg.Transfer.BandwidthLimit / len(devices)
+		if f.cfg.Transfer.BandwidthLimit > 0 && backend.BWLimit < 1 {
+			backend.BWLimit = 1
+		}
+		targets = append(targets, transfer.BulkDeviceTransfer{
+			Device:  dev.Label(),
+			Backend: backend,
+			Folders: transfer.DeviceFolderMappings(f.localRoot(), dev.RootPath, folders, dev.Systems),
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+
+	progressCh := make(chan transfer.DeviceProgress, 100)
+	f.progressCh = progressCh
+	pauseCh := f.listenPause()
+	throttle := f.throttle
+
+	return tea.Batch(
+		listenFanoutProgress(progressCh),
+		listenTransferPause(pauseCh),
+		func() tea.Msg {
+			// rsync cannot be paused mid-run: wait for the window to open
+			// before starting it.
+			err := throttle.WaitWindow(ctx)
+			close(pauseCh)
+			if err != nil {
+				close(progressCh)
+				results := make([]transfer.DeviceResult, len(targets))
+				for i, t := range targets {
+					results[i] = transfer.DeviceResult{Device: t.Device, Err: err}
+				}
+				return fanoutDoneMsg{results: results}
+			}
+			return fanoutDoneMsg{results: transfer.TransferDevicesBulk(ctx, targets, progressCh)}
+		},
+	), nil
+}
+
+// listenPause reports the throttle's window pauses on the returned
+// channel, which the caller closes once the transfer is over.
+func (f *FanoutScreen) listenPause() chan time.Time {
+	pauseCh := make(chan time.Time, 1)
+	f.pauseCh = pauseCh
+	if f.throttle != nil {
+		f.throttle.OnPause = func(until time.Time) {
+			select {
+			case pauseCh <- until:
+			default:
+			}
+		}
+	}
+	return pauseCh
+}
+
+func listenFanoutProgress(ch <-chan transfer.DeviceProgress) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return fanoutProgressMsg{progress: p}
+	}
+}
+
+func (f *FanoutScreen) View() string {
+	switch f.phase {
+	case fanoutPhasePlan:
+		return f.viewPlan()
+	case fanoutPhaseProgress:
+		return f.viewProgress()
+	case fanoutPhaseResults:
+		return f.viewResults()
+	}
+	return f.viewSelect()
+}
+
+func (f *FanoutScreen) viewSelect() string {
+	s := tui.StyleSubtitle.Render("Transfer to Devices") + "\n\n"
+
+	if f.err != nil {
+		s += tui.StyleError.Render(f.err.Error()) + "\n\n"
+	}
+
+	method := "SFTP"
+	if f.rsync {
+		method = "rsync"
+	}
+	s += fmt.Sprintf("Method: %s\n\n", method)
+
+	line := func(i int, selected bool, text string) string {
+		check := "[ ]"
+		if selected {
+			check = "[x]"
+		}
+		cursor := "  "
+		style := tui.StyleNormal
+		if i == f.cursor {
+			cursor = tui.StyleMenuCursor.String()
+			style = tui.StyleSelected
+		}
+		return cursor + style.Render(check+" "+text) + "\n"
+	}
+
+	s += tui.StyleDim.Render("Devices") + "\n"
+	for i, d := range f.devices {
+		text := fmt.Sprintf("%-16s %s@%s", d.cfg.Label(), d.cfg.User, d.cfg.Host)
+		if len(d.cfg.Systems) > 0 {
+			text += fmt.Sprintf("  (%d systems)", len(d.cfg.Systems))
+		}
+		s += line(i, d.selected, text)
+	}
+	s += "\n" + tui.StyleDim.Render("Folders") + "\n"
+	for i, fo := range f.folders {
+		s += line(len(f.devices)+i, fo.selected, fmt.Sprintf("%-10s %s/", fo.label, fo.dirName))
+	}
+
+	if len(f.cfg.Devices) == 0 {
+		s += "\n" + tui.StyleDim.Render("Add named devices under 'devices:' in the config file to send to several at once.") + "\n"
+	}
+
+	s += "\n" + tui.StyleDim.Render("space: toggle  m: sftp/rsync  enter: start  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
+func (f *FanoutScreen) viewPlan() string {
+	s := tui.StyleSubtitle.Render("Transfer Plan") + "\n\n"
+
+	if f.jobs == nil && f.failed == nil {
+		s += tui.StyleDim.Render("Connecting and planning...")
+		return lipgloss.NewStyle().Padding(1, 2).Render(s)
+	}
+
+	for _, j := range f.jobs {
+		p := j.Plan
+		s += fmt.Sprintf("%-16s %5d files %10s  %d already there", j.Device, len(p.Items)-p.SkipCount, formatBytes(p.TotalSize), p.SkipCount)
+		if len(p.Omitted) > 0 {
+			s += tui.StyleWarning.Render(fmt.Sprintf("  %d left out", len(p.Omitted)))
+		}
+		s += "\n"
+	}
+	for _, r := range f.failed {
+		s += tui.StyleError.Render(fmt.Sprintf("%-16s %v", r.Device, r.Err)) + "\n"
+	}
+
+	s += "\n" + tui.StyleDim.Render("enter: start transfer  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
+func (f *FanoutScreen) viewProgress() string {
+	s := tui.StyleSubtitle.Render("Transferring...") + "\n\n"
+
+	if !f.pausedUntil.IsZero() {
+		s += tui.StyleWarning.Render(fmt.Sprintf("Outside the transfer window \u2014 paused until %s", f.pausedUntil.Format("15:04"))) + "\n\n"
+	}
+
+	for _, d := range f.devices {
+		if !d.selected || d.failed {
+			continue
+		}
+		p := d.progress
+		s += tui.StyleSelected.Render(d.cfg.Label()) + "\n"
+		if p.TotalFiles == 0 {
+			s += tui.StyleDim.Render("  waiting...") + "\n\n"
+			continue
+		}
+		s += fmt.Sprintf("  %d / %d: %s\n", p.FileIndex+1, p.TotalFiles, p.Filename)
+		if p.TotalSize > 0 {
+			s += "  " + renderProgressBar(float64(p.TotalSent)/float64(p.TotalSize)*100, 40) + "\n"
+		}
+		s += "\n"
+	}
+
+	s += tui.StyleDim.Render("esc: cancel all")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
+func (f *FanoutScreen) viewResults() string {
+	s := tui.StyleSubtitle.Render("Transfer Complete") + "\n\n"
+
+	unit := "files"
+	if f.rsync {
+		unit = "folders"
+	}
+	for _, r := range f.results {
+		if r.Err != nil {
+			s += fmt.Sprintf("%s %-16s %s\n", tui.StyleError.Render("FAIL"), r.Device, tui.StyleError.Render(r.Err.Error()))
+			continue
+		}
+		line := fmt.Sprintf("%-16s %d %s", r.Device, r.Files, unit)
+		if r.Bytes > 0 {
+			line += fmt.Sprintf(" (%s)", formatBytes(r.Bytes))
+		}
+		line += " in " + r.Duration.Round(time.Second).String()
+		s += fmt.Sprintf("%s   %s\n", tui.StyleSuccess.Render("OK"), line)
+	}
+
+	s += "\n" + tui.StyleDim.Render("enter/esc: done")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
+func (f *FanoutScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Enter, tui.Keys.Back}
+}
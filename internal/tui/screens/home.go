@@ -49,6 +49,14 @@ func NewHomeScreen(cfg *config.Config, width, height int) *HomeScreen {
 			{title: "Convert Files", desc: "Convert disc images to CHD format", screen: tui.ScreenConvert},
 			{title: "Generate m3u Files", desc: "Generate m3u files for multi-disc games", screen: tui.ScreenM3U},
 			{title: "Transfer", desc: "Send files to your gaming device", screen: tui.ScreenTransfer},
+			{title: "Transfer to Devices", desc: "Send your library to several ReplayOS devices at once", screen: tui.ScreenFanout},
+			{title: "Provision Drive", desc: "Set up a fresh ReplayOS SD card or USB drive: folders, BIOS, ROMs and replay.cfg in one pass", screen: tui.ScreenProvision},
+			{title: "Device Status", desc: "Free space, space per system, BIOS files and system info of your device", screen: tui.ScreenDeviceStatus},
+			{title: "ReplayOS Options", desc: "Edit the device's replay.cfg, apply display presets and push it back", screen: tui.ScreenReplayCfg},
+			{title: "Favorites & Autostart", desc: "Pick favorites, the autostart game and kiosk mode from your library", screen: tui.ScreenLists},
+			{title: "Config Overrides", desc: "Per-game and per-system settings and input overrides: copy them between games, find orphans", screen: tui.ScreenOverrides},
+			{title: "Device Inventory", desc: "Compare your library with what's on the device, per system", screen: tui.ScreenInventory},
+			{title: "Backup & Restore", desc: "Pull saves, captures and config from your device, or restore a snapshot", screen: tui.ScreenBackup},
 			{title: "Archive Redundant Files", desc: "Clean up duplicates, superseded disc images, and spent archives", screen: tui.ScreenArchive},
 			{title: "Settings", desc: "Configure devices, paths, and options", screen: tui.ScreenSettings},
 			{title: "About ReplayOS", desc: "Learn more about ReplayOS and support the project", screen: tui.ScreenReplayOS},
@@ -0,0 +1,245 @@
+package screens
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type inventoryDoneMsg struct {
+	diff *transfer.InventoryDiff
+	err  error
+}
+
+type inventoryProgressMsg struct {
+	current, total int
+	filename       string
+}
+
+// InventoryScreen compares the local library with the device, per ReplayOS
+// system folder.
+type InventoryScreen struct {
+	cfg           *config.Config
+	width, height int
+
+	loading    bool
+	hashing    bool
+	progressCh chan inventoryProgressMsg
+	progress   inventoryProgressMsg
+	cancel     context.CancelFunc
+
+	diff         *transfer.InventoryDiff
+	err          error
+	scrollOffset int
+}
+
+func NewInventoryScreen(cfg *config.Config, width, height int) *InventoryScreen {
+	return &InventoryScreen{cfg: cfg, width: width, height: height}
+}
+
+func (s *InventoryScreen) Init() tea.Cmd {
+	return s.load(false)
+}
+
+// load scans the library and diffs it against the device, optionally
+// hashing files of equal size.
+func (s *InventoryScreen) load(hash bool) tea.Cmd {
+	s.loading = true
+	s.hashing = hash
+	s.err = nil
+	s.progress = inventoryProgressMsg{}
+
+	cfg := s.cfg
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	progressCh := make(chan inventoryProgressMsg, 100)
+	s.progressCh = progressCh
+
+	run := func() tea.Msg {
+		defer close(progressCh)
+
+		dirs := cfg.ROMDirs()
+		if len(dirs) == 0 {
+			return inventoryDoneMsg{err: fmt.Errorf("no source directories configured")}
+		}
+		backend, err := transfer.NewBackend(cfg, "")
+		if err != nil {
+			return inventoryDoneMsg{err: err}
+		}
+		if err := backend.Connect(ctx); err != nil {
+			return inventoryDoneMsg{err: fmt.Errorf("connect: %w", err)}
+		}
+		defer backend.Close()
+
+		result := organizer.Scan(dirs, cfg.Aliases)
+		opts := transfer.DiffOptions{Hash: hash}
+		if hash {
+			opts.ProgressFn = func(current, total int, filename string) {
+				select {
+				case progressCh <- inventoryProgressMsg{current: current, total: total, filename: filename}:
+				default:
+				}
+			}
+		}
+		diff, err := transfer.DiffInventory(ctx, backend, result, dirs, opts)
+		return inventoryDoneMsg{diff: diff, err: err}
+	}
+
+	return tea.Batch(listenInventoryProgress(progressCh), run)
+}
+
+func listenInventoryProgress(ch <-chan inventoryProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return p
+	}
+}
+
+func (s *InventoryScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+
+	case inventoryProgressMsg:
+		s.progress = msg
+		return s, listenInventoryProgress(s.progressCh)
+
+	case inventoryDoneMsg:
+		s.loading = false
+		s.cancel = nil
+		s.err = msg.err
+		if msg.diff != nil {
+			s.diff = msg.diff
+			s.scrollOffset = 0
+		}
+
+	case tea.KeyMsg:
+		if s.loading {
+			if key.Matches(msg, tui.Keys.Back) && s.cancel != nil {
+				s.cancel()
+			}
+			return s, nil
+		}
+		switch {
+		case key.Matches(msg, tui.Keys.Back):
+			return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+		case key.Matches(msg, tui.Keys.Up):
+			if s.scrollOffset > 0 {
+				s.scrollOffset--
+			}
+		case key.Matches(msg, tui.Keys.Down):
+			s.scrollOffset++
+		case msg.String() == "h":
+			return s, s.load(true)
+		case msg.String() == "r":
+			return s, s.load(false)
+		}
+	}
+	return s, nil
+}
+
+func (s *InventoryScreen) View() string {
+	out := tui.StyleSubtitle.Render("Device Inventory") + "\n\n"
+
+	if s.loading {
+		if s.hashing && s.progress.total > 0 {
+			p := s.progress
+			out += fmt.Sprintf("Hashing %d / %d: %s\n", p.current, p.total, p.filename)
+			out += renderProgressBar(float64(p.current)/float64(p.total)*100, 40) + "\n"
+		} else {
+			out += tui.StyleDim.Render("Scanning library and listing device...") + "\n"
+		}
+		out += "\n" + tui.StyleDim.Render("esc: cancel")
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+
+	if s.err != nil {
+		out += tui.StyleError.Render("Error: "+s.err.Error()) + "\n"
+	}
+	if s.diff == nil {
+		out += "\n" + tui.StyleDim.Render("r: retry  esc: back")
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+
+	lines, inSync := s.diffLines()
+	if len(lines) == 0 {
+		out += tui.StyleSuccess.Render("Library and device are in sync.") + "\n"
+	}
+
+	maxVisible := s.height - 12
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	if s.scrollOffset > len(lines)-maxVisible {
+		s.scrollOffset = len(lines) - maxVisible
+	}
+	if s.scrollOffset < 0 {
+		s.scrollOffset = 0
+	}
+	end := s.scrollOffset + maxVisible
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for _, line := range lines[s.scrollOffset:end] {
+		out += line + "\n"
+	}
+	if len(lines) > maxVisible {
+		out += tui.StyleDim.Render(fmt.Sprintf("(%d more, use arrows to scroll)", len(lines)-maxVisible)) + "\n"
+	}
+
+	if inSync > 0 {
+		out += "\n" + tui.StyleDim.Render(fmt.Sprintf("%d systems in sync", inSync)) + "\n"
+	}
+	if !s.diff.Hashed {
+		out += tui.StyleDim.Render("Files of equal size were not hashed.") + "\n"
+	}
+
+	out += "\n" + tui.StyleDim.Render("+ only local  - only on device  ~ differs    h: compare hashes  r: refresh  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(out)
+}
+
+// diffLines renders systems with differences and counts those in sync.
+func (s *InventoryScreen) diffLines() ([]string, int) {
+	var lines []string
+	inSync := 0
+	for _, sys := range s.diff.Systems {
+		if sys.Changes() == 0 {
+			inSync++
+			continue
+		}
+		lines = append(lines, tui.StyleSubtitle.Render(sys.Folder)+tui.StyleDim.Render(fmt.Sprintf(
+			"  %d same, %d only local, %d only on device, %d different",
+			sys.Same, len(sys.OnlyLocal), len(sys.OnlyDevice), len(sys.Different))))
+		for _, e := range sys.OnlyLocal {
+			lines = append(lines, tui.StyleSuccess.Render("  + "+e.Path)+tui.StyleDim.Render(" "+formatBytes(e.LocalSize)))
+		}
+		for _, e := range sys.OnlyDevice {
+			lines = append(lines, tui.StyleError.Render("  - "+e.Path)+tui.StyleDim.Render(" "+formatBytes(e.RemoteSize)))
+		}
+		for _, e := range sys.Different {
+			detail := fmt.Sprintf(" %s local, %s device", formatBytes(e.LocalSize), formatBytes(e.RemoteSize))
+			if e.Reason == "hash" {
+				detail = " same size, different SHA1"
+			}
+			lines = append(lines, tui.StyleWarning.Render("  ~ "+e.Path)+tui.StyleDim.Render(detail))
+		}
+		lines = append(lines, "")
+	}
+	return lines, inSync
+}
+
+func (s *InventoryScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Back}
+}
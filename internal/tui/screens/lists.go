@@ -0,0 +1,344 @@
+package screens
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type listsScanMsg struct {
+	games []string
+	err   error
+}
+
+// ListsScreen curates a device's favorites, autostart game and kiosk mode
+// from the library. The lists are saved to the device's config and written
+// to roms/_favorites and roms/_autostart on the next transfer.
+type ListsScreen struct {
+	cfg           *config.Config
+	width, height int
+
+	// devices are the configured devices whose lists can be edited.
+	devices []*config.DeviceConfig
+	device  int
+
+	loading bool
+	err     error
+	games   []string // paths under roms/, sorted
+
+	filter    textinput.Model
+	filtering bool
+	favsOnly  bool
+	cursor    int
+	offset    int
+
+	dirty   bool
+	notice  string
+	saveErr error
+}
+
+func NewListsScreen(cfg *config.Config, width, height int) *ListsScreen {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.CharLimit = 128
+	ti.Width = 40
+
+	devices := []*config.DeviceConfig{&cfg.Device}
+	for i := range cfg.Devices {
+		if cfg.Devices[i].Label() != cfg.Device.Label() {
+			devices = append(devices, &cfg.Devices[i])
+		}
+	}
+	return &ListsScreen{cfg: cfg, width: width, height: height, devices: devices, filter: ti}
+}
+
+func (s *ListsScreen) Init() tea.Cmd {
+	return s.scan()
+}
+
+// scan lists the games in the first source root, the one transfers send.
+func (s *ListsScreen) scan() tea.Cmd {
+	s.loading = true
+	s.err = nil
+	cfg := s.cfg
+	return func() tea.Msg {
+		dirs := cfg.ROMDirs()
+		if len(dirs) == 0 {
+			return listsScanMsg{err: fmt.Errorf("no source directories configured")}
+		}
+		result := organizer.Scan(dirs[:1], cfg.Aliases)
+		return listsScanMsg{games: transfer.LibraryGames(result, dirs[0])}
+	}
+}
+
+func (s *ListsScreen) lists() *config.ListsConfig {
+	return &s.devices[s.device].Lists
+}
+
+// visible returns the games shown under the current filter.
+func (s *ListsScreen) visible() []string {
+	q := strings.ToLower(s.filter.Value())
+	lists := s.lists()
+	var out []string
+	for _, g := range s.games {
+		if s.favsOnly && !s.isFavorite(g) && g != lists.Autostart {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(g), q) {
+			continue
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+func (s *ListsScreen) isFavorite(game string) bool {
+	for _, f := range s.lists().Favorites {
+		if f == game {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ListsScreen) toggleFavorite(game string) {
+	lists := s.lists()
+	for i, f := range lists.Favorites {
+		if f == game {
+			lists.Favorites = append(lists.Favorites[:i], lists.Favorites[i+1:]...)
+			return
+		}
+	}
+	lists.Favorites = append(lists.Favorites, game)
+	sort.Strings(lists.Favorites)
+}
+
+// cycleKiosk steps kiosk mode through unset, on and off.
+func (s *ListsScreen) cycleKiosk() {
+	lists := s.lists()
+	switch {
+	case lists.Kiosk == nil:
+		on := true
+		lists.Kiosk = &on
+	case *lists.Kiosk:
+		off := false
+		lists.Kiosk = &off
+	default:
+		lists.Kiosk = nil
+	}
+}
+
+func (s *ListsScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+
+	case listsScanMsg:
+		s.loading = false
+		s.err = msg.err
+		s.games = msg.games
+
+	case tea.KeyMsg:
+		if s.loading {
+			if key.Matches(msg, tui.Keys.Back) {
+				return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+			}
+			return s, nil
+		}
+		if s.filtering {
+			return s.updateFilter(msg)
+		}
+		return s.updateList(msg)
+	}
+	return s, nil
+}
+
+func (s *ListsScreen) updateFilter(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Enter):
+		s.filtering = false
+		s.filter.Blur()
+		return s, nil
+	case key.Matches(msg, tui.Keys.Back):
+		s.filtering = false
+		s.filter.Blur()
+		s.filter.SetValue("")
+		s.cursor, s.offset = 0, 0
+		return s, nil
+	}
+	var cmd tea.Cmd
+	s.filter, cmd = s.filter.Update(msg)
+	s.cursor, s.offset = 0, 0
+	return s, cmd
+}
+
+func (s *ListsScreen) updateList(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	games := s.visible()
+	var current string
+	if s.cursor < len(games) {
+		current = games[s.cursor]
+	}
+	s.notice = ""
+
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+	case key.Matches(msg, tui.Keys.Up):
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if s.cursor < len(games)-1 {
+			s.cursor++
+		}
+	case key.Matches(msg, tui.Keys.Tab):
+		s.device = (s.device + 1) % len(s.devices)
+		s.cursor, s.offset = 0, 0
+	case key.Matches(msg, tui.Keys.Space), key.Matches(msg, tui.Keys.Enter):
+		if current != "" {
+			s.toggleFavorite(current)
+			s.dirty = true
+		}
+	case msg.String() == "a":
+		if current != "" {
+			lists := s.lists()
+			if lists.Autostart == current {
+				lists.Autostart = ""
+			} else {
+				lists.Autostart = current
+			}
+			s.dirty = true
+		}
+	case msg.String() == "k":
+		s.cycleKiosk()
+		s.dirty = true
+	case msg.String() == "f":
+		s.favsOnly = !s.favsOnly
+		s.cursor, s.offset = 0, 0
+	case msg.String() == "/":
+		s.filtering = true
+		return s, s.filter.Focus()
+	case msg.String() == "r":
+		return s, s.scan()
+	case msg.String() == "s":
+		s.saveErr = config.Save(s.cfg, "")
+		if s.saveErr == nil {
+			s.dirty = false
+			s.notice = fmt.Sprintf("Saved. The next ROM transfer writes roms/%s and roms/%s.", transfer.FavoritesFolder, transfer.AutostartFolder)
+		}
+	}
+	return s, nil
+}
+
+func (s *ListsScreen) View() string {
+	out := tui.StyleSubtitle.Render("Favorites & Autostart") + "\n\n"
+
+	if s.loading {
+		out += tui.StyleDim.Render("Scanning library...") + "\n"
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+	if s.err != nil {
+		out += tui.StyleError.Render("Error: "+s.err.Error()) + "\n"
+		out += "\n" + tui.StyleDim.Render("r: retry  esc: back")
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+
+	dev := s.devices[s.device]
+	lists := dev.Lists
+	label := dev.Label()
+	if label == "" {
+		label = "device"
+	}
+	if len(s.devices) > 1 {
+		label += tui.StyleDim.Render(fmt.Sprintf("  (%d/%d, tab: next device)", s.device+1, len(s.devices)))
+	}
+	out += fmt.Sprintf("Device:     %s\n", label)
+	out += fmt.Sprintf("Favorites:  %d games\n", len(lists.Favorites))
+	autostart := tui.StyleDim.Render("none")
+	if lists.Autostart != "" {
+		autostart = lists.Autostart
+	}
+	out += fmt.Sprintf("Autostart:  %s\n", autostart)
+	kiosk := tui.StyleDim.Render("leave as is")
+	if lists.Kiosk != nil {
+		kiosk = "off"
+		if *lists.Kiosk {
+			kiosk = "on"
+		}
+	}
+	out += fmt.Sprintf("Kiosk mode: %s\n\n", kiosk)
+
+	if s.filtering || s.filter.Value() != "" {
+		out += s.filter.View() + "\n"
+	}
+
+	games := s.visible()
+	if len(games) == 0 {
+		out += tui.StyleDim.Render("No games match.") + "\n"
+	}
+	maxVisible := s.height - 18
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+maxVisible {
+		s.offset = s.cursor - maxVisible + 1
+	}
+	end := s.offset + maxVisible
+	if end > len(games) {
+		end = len(games)
+	}
+	for i := s.offset; i < end; i++ {
+		g := games[i]
+		check := "[ ]"
+		if s.isFavorite(g) {
+			check = "[x]"
+		}
+		mark := "  "
+		if g == lists.Autostart {
+			mark = "A "
+		}
+		cursor := "  "
+		style := tui.StyleNormal
+		if i == s.cursor {
+			cursor = tui.StyleMenuCursor.String()
+			style = tui.StyleSelected
+		}
+		out += cursor + style.Render(check+" "+mark+g) + "\n"
+	}
+	if len(games) > maxVisible {
+		out += tui.StyleDim.Render(fmt.Sprintf("(%d games, use arrows to scroll)", len(games))) + "\n"
+	}
+
+	if s.saveErr != nil {
+		out += "\n" + tui.StyleError.Render("Save failed: "+s.saveErr.Error()) + "\n"
+	} else if s.notice != "" {
+		out += "\n" + tui.StyleSuccess.Render(s.notice) + "\n"
+	} else if s.dirty {
+		out += "\n" + tui.StyleWarning.Render("Unsaved changes") + "\n"
+	}
+
+	show := "favorites only"
+	if s.favsOnly {
+		show = "all games"
+	}
+	out += "\n" + tui.StyleDim.Render("[x] favorite  A autostart") + "\n"
+	out += tui.StyleDim.Render("space: favorite  a: autostart  k: kiosk  f: " + show + "  /: filter  s: save  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(out)
+}
+
+func (s *ListsScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Space, tui.Keys.Back}
+}
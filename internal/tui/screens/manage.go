@@ -94,7 +94,7 @@ type resolveProgressMsg struct {
 type resolveDoneMsg struct {
 	misplaced   []organizer.MisplacedFile
 	resolvedN   int // number of unresolved files resolved by extension
-	ssResolvedN int // number of unresolved files resolved by ScreenScraper
+	ssResolvedN int // number of unresolved files resolved by DATs or ScreenScraper
 }
 
 type ManageScreen struct {
@@ -109,7 +109,7 @@ type ManageScreen struct {
 	// Resolve misplaced/unknown ROMs
 	misplaced         []organizer.MisplacedFile
 	resolvedN         int // number of unresolved files resolved by extension
-	ssResolvedN       int // number resolved by ScreenScraper
+	ssResolvedN       int // number resolved by DATs or ScreenScraper
 	resolveDone       bool
 	resolveProgressCh <-chan resolveProgressMsg
 	resolveProgress   struct {
@@ -694,8 +694,9 @@ func (m *ManageScreen) startResolve() tea.Cmd {
 	scanResult := m.scanResult
 	cfg := m.cfg
 	hasSS := cfg.Scraping.ScreenScraperUser != ""
+	hasDATs := len(cfg.Scraping.DATDirs) > 0
 
-	if !hasSS {
+	if !hasSS && !hasDATs {
 		// Simple path: extension-only resolve (instant, no progress needed)
 		return func() tea.Msg {
 			misplaced := organizer.DetectMisplaced(scanResult)
@@ -706,7 +707,7 @@ func (m *ManageScreen) startResolve() tea.Cmd {
 		}
 	}
 
-	// SS path: async with progress reporting
+	// Hash path (DATs and/or ScreenScraper): async with progress reporting
 	progressCh := make(chan resolveProgressMsg, 100)
 	m.resolveProgressCh = progressCh
 	m.resolveProgress.current = 0
@@ -721,18 +722,26 @@ func (m *ManageScreen) startResolve() tea.Cmd {
 		organizer.ResolveUnknown(context.Background(), scanResult, nil)
 		resolvedN := unresolvedBefore - len(scanResult.Unresolved)
 
-		// SS resolve for remaining unresolved files
+		// DAT and SS resolve for remaining unresolved files
 		ssResolvedN := 0
 		if len(scanResult.Unresolved) > 0 {
-			ssClient := scraper.NewScreenScraperClient(
-				cfg.Scraping.ScreenScraperUser,
-				cfg.Scraping.ScreenScraperPass,
-			)
+			var ssClient *scraper.ScreenScraperClient
+			if hasSS {
+				ssClient = scraper.NewScreenScraperClient(
+					cfg.Scraping.ScreenScraperUser,
+					cfg.Scraping.ScreenScraperPass,
+				)
+			}
 			var cache scraper.Cache
 			if db, err := romdb.Open(""); err == nil {
 				cache = db
 			}
-			identifier := scraper.NewIdentifier(nil, ssClient, cache)
+			// DATs and header skippers that fail to load are skipped; the
+			// rest still resolve.
+			dats, _ := scraper.LoadDATDirs(cfg.Scraping.DATDirs)
+			skippers, _ := scraper.LoadHeaderSkippers(cfg.Scraping.DATDirs)
+			identifier := scraper.NewIdentifier(dats, ssClient, cache)
+			identifier.SetHeaderSkippers(append(skippers, scraper.BuiltinHeaderSkippers...))
 
 			total := len(scanResult.Unresolved)
 			var stillUnresolved []string
@@ -805,14 +814,25 @@ func (m *ManageScreen) updateResolve(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 	return m, nil
 }
 
+// resolveSources names the hash lookups startResolve uses.
+func resolveSources(cfg *config.Config) string {
+	switch {
+	case len(cfg.Scraping.DATDirs) == 0:
+		return "ScreenScraper"
+	case cfg.Scraping.ScreenScraperUser == "":
+		return "DATs"
+	}
+	return "DATs and ScreenScraper"
+}
+
 func (m *ManageScreen) viewResolve() string {
 	s := tui.StyleSubtitle.Render("Detect Misplaced ROMs") + "\n\n"
 
 	if !m.resolveDone {
 		if m.resolveProgress.total > 0 {
-			// ScreenScraper lookup in progress
-			s += fmt.Sprintf("Resolving via ScreenScraper... (%d / %d)\n",
-				m.resolveProgress.current, m.resolveProgress.total)
+			// DAT / ScreenScraper lookup in progress
+			s += fmt.Sprintf("Resolving via %s... (%d / %d)\n",
+				resolveSources(m.cfg), m.resolveProgress.current, m.resolveProgress.total)
 			s += tui.StyleDim.Render("Hashing: "+m.resolveProgress.filename) + "\n"
 		} else {
 			s += tui.StyleDim.Render("Analyzing file placements...")
@@ -833,8 +853,8 @@ func (m *ManageScreen) viewResolve() string {
 	}
 
 	if m.ssResolvedN > 0 {
-		s += fmt.Sprintf("%s Resolved %d files via ScreenScraper\n\n",
-			tui.StyleSuccess.Render("+"), m.ssResolvedN)
+		s += fmt.Sprintf("%s Resolved %d files via %s\n\n",
+			tui.StyleSuccess.Render("+"), m.ssResolvedN, resolveSources(m.cfg))
 	}
 
 	if len(m.misplaced) > 0 {
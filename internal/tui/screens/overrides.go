@@ -0,0 +1,446 @@
+package screens
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/organizer"
+	"github.com/kurlmarx/romwrangler/internal/overrides"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type overridesLoadedMsg struct {
+	matches []overrides.Match
+	lib     *overrides.Library
+	pulled  *overrides.PullResult
+	err     error
+}
+
+type overridesPhase int
+
+const (
+	overridesPhaseList overridesPhase = iota
+	overridesPhaseTargets
+)
+
+// OverridesScreen lists the per-game and per-system overrides in the
+// library's config folder, maps them to games, flags orphans and copies a
+// game's override to other games. The Config transfer folder sends them.
+type OverridesScreen struct {
+	cfg           *config.Config
+	width, height int
+	phase         overridesPhase
+
+	loading bool
+	err     error
+	notice  string
+
+	matches     []overrides.Match
+	lib         *overrides.Library
+	orphansOnly bool
+	cursor      int
+	offset      int
+
+	// Copy targets
+	source    overrides.Override
+	filter    textinput.Model
+	filtering bool
+	targets   map[string]bool
+	overwrite bool
+	tCursor   int
+	tOffset   int
+}
+
+func NewOverridesScreen(cfg *config.Config, width, height int) *OverridesScreen {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.CharLimit = 128
+	ti.Width = 40
+	return &OverridesScreen{cfg: cfg, width: width, height: height, filter: ti}
+}
+
+func (s *OverridesScreen) Init() tea.Cmd {
+	return s.load(false)
+}
+
+func (s *OverridesScreen) configDir() string {
+	if len(s.cfg.SourceDirs) == 0 {
+		return ""
+	}
+	return filepath.Join(s.cfg.SourceDirs[0], overrides.ConfigFolder)
+}
+
+// load scans the library and lists the local overrides, after pulling the
+// device's when pull is set.
+func (s *OverridesScreen) load(pull bool) tea.Cmd {
+	s.loading = true
+	s.err = nil
+	s.notice = ""
+	cfg := s.cfg
+	configDir := s.configDir()
+	return func() tea.Msg {
+		if configDir == "" {
+			return overridesLoadedMsg{err: fmt.Errorf("no source directories configured")}
+		}
+		var msg overridesLoadedMsg
+		if pull {
+			ctx := context.Background()
+			backend, err := transfer.NewBackend(cfg, "")
+			if err != nil {
+				return overridesLoadedMsg{err: err}
+			}
+			if err := backend.Connect(ctx); err != nil {
+				return overridesLoadedMsg{err: fmt.Errorf("connect: %w", err)}
+			}
+			msg.pulled, err = overrides.Pull(ctx, backend, configDir, nil)
+			backend.Close()
+			if err != nil {
+				return overridesLoadedMsg{err: err}
+			}
+		}
+
+		list, err := overrides.List(configDir)
+		if err != nil {
+			return overridesLoadedMsg{err: err}
+		}
+		romDir := cfg.ROMDirs()[0]
+		scan := organizer.Scan([]string{romDir}, cfg.Aliases)
+		msg.lib = overrides.NewLibrary(transfer.LibraryGames(scan, romDir))
+		msg.matches = overrides.Map(list, msg.lib)
+		return msg
+	}
+}
+
+// visible returns the overrides shown in the list.
+func (s *OverridesScreen) visible() []overrides.Match {
+	if s.orphansOnly {
+		return overrides.Orphans(s.matches)
+	}
+	return s.matches
+}
+
+// visibleTargets returns the library games shown under the current filter.
+func (s *OverridesScreen) visibleTargets() []string {
+	if s.lib == nil {
+		return nil
+	}
+	q := strings.ToLower(s.filter.Value())
+	var out []string
+	for _, g := range s.lib.Games {
+		if q == "" || strings.Contains(strings.ToLower(g), q) {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func (s *OverridesScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+
+	case overridesLoadedMsg:
+		s.loading = false
+		s.err = msg.err
+		if msg.err == nil {
+			s.matches = msg.matches
+			s.lib = msg.lib
+			s.cursor, s.offset = 0, 0
+		}
+		if p := msg.pulled; p != nil {
+			s.notice = fmt.Sprintf("Pulled %d overrides from the device, %d unchanged", p.Downloaded, p.Unchanged)
+			if len(p.Errors) > 0 {
+				s.err = fmt.Errorf("%d overrides could not be downloaded, first: %w", len(p.Errors), p.Errors[0])
+			}
+		}
+
+	case tea.KeyMsg:
+		if s.loading {
+			return s, nil
+		}
+		if s.phase == overridesPhaseTargets {
+			if s.filtering {
+				return s.updateFilter(msg)
+			}
+			return s.updateTargets(msg)
+		}
+		return s.updateList(msg)
+	}
+	return s, nil
+}
+
+func (s *OverridesScreen) updateList(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	list := s.visible()
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+	case key.Matches(msg, tui.Keys.Up):
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if s.cursor < len(list)-1 {
+			s.cursor++
+		}
+	case msg.String() == "o":
+		s.orphansOnly = !s.orphansOnly
+		s.cursor, s.offset = 0, 0
+	case msg.String() == "p":
+		return s, s.load(true)
+	case msg.String() == "r":
+		return s, s.load(false)
+	case key.Matches(msg, tui.Keys.Enter), msg.String() == "c":
+		if s.cursor >= len(list) {
+			return s, nil
+		}
+		m := list[s.cursor]
+		if m.Scope != overrides.ScopeGame {
+			s.notice = "System overrides apply to a whole system and cannot be copied to games"
+			return s, nil
+		}
+		s.source = m.Override
+		s.targets = make(map[string]bool)
+		s.overwrite = false
+		s.filter.SetValue("")
+		s.tCursor, s.tOffset = 0, 0
+		s.notice = ""
+		s.phase = overridesPhaseTargets
+	}
+	return s, nil
+}
+
+func (s *OverridesScreen) updateFilter(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Enter):
+		s.filtering = false
+		s.filter.Blur()
+		return s, nil
+	case key.Matches(msg, tui.Keys.Back):
+		s.filtering = false
+		s.filter.Blur()
+		s.filter.SetValue("")
+		s.tCursor, s.tOffset = 0, 0
+		return s, nil
+	}
+	var cmd tea.Cmd
+	s.filter, cmd = s.filter.Update(msg)
+	s.tCursor, s.tOffset = 0, 0
+	return s, cmd
+}
+
+func (s *OverridesScreen) updateTargets(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	games := s.visibleTargets()
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		s.phase = overridesPhaseList
+	case key.Matches(msg, tui.Keys.Up):
+		if s.tCursor > 0 {
+			s.tCursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if s.tCursor < len(games)-1 {
+			s.tCursor++
+		}
+	case key.Matches(msg, tui.Keys.Space):
+		if s.tCursor < len(games) {
+			g := games[s.tCursor]
+			s.targets[g] = !s.targets[g]
+		}
+	case msg.String() == "a":
+		// Select every shown game, or clear them when all are selected.
+		all := true
+		for _, g := range games {
+			if !s.targets[g] {
+				all = false
+				break
+			}
+		}
+		for _, g := range games {
+			s.targets[g] = !all
+		}
+	case msg.String() == "w":
+		s.overwrite = !s.overwrite
+	case msg.String() == "/":
+		s.filtering = true
+		return s, s.filter.Focus()
+	case key.Matches(msg, tui.Keys.Enter):
+		return s, s.copy()
+	}
+	return s, nil
+}
+
+// copy writes the source override for every selected game and reloads the
+// list.
+func (s *OverridesScreen) copy() tea.Cmd {
+	var games []string
+	for _, g := range s.lib.Games {
+		if s.targets[g] {
+			games = append(games, g)
+		}
+	}
+	if len(games) == 0 {
+		s.notice = "Select games with space first"
+		return nil
+	}
+	result, err := overrides.Copy(s.configDir(), s.source, games, s.overwrite)
+	s.phase = overridesPhaseList
+	if err != nil {
+		s.err = err
+		return nil
+	}
+	cmd := s.load(false)
+	s.notice = fmt.Sprintf("Copied %s to %d games", s.source.Name, len(result.Written))
+	if n := len(result.Skipped); n > 0 {
+		s.notice += fmt.Sprintf(", %d skipped (already had one)", n)
+	}
+	s.notice += ". Send them with Transfer > Config."
+	return cmd
+}
+
+func (s *OverridesScreen) View() string {
+	if s.phase == overridesPhaseTargets {
+		return s.viewTargets()
+	}
+	out := tui.StyleSubtitle.Render("Config Overrides") + "  " + tui.StyleDim.Render(s.configDir()) + "\n\n"
+
+	if s.loading {
+		out += tui.StyleDim.Render("Scanning library and overrides...") + "\n"
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+	if s.err != nil {
+		out += tui.StyleError.Render("Error: "+s.err.Error()) + "\n\n"
+	}
+
+	orphans := len(overrides.Orphans(s.matches))
+	out += fmt.Sprintf("%d overrides, ", len(s.matches))
+	if orphans > 0 {
+		out += tui.StyleWarning.Render(fmt.Sprintf("%d orphaned", orphans))
+	} else {
+		out += "none orphaned"
+	}
+	out += "\n\n"
+
+	list := s.visible()
+	if len(list) == 0 {
+		out += tui.StyleDim.Render("No overrides. Press p to pull them from the device.") + "\n"
+	}
+	maxVisible := s.height - 14
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	s.offset = clampOffset(s.cursor, s.offset, maxVisible)
+	end := s.offset + maxVisible
+	if end > len(list) {
+		end = len(list)
+	}
+	for i := s.offset; i < end; i++ {
+		m := list[i]
+		cursor := "  "
+		style := tui.StyleNormal
+		if i == s.cursor {
+			cursor = tui.StyleMenuCursor.String()
+			style = tui.StyleSelected
+		}
+		where := fmt.Sprintf("%-8s %-6s %-3s", m.Kind, m.Scope, m.Display)
+		var match string
+		switch {
+		case m.Orphan:
+			match = tui.StyleWarning.Render("  orphaned")
+		case m.Scope == overrides.ScopeGame:
+			match = tui.StyleDim.Render("  " + strings.Join(m.Games, ", "))
+		}
+		out += cursor + style.Render(where+"  "+m.Name) + match + "\n"
+	}
+	if len(list) > maxVisible {
+		out += tui.StyleDim.Render(fmt.Sprintf("(%d overrides, use arrows to scroll)", len(list))) + "\n"
+	}
+
+	if s.notice != "" {
+		out += "\n" + tui.StyleSuccess.Render(s.notice) + "\n"
+	}
+	filter := "orphans only"
+	if s.orphansOnly {
+		filter = "all"
+	}
+	out += "\n" + tui.StyleDim.Render("enter: copy to games  o: "+filter+"  p: pull from device  r: rescan  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(out)
+}
+
+func (s *OverridesScreen) viewTargets() string {
+	out := tui.StyleSubtitle.Render("Copy Override") + "\n\n"
+	out += fmt.Sprintf("From: %s\n", s.source.Path)
+
+	selected := 0
+	for _, on := range s.targets {
+		if on {
+			selected++
+		}
+	}
+	overwrite := "keep overrides games already have"
+	if s.overwrite {
+		overwrite = "replace overrides games already have"
+	}
+	out += fmt.Sprintf("To:   %d games selected (%s)\n\n", selected, overwrite)
+
+	if s.filtering || s.filter.Value() != "" {
+		out += s.filter.View() + "\n"
+	}
+
+	games := s.visibleTargets()
+	maxVisible := s.height - 14
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	s.tOffset = clampOffset(s.tCursor, s.tOffset, maxVisible)
+	end := s.tOffset + maxVisible
+	if end > len(games) {
+		end = len(games)
+	}
+	for i := s.tOffset; i < end; i++ {
+		g := games[i]
+		check := "[ ]"
+		if s.targets[g] {
+			check = "[x]"
+		}
+		cursor := "  "
+		style := tui.StyleNormal
+		if i == s.tCursor {
+			cursor = tui.StyleMenuCursor.String()
+			style = tui.StyleSelected
+		}
+		out += cursor + style.Render(check+" "+g) + "\n"
+	}
+	if len(games) > maxVisible {
+		out += tui.StyleDim.Render(fmt.Sprintf("(%d games, use arrows to scroll)", len(games))) + "\n"
+	}
+	if s.notice != "" {
+		out += "\n" + tui.StyleWarning.Render(s.notice) + "\n"
+	}
+
+	out += "\n" + tui.StyleDim.Render("space: select  a: select shown  /: filter  w: overwrite  enter: copy  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(out)
+}
+
+// clampOffset scrolls a list of height rows so that cursor stays visible.
+func clampOffset(cursor, offset, height int) int {
+	if cursor < offset {
+		return cursor
+	}
+	if cursor >= offset+height {
+		return cursor - height + 1
+	}
+	return offset
+}
+
+func (s *OverridesScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Enter, tui.Keys.Back}
+}
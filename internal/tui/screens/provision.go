@@ -0,0 +1,527 @@
+package screens
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/provision"
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type provisionPhase int
+
+const (
+	provisionPhaseMount provisionPhase = iota
+	provisionPhaseOptions
+	provisionPhasePlan
+	provisionPhaseProgress
+	provisionPhaseReport
+)
+
+type provisionPrepareMsg struct {
+	p   *provision.Provision
+	err error
+}
+
+type provisionDoneMsg struct {
+	report *provision.Report
+	err    error
+}
+
+// ProvisionScreen sets up a fresh ReplayOS SD card or USB drive: it checks
+// the mount, lets the user pick systems and a replay.cfg preset, copies
+// everything in one pass and ends with a completeness report.
+type ProvisionScreen struct {
+	cfg           *config.Config
+	width, height int
+	phase         provisionPhase
+
+	mount   textinput.Model
+	state   provision.MountState
+	foreign []string
+	force   bool
+	err     error
+
+	// Options
+	systems  []string // system folders in the library
+	selected map[string]bool
+	cursor   int
+	offset   int
+	presets  []replaycfg.Preset
+	preset   int // index into presets, -1 for none
+
+	loading bool
+	prov    *provision.Provision
+
+	cancel     context.CancelFunc
+	progressCh <-chan transfer.TransferProgress
+	progress   transfer.TransferProgress
+
+	report       *provision.Report
+	showBIOS     bool
+	scrollOffset int
+}
+
+func NewProvisionScreen(cfg *config.Config, width, height int) *ProvisionScreen {
+	ti := textinput.New()
+	ti.Placeholder = "/media/REPLAY"
+	ti.CharLimit = 256
+	ti.Width = 50
+	ti.SetValue(cfg.Transfer.USBPath)
+	ti.Focus()
+	return &ProvisionScreen{cfg: cfg, width: width, height: height, mount: ti, preset: -1}
+}
+
+func (s *ProvisionScreen) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// libraryRoot is the source root transfers send, holding roms/ and bios/.
+func (s *ProvisionScreen) libraryRoot() string {
+	if len(s.cfg.SourceDirs) == 0 {
+		return ""
+	}
+	return s.cfg.SourceDirs[0]
+}
+
+// check inspects the mount and lists the library's systems.
+func (s *ProvisionScreen) check() {
+	s.err = nil
+	mount := strings.TrimSpace(s.mount.Value())
+	if mount == "" {
+		s.err = fmt.Errorf("enter the mount path of the drive")
+		return
+	}
+	if s.libraryRoot() == "" {
+		s.err = fmt.Errorf("no source directories configured")
+		return
+	}
+	state, foreign, err := provision.CheckMount(mount)
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.state, s.foreign, s.force = state, foreign, false
+
+	entries, err := os.ReadDir(filepath.Join(s.libraryRoot(), "roms"))
+	if err != nil && !os.IsNotExist(err) {
+		s.err = err
+		return
+	}
+	s.systems = nil
+	s.selected = make(map[string]bool)
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		s.systems = append(s.systems, name)
+		s.selected[name] = true
+	}
+	sort.Strings(s.systems)
+	s.cursor, s.offset = 0, 0
+
+	s.presets, err = replaycfg.LoadPresets(presetDir())
+	if err != nil {
+		s.presets = replaycfg.BuiltinPresets
+	}
+	if s.preset >= len(s.presets) {
+		s.preset = -1
+	}
+	s.mount.Blur()
+	s.phase = provisionPhaseOptions
+}
+
+func (s *ProvisionScreen) options() provision.Options {
+	opts := provision.Options{
+		Mount:       strings.TrimSpace(s.mount.Value()),
+		LibraryRoot: s.libraryRoot(),
+		Transfer:    s.cfg.Transfer,
+		Force:       s.force,
+	}
+	if len(s.selectedSystems()) < len(s.systems) {
+		opts.Systems = s.selectedSystems()
+	}
+	if s.preset >= 0 {
+		p := s.presets[s.preset]
+		opts.Preset = &p
+	}
+	return opts
+}
+
+func (s *ProvisionScreen) selectedSystems() []string {
+	var out []string
+	for _, sys := range s.systems {
+		if s.selected[sys] {
+			out = append(out, sys)
+		}
+	}
+	return out
+}
+
+func (s *ProvisionScreen) prepare() tea.Cmd {
+	s.loading = true
+	s.err = nil
+	opts := s.options()
+	return func() tea.Msg {
+		p, err := provision.Prepare(context.Background(), opts)
+		return provisionPrepareMsg{p: p, err: err}
+	}
+}
+
+func (s *ProvisionScreen) run() tea.Cmd {
+	p := s.prov
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	progressCh := make(chan transfer.TransferProgress, 100)
+	s.progressCh = progressCh
+
+	doneCh := make(chan provisionDoneMsg, 1)
+	go func() {
+		report, err := p.Run(ctx, progressCh)
+		doneCh <- provisionDoneMsg{report: report, err: err}
+	}()
+
+	return tea.Batch(
+		listenTransferProgress(progressCh),
+		func() tea.Msg { return <-doneCh },
+	)
+}
+
+func (s *ProvisionScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+
+	case provisionPrepareMsg:
+		s.loading = false
+		if msg.err != nil {
+			s.err = msg.err
+			return s, nil
+		}
+		s.prov = msg.p
+		s.phase = provisionPhasePlan
+
+	case transferProgressMsg:
+		s.progress = msg.progress
+		return s, listenTransferProgress(s.progressCh)
+
+	case provisionDoneMsg:
+		s.prov.Plan.Cleanup()
+		s.cancel = nil
+		s.err = msg.err
+		s.report = msg.report
+		s.scrollOffset = 0
+		s.phase = provisionPhaseReport
+
+	case tea.KeyMsg:
+		if s.loading {
+			return s, nil
+		}
+		switch s.phase {
+		case provisionPhaseMount:
+			return s.updateMount(msg)
+		case provisionPhaseOptions:
+			return s.updateOptions(msg)
+		case provisionPhasePlan:
+			return s.updatePlan(msg)
+		case provisionPhaseProgress:
+			if key.Matches(msg, tui.Keys.Back) && s.cancel != nil {
+				s.cancel()
+			}
+		case provisionPhaseReport:
+			return s.updateReport(msg)
+		}
+	}
+	return s, nil
+}
+
+func (s *ProvisionScreen) updateMount(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+	case key.Matches(msg, tui.Keys.Enter):
+		s.check()
+		return s, nil
+	}
+	var cmd tea.Cmd
+	s.mount, cmd = s.mount.Update(msg)
+	return s, cmd
+}
+
+func (s *ProvisionScreen) updateOptions(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		s.err = nil
+		s.phase = provisionPhaseMount
+		return s, s.mount.Focus()
+	case key.Matches(msg, tui.Keys.Up):
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if s.cursor < len(s.systems)-1 {
+			s.cursor++
+		}
+	case key.Matches(msg, tui.Keys.Space):
+		if s.cursor < len(s.systems) {
+			sys := s.systems[s.cursor]
+			s.selected[sys] = !s.selected[sys]
+		}
+	case msg.String() == "a":
+		all := len(s.selectedSystems()) < len(s.systems)
+		for _, sys := range s.systems {
+			s.selected[sys] = all
+		}
+	case msg.String() == "p":
+		s.preset++
+		if s.preset >= len(s.presets) {
+			s.preset = -1
+		}
+	case msg.String() == "f":
+		if s.state == provision.MountForeign {
+			s.force = !s.force
+		}
+	case key.Matches(msg, tui.Keys.Enter):
+		if s.state == provision.MountForeign && !s.force {
+			s.err = fmt.Errorf("the drive holds other files; press f to provision it anyway")
+			return s, nil
+		}
+		if len(s.systems) > 0 && len(s.selectedSystems()) == 0 {
+			s.err = fmt.Errorf("pick at least one system")
+			return s, nil
+		}
+		return s, s.prepare()
+	}
+	return s, nil
+}
+
+func (s *ProvisionScreen) updatePlan(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		s.prov.Plan.Cleanup()
+		s.prov = nil
+		s.phase = provisionPhaseOptions
+	case key.Matches(msg, tui.Keys.Enter):
+		s.progress = transfer.TransferProgress{}
+		s.phase = provisionPhaseProgress
+		return s, s.run()
+	}
+	return s, nil
+}
+
+func (s *ProvisionScreen) updateReport(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back), key.Matches(msg, tui.Keys.Enter):
+		return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+	case key.Matches(msg, tui.Keys.Up):
+		if s.scrollOffset > 0 {
+			s.scrollOffset--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		s.scrollOffset++
+	case msg.String() == "b":
+		s.showBIOS = !s.showBIOS
+	}
+	return s, nil
+}
+
+func (s *ProvisionScreen) View() string {
+	out := tui.StyleSubtitle.Render("Provision Drive") + "\n\n"
+	switch s.phase {
+	case provisionPhaseMount:
+		out += "Mount path of the SD card or USB drive:\n\n"
+		out += s.mount.View() + "\n"
+		if s.err != nil {
+			out += "\n" + tui.StyleError.Render("Error: "+s.err.Error()) + "\n"
+		}
+		out += "\n" + tui.StyleDim.Render("enter: check drive  esc: back")
+	case provisionPhaseOptions:
+		out += s.viewOptions()
+	case provisionPhasePlan:
+		out += s.viewPlan()
+	case provisionPhaseProgress:
+		out += s.viewProgress()
+	case provisionPhaseReport:
+		out += s.viewReport()
+	}
+	return lipgloss.NewStyle().Padding(1, 2).Render(out)
+}
+
+func (s *ProvisionScreen) viewOptions() string {
+	out := fmt.Sprintf("Drive:  %s  ", strings.TrimSpace(s.mount.Value()))
+	if s.state == provision.MountForeign {
+		out += tui.StyleWarning.Render(s.state.String()) + "\n"
+		out += tui.StyleDim.Render("  found: "+strings.Join(s.foreign, ", ")) + "\n"
+		if s.force {
+			out += tui.StyleWarning.Render("  will provision anyway; existing files are kept") + "\n"
+		}
+	} else {
+		out += tui.StyleSuccess.Render(s.state.String()) + "\n"
+	}
+	presetName := "none (ReplayOS defaults)"
+	if s.preset >= 0 {
+		presetName = s.presets[s.preset].Name
+	}
+	out += fmt.Sprintf("Preset: %s\n\n", presetName)
+
+	out += fmt.Sprintf("Systems to copy (%d of %d):\n", len(s.selectedSystems()), len(s.systems))
+	if len(s.systems) == 0 {
+		out += tui.StyleDim.Render("  No system folders in the library; only BIOS files will be copied.") + "\n"
+	}
+	height := s.height - 16
+	if height < 5 {
+		height = 5
+	}
+	s.offset = clampOffset(s.cursor, s.offset, height)
+	end := s.offset + height
+	if end > len(s.systems) {
+		end = len(s.systems)
+	}
+	for i := s.offset; i < end; i++ {
+		sys := s.systems[i]
+		cursor := "  "
+		style := tui.StyleNormal
+		if i == s.cursor {
+			cursor = tui.StyleMenuCursor.String()
+			style = tui.StyleSelected
+		}
+		check := "[ ]"
+		if s.selected[sys] {
+			check = "[x]"
+		}
+		out += cursor + style.Render(check+" "+sys) + "\n"
+	}
+
+	if s.loading {
+		out += "\n" + tui.StyleDim.Render("Planning...") + "\n"
+	}
+	if s.err != nil {
+		out += "\n" + tui.StyleError.Render("Error: "+s.err.Error()) + "\n"
+	}
+	help := "space: toggle  a: all/none  p: preset  enter: plan  esc: back"
+	if s.state == provision.MountForeign {
+		help = "f: force  " + help
+	}
+	return out + "\n" + tui.StyleDim.Render(help)
+}
+
+func (s *ProvisionScreen) viewPlan() string {
+	p := s.prov
+	plan := p.Plan
+	out := fmt.Sprintf("Drive %s is %s\n\n", p.Mount, p.State)
+	out += fmt.Sprintf("  %d folders in the ReplayOS layout\n", len(provision.Skeleton()))
+	out += fmt.Sprintf("  %d files to copy (%s)", len(plan.Items)-plan.SkipCount, formatBytes(plan.TotalSize))
+	if plan.SkipCount > 0 {
+		out += tui.StyleDim.Render(fmt.Sprintf(", %d already there", plan.SkipCount))
+	}
+	out += "\n"
+	if p.BIOS > 0 {
+		out += fmt.Sprintf("  %d BIOS files found among the ROMs go to bios/\n", p.BIOS)
+	}
+	if len(plan.Omitted) > 0 {
+		out += tui.StyleWarning.Render(fmt.Sprintf("  %d files left out (%s)", len(plan.Omitted), formatBytes(plan.OmittedSize))) + "\n"
+	}
+	cfgLine := "  replay.cfg with ReplayOS defaults"
+	if p.Preset != nil {
+		cfgLine += ", preset " + p.Preset.Name
+	}
+	out += cfgLine + "\n"
+	out += "\n" + tui.StyleDim.Render("enter: provision  esc: back")
+	return out
+}
+
+func (s *ProvisionScreen) viewProgress() string {
+	out := ""
+	p := s.progress
+	if p.TotalFiles > 0 {
+		out += fmt.Sprintf("File %d / %d: %s\n", p.FileIndex+1, p.TotalFiles, p.Filename)
+		if p.FileSize > 0 {
+			out += renderProgressBar(float64(p.BytesSent)/float64(p.FileSize)*100, 40) + "\n"
+		}
+		out += "\n"
+		if p.TotalSize > 0 {
+			out += fmt.Sprintf("Overall: %s / %s\n", formatBytes(p.TotalSent), formatBytes(p.TotalSize))
+			out += renderProgressBar(float64(p.TotalSent)/float64(p.TotalSize)*100, 40) + "\n"
+		}
+	} else {
+		out += tui.StyleDim.Render("Creating folders...") + "\n"
+	}
+	return out + "\n" + tui.StyleDim.Render("esc: cancel")
+}
+
+func (s *ProvisionScreen) viewReport() string {
+	if s.err != nil {
+		return tui.StyleError.Render("Error: "+s.err.Error()) + "\n\n" + tui.StyleDim.Render("esc: back")
+	}
+	r := s.report
+	out := ""
+	if r.Complete() {
+		out += tui.StyleSuccess.Render("Drive is ready for ReplayOS.") + "\n\n"
+	} else {
+		out += tui.StyleWarning.Render("Provisioning incomplete.") + "\n\n"
+	}
+
+	lines := []string{
+		fmt.Sprintf("Folders     %d created", r.FoldersCreated),
+		fmt.Sprintf("Files       %d copied (%s)", r.FilesSent, formatBytes(r.BytesSent)),
+		fmt.Sprintf("replay.cfg  %s", r.ReplayCfg),
+	}
+	if len(r.Missing) > 0 {
+		lines = append(lines, tui.StyleError.Render(fmt.Sprintf("%d folders missing: %s", len(r.Missing), strings.Join(r.Missing, ", "))))
+	}
+	if len(r.Omitted) > 0 {
+		lines = append(lines, tui.StyleWarning.Render(fmt.Sprintf("%d files left out", len(r.Omitted))))
+	}
+	for _, err := range r.ReplayCfgErrs {
+		lines = append(lines, tui.StyleError.Render("replay.cfg: "+err.Error()))
+	}
+	for _, err := range r.Errors {
+		lines = append(lines, tui.StyleError.Render("! ")+tui.StyleDim.Render(err.Error()))
+	}
+	lines = append(lines, "")
+	if r.Status != nil {
+		status := *r.Status
+		status.Errors = nil // already listed above
+		lines = append(lines, deviceStatusLines(&status, s.showBIOS)...)
+	}
+
+	maxVisible := s.height - 12
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	if s.scrollOffset > len(lines)-maxVisible {
+		s.scrollOffset = len(lines) - maxVisible
+	}
+	if s.scrollOffset < 0 {
+		s.scrollOffset = 0
+	}
+	end := s.scrollOffset + maxVisible
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for _, line := range lines[s.scrollOffset:end] {
+		out += line + "\n"
+	}
+	if len(lines) > maxVisible {
+		out += tui.StyleDim.Render(fmt.Sprintf("(%d more, use arrows to scroll)", len(lines)-maxVisible)) + "\n"
+	}
+	return out + "\n" + tui.StyleDim.Render("b: show missing BIOS files  esc: back")
+}
+
+func (s *ProvisionScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Space, tui.Keys.Enter, tui.Keys.Back}
+}
@@ -0,0 +1,533 @@
+package screens
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type replayCfgPhase int
+
+const (
+	replayCfgList replayCfgPhase = iota
+	replayCfgEdit
+	replayCfgPresets
+	replayCfgExport
+)
+
+type replayCfgLoadedMsg struct {
+	file *replaycfg.File
+	err  error
+}
+
+type replayCfgPushedMsg struct {
+	err error
+}
+
+// ReplayCfgScreen edits the device's replay.cfg: it downloads the file,
+// edits values within their documented ranges, applies or exports presets
+// and pushes the result back.
+type ReplayCfgScreen struct {
+	cfg           *config.Config
+	width, height int
+	phase         replayCfgPhase
+
+	loading bool
+	pushing bool
+	file    *replaycfg.File
+	options []replaycfg.Option // schema order, then keys the schema lacks
+	dirty   bool
+	err     error
+	notice  string
+
+	cursor int
+	scroll int
+
+	// Editing
+	input   textinput.Model
+	choice  int // index into Allowed() for enum and bool options
+	editErr error
+
+	// Presets
+	presets      []replaycfg.Preset
+	presetCursor int
+	presetErr    error
+}
+
+func NewReplayCfgScreen(cfg *config.Config, width, height int) *ReplayCfgScreen {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.CharLimit = 256
+	ti.Width = 40
+	return &ReplayCfgScreen{cfg: cfg, width: width, height: height, input: ti}
+}
+
+func (s *ReplayCfgScreen) Init() tea.Cmd {
+	return s.fetch()
+}
+
+// presetDir is where exported presets are kept, next to config.yaml.
+func presetDir() string {
+	return filepath.Join(filepath.Dir(config.DefaultPath()), "presets")
+}
+
+func (s *ReplayCfgScreen) fetch() tea.Cmd {
+	s.loading = true
+	s.err = nil
+	s.notice = ""
+	cfg := s.cfg
+	return func() tea.Msg {
+		ctx := context.Background()
+		backend, err := transfer.NewBackend(cfg, "")
+		if err != nil {
+			return replayCfgLoadedMsg{err: err}
+		}
+		if err := backend.Connect(ctx); err != nil {
+			return replayCfgLoadedMsg{err: fmt.Errorf("connect: %w", err)}
+		}
+		defer backend.Close()
+		f, err := replaycfg.Fetch(ctx, backend)
+		return replayCfgLoadedMsg{file: f, err: err}
+	}
+}
+
+func (s *ReplayCfgScreen) push() tea.Cmd {
+	s.pushing = true
+	s.err = nil
+	s.notice = ""
+	cfg := s.cfg
+	data := s.file.Bytes()
+	return func() tea.Msg {
+		ctx := context.Background()
+		backend, err := transfer.NewBackend(cfg, "")
+		if err != nil {
+			return replayCfgPushedMsg{err: err}
+		}
+		if err := backend.Connect(ctx); err != nil {
+			return replayCfgPushedMsg{err: fmt.Errorf("connect: %w", err)}
+		}
+		defer backend.Close()
+		return replayCfgPushedMsg{err: replaycfg.Push(ctx, backend, replaycfg.Parse(data))}
+	}
+}
+
+// setFile shows f and lists its options.
+func (s *ReplayCfgScreen) setFile(f *replaycfg.File) {
+	s.file = f
+	s.dirty = false
+	s.options = append([]replaycfg.Option(nil), replaycfg.Schema...)
+	for _, k := range f.Keys() {
+		if _, ok := replaycfg.Lookup(k); !ok {
+			s.options = append(s.options, replaycfg.Option{Key: k, Kind: replaycfg.KindString})
+		}
+	}
+	if s.cursor >= len(s.options) {
+		s.cursor = 0
+	}
+}
+
+// value returns the option's value in the file, or its default.
+func (s *ReplayCfgScreen) value(o replaycfg.Option) (string, bool) {
+	if v, ok := s.file.Get(o.Key); ok {
+		return v, true
+	}
+	return o.Default, false
+}
+
+func (s *ReplayCfgScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+
+	case replayCfgLoadedMsg:
+		s.loading = false
+		s.err = msg.err
+		if msg.file != nil {
+			s.setFile(msg.file)
+		}
+
+	case replayCfgPushedMsg:
+		s.pushing = false
+		s.err = msg.err
+		if msg.err == nil {
+			s.dirty = false
+			s.notice = "Pushed " + replaycfg.DevicePath + " to the device"
+		}
+
+	case tea.KeyMsg:
+		if s.loading || s.pushing {
+			return s, nil
+		}
+		if s.file == nil {
+			switch {
+			case key.Matches(msg, tui.Keys.Back):
+				return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+			case msg.String() == "r":
+				return s, s.fetch()
+			case msg.String() == "n":
+				s.err = nil
+				s.setFile(replaycfg.Parse([]byte(replaycfg.Documented)))
+				s.dirty = true
+			}
+			return s, nil
+		}
+		switch s.phase {
+		case replayCfgList:
+			return s.updateList(msg)
+		case replayCfgEdit:
+			return s.updateEdit(msg)
+		case replayCfgPresets:
+			return s.updatePresets(msg)
+		case replayCfgExport:
+			return s.updateExport(msg)
+		}
+	}
+
+	if s.phase == replayCfgEdit || s.phase == replayCfgExport {
+		var cmd tea.Cmd
+		s.input, cmd = s.input.Update(msg)
+		return s, cmd
+	}
+	return s, nil
+}
+
+func (s *ReplayCfgScreen) updateList(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	s.notice = ""
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+	case key.Matches(msg, tui.Keys.Up):
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if s.cursor < len(s.options)-1 {
+			s.cursor++
+		}
+	case key.Matches(msg, tui.Keys.Enter):
+		return s, s.startEdit()
+	case msg.String() == "d":
+		o := s.options[s.cursor]
+		if o.Default != "" && s.file.Set(o.Key, o.Default) {
+			s.dirty = true
+		}
+	case msg.String() == "p":
+		s.presets, s.presetErr = replaycfg.LoadPresets(presetDir())
+		s.presetCursor = 0
+		s.phase = replayCfgPresets
+	case msg.String() == "e":
+		s.input.SetValue("")
+		s.input.Placeholder = "preset name"
+		s.editErr = nil
+		s.phase = replayCfgExport
+		s.input.Focus()
+		return s, s.input.Cursor.BlinkCmd()
+	case msg.String() == "s":
+		if errs := replaycfg.Validate(s.file); len(errs) > 0 {
+			s.err = fmt.Errorf("fix %d invalid values before pushing", len(errs))
+			return s, nil
+		}
+		return s, s.push()
+	case msg.String() == "r":
+		return s, s.fetch()
+	}
+	return s, nil
+}
+
+func (s *ReplayCfgScreen) startEdit() tea.Cmd {
+	o := s.options[s.cursor]
+	v, _ := s.value(o)
+	s.editErr = nil
+	s.phase = replayCfgEdit
+
+	if allowed := o.Allowed(); len(allowed) > 0 {
+		s.choice = 0
+		for i, a := range allowed {
+			if a == v {
+				s.choice = i
+			}
+		}
+		return nil
+	}
+	s.input.SetValue(v)
+	s.input.Placeholder = ""
+	s.input.CursorEnd()
+	s.input.Focus()
+	return s.input.Cursor.BlinkCmd()
+}
+
+func (s *ReplayCfgScreen) updateEdit(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	o := s.options[s.cursor]
+	allowed := o.Allowed()
+
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		s.input.Blur()
+		s.phase = replayCfgList
+		return s, nil
+	case key.Matches(msg, tui.Keys.Enter):
+		v := strings.TrimSpace(s.input.Value())
+		if len(allowed) > 0 {
+			v = allowed[s.choice]
+		}
+		if err := o.Validate(v); err != nil {
+			s.editErr = err
+			return s, nil
+		}
+		if s.file.Set(o.Key, v) {
+			s.dirty = true
+		}
+		s.input.Blur()
+		s.phase = replayCfgList
+		return s, nil
+	}
+
+	if len(allowed) > 0 {
+		switch msg.String() {
+		case "left", "h", "up", "k":
+			s.choice = (s.choice + len(allowed) - 1) % len(allowed)
+		case "right", "l", "down", "j", " ":
+			s.choice = (s.choice + 1) % len(allowed)
+		}
+		return s, nil
+	}
+
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	s.editErr = nil
+	return s, cmd
+}
+
+func (s *ReplayCfgScreen) updatePresets(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		s.phase = replayCfgList
+	case key.Matches(msg, tui.Keys.Up):
+		if s.presetCursor > 0 {
+			s.presetCursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if s.presetCursor < len(s.presets)-1 {
+			s.presetCursor++
+		}
+	case key.Matches(msg, tui.Keys.Enter):
+		if s.presetCursor >= len(s.presets) {
+			return s, nil
+		}
+		p := s.presets[s.presetCursor]
+		if errs := p.Validate(); len(errs) > 0 {
+			s.presetErr = fmt.Errorf("preset %q: %w", p.Name, errs[0])
+			return s, nil
+		}
+		changed := p.Apply(s.file)
+		if len(changed) > 0 {
+			s.dirty = true
+		}
+		s.notice = fmt.Sprintf("Applied %q: %d values changed", p.Name, len(changed))
+		s.phase = replayCfgList
+	}
+	return s, nil
+}
+
+func (s *ReplayCfgScreen) updateExport(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		s.input.Blur()
+		s.phase = replayCfgList
+		return s, nil
+	case key.Matches(msg, tui.Keys.Enter):
+		p := replaycfg.ExportPreset(strings.TrimSpace(s.input.Value()), s.file)
+		path, err := replaycfg.SavePreset(presetDir(), p)
+		if err != nil {
+			s.editErr = err
+			return s, nil
+		}
+		s.input.Blur()
+		s.notice = "Exported preset to " + path
+		s.phase = replayCfgList
+		return s, nil
+	}
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	s.editErr = nil
+	return s, cmd
+}
+
+func (s *ReplayCfgScreen) View() string {
+	out := tui.StyleSubtitle.Render("ReplayOS Options") + "  " + tui.StyleDim.Render(replaycfg.DevicePath)
+	if s.dirty {
+		out += "  " + tui.StyleWarning.Render("modified")
+	}
+	out += "\n\n"
+
+	switch {
+	case s.loading:
+		out += tui.StyleDim.Render("Downloading replay.cfg from the device...") + "\n"
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	case s.pushing:
+		out += tui.StyleDim.Render("Pushing replay.cfg to the device...") + "\n"
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	case s.file == nil:
+		if s.err != nil {
+			out += tui.StyleError.Render("Error: "+s.err.Error()) + "\n"
+		}
+		out += "\n" + tui.StyleDim.Render("r: retry  n: start from the documented defaults  esc: back")
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+
+	switch s.phase {
+	case replayCfgPresets:
+		out += s.viewPresets()
+	case replayCfgExport:
+		out += "Export the current options (except Wi-Fi and NFS settings) as a preset.\n\n"
+		out += "Name: " + s.input.View() + "\n"
+		if s.editErr != nil {
+			out += tui.StyleError.Render(s.editErr.Error()) + "\n"
+		}
+		out += "\n" + tui.StyleDim.Render("enter: save  esc: cancel")
+	default:
+		out += s.viewList()
+	}
+	return lipgloss.NewStyle().Padding(1, 2).Render(out)
+}
+
+func (s *ReplayCfgScreen) viewList() string {
+	out := ""
+	maxVisible := s.height - 16
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	if s.cursor < s.scroll {
+		s.scroll = s.cursor
+	}
+	if s.cursor >= s.scroll+maxVisible {
+		s.scroll = s.cursor - maxVisible + 1
+	}
+	end := s.scroll + maxVisible
+	if end > len(s.options) {
+		end = len(s.options)
+	}
+
+	for i := s.scroll; i < end; i++ {
+		o := s.options[i]
+		v, set := s.value(o)
+		cursor := "  "
+		name := fmt.Sprintf("%-28s", o.Key)
+		if i == s.cursor {
+			cursor = tui.StyleMenuCursor.Render("> ")
+			name = tui.StyleSelected.Render(name)
+		}
+
+		shown := tui.StyleNormal.Render(v)
+		if i == s.cursor && s.phase == replayCfgEdit {
+			if allowed := o.Allowed(); len(allowed) > 0 {
+				shown = tui.StyleSelected.Render("< " + allowed[s.choice] + " >")
+				v = allowed[s.choice]
+			} else {
+				shown = s.input.View()
+			}
+		} else if !set {
+			shown = tui.StyleDim.Render(v + " (default)")
+		}
+
+		line := cursor + name + " " + shown
+		if label := o.Label(v); label != "" {
+			line += "  " + tui.StyleDim.Render(label)
+		}
+		if err := o.Validate(v); err != nil && s.phase != replayCfgEdit {
+			line += "  " + tui.StyleError.Render("invalid")
+		}
+		out += line + "\n"
+	}
+	if len(s.options) > maxVisible {
+		out += tui.StyleDim.Render(fmt.Sprintf("  %d-%d of %d", s.scroll+1, end, len(s.options))) + "\n"
+	}
+
+	out += "\n" + s.optionHelp(s.options[s.cursor]) + "\n"
+	if s.phase == replayCfgEdit && s.editErr != nil {
+		out += tui.StyleError.Render(s.editErr.Error()) + "\n"
+	}
+	if s.err != nil {
+		out += tui.StyleError.Render("Error: "+s.err.Error()) + "\n"
+	}
+	if s.notice != "" {
+		out += tui.StyleSuccess.Render(s.notice) + "\n"
+	}
+
+	if s.phase == replayCfgEdit {
+		if len(s.options[s.cursor].Allowed()) > 0 {
+			out += "\n" + tui.StyleDim.Render("←/→: change  enter: set  esc: cancel")
+		} else {
+			out += "\n" + tui.StyleDim.Render("enter: set  esc: cancel")
+		}
+	} else {
+		out += "\n" + tui.StyleDim.Render("enter: edit  d: default  p: presets  e: export preset  s: push to device  r: reload  esc: back")
+	}
+	return out
+}
+
+// optionHelp describes what values the option accepts.
+func (s *ReplayCfgScreen) optionHelp(o replaycfg.Option) string {
+	var parts []string
+	if o.Description != "" {
+		parts = append(parts, o.Description)
+	}
+	switch o.Kind {
+	case replaycfg.KindRange:
+		parts = append(parts, fmt.Sprintf("%g to %g", o.Min, o.Max))
+	case replaycfg.KindInt:
+		parts = append(parts, "whole number")
+	case replaycfg.KindBool:
+		parts = append(parts, "true or false")
+	case replaycfg.KindEnum:
+		parts = append(parts, fmt.Sprintf("%d documented values", len(o.Allowed())))
+	}
+	if o.Default != "" {
+		parts = append(parts, "default "+o.Default)
+	}
+	if len(parts) == 0 {
+		return tui.StyleDim.Render("Not documented; edited as text.")
+	}
+	return tui.StyleDim.Render(strings.Join(parts, " · "))
+}
+
+func (s *ReplayCfgScreen) viewPresets() string {
+	out := "Apply a preset to the options below; push to send them to the device.\n\n"
+	for i, p := range s.presets {
+		cursor := "  "
+		name := p.Name
+		if i == s.presetCursor {
+			cursor = tui.StyleMenuCursor.Render("> ")
+			name = tui.StyleSelected.Render(name)
+		}
+		line := cursor + name
+		if p.Builtin {
+			line += tui.StyleDim.Render("  (built-in)")
+		}
+		if p.Description != "" {
+			line += "  " + tui.StyleDim.Render(p.Description)
+		}
+		out += line + "\n"
+	}
+	if s.presetErr != nil {
+		out += "\n" + tui.StyleError.Render(s.presetErr.Error()) + "\n"
+	}
+	out += "\n" + tui.StyleDim.Render("Presets are saved in "+presetDir()) + "\n"
+	out += "\n" + tui.StyleDim.Render("enter: apply  esc: back")
+	return out
+}
+
+func (s *ReplayCfgScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Enter, tui.Keys.Back}
+}
@@ -2,6 +2,8 @@ package screens
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -18,9 +20,10 @@ const (
 	sectionMenu     settingsSection = iota
 	sectionGeneral                  // fields: source dirs, chdman, delete archive
 	sectionTransfer                 // sub-menu: SFTP, USB, Concurrency
-	sectionNetwork                  // fields: host, port, user, password, ROM path
-	sectionUSB                      // fields: USB path
-	sectionConcurrency              // fields: concurrency
+	sectionNetwork                  // fields: host, port, user, password, ROM path, SSH key options
+	sectionUSB                      // fields: USB path, filesystem
+	sectionConcurrency              // fields: concurrency, verify, mirror, manifest, bandwidth, window
+	sectionCapacity                 // fields: fit to device, budget, reserve, priorities, include, exclude
 )
 
 type settingsField struct {
@@ -45,7 +48,7 @@ type SettingsScreen struct {
 }
 
 var mainMenuItems = []string{"General", "Transfer", "Setup ROM Folders", "Setup BIOS Folders"}
-var transferMenuItems = []string{"Network Settings", "USB Settings", "Concurrency"}
+var transferMenuItems = []string{"Network Settings", "USB Settings", "Concurrency & Verification", "Capacity Planning"}
 
 func NewSettingsScreen(cfg *config.Config, width, height int) *SettingsScreen {
 	s := &SettingsScreen{
@@ -155,8 +158,12 @@ func (s *SettingsScreen) updateTransferMenu(msg tea.KeyMsg) (tui.Screen, tea.Cmd
 			s.buildUSBFields()
 		case 2:
 			s.section = sectionConcurrency
-			s.sectionTitle = "Concurrency"
+			s.sectionTitle = "Concurrency & Verification"
 			s.buildConcurrencyFields()
+		case 3:
+			s.section = sectionCapacity
+			s.sectionTitle = "Capacity Planning"
+			s.buildCapacityFields()
 		}
 		if len(s.fields) > 0 {
 			s.fields[0].input.Focus()
@@ -221,6 +228,10 @@ func (s *SettingsScreen) buildNetworkFields() {
 		s.makeField("User", s.cfg.Device.User),
 		s.makeField("Password", s.cfg.Device.Password),
 		s.makeField("Root Path", s.cfg.Device.RootPath),
+		s.makeField("Identity File (SSH key)", s.cfg.Device.IdentityFile),
+		s.makeField("Use ssh-agent (true/false)", fmt.Sprintf("%v", s.cfg.Device.UseAgent)),
+		s.makeField("Known Hosts File (empty = ~/.ssh/known_hosts)", s.cfg.Device.KnownHostsFile),
+		s.makeField("INSECURE: skip host key check (true/false)", fmt.Sprintf("%v", s.cfg.Device.InsecureHostKey)),
 	}
 	s.fields[3].input.EchoMode = textinput.EchoPassword
 }
@@ -228,13 +239,77 @@ func (s *SettingsScreen) buildNetworkFields() {
 func (s *SettingsScreen) buildUSBFields() {
 	s.fields = []settingsField{
 		s.makeField("USB Path", s.cfg.Transfer.USBPath),
+		s.makeField("Filesystem (empty = detect, fat32, exfat, none)", s.cfg.Transfer.USBFilesystem),
 	}
 }
 
 func (s *SettingsScreen) buildConcurrencyFields() {
 	s.fields = []settingsField{
 		s.makeField("Concurrency", fmt.Sprintf("%d", s.cfg.Transfer.Concurrency)),
+		s.makeField("Verify Checksums (true/false)", fmt.Sprintf("%v", s.cfg.Transfer.Verify)),
+		s.makeField("Mirror ROMs - delete files missing locally (true/false)", fmt.Sprintf("%v", s.cfg.Transfer.Mirror)),
+		s.makeField("Device Manifest - fast sync decisions (true/false)", fmt.Sprintf("%v", s.cfg.Transfer.Manifest)),
+		s.makeField("Bandwidth Limit KiB/s (0 = unlimited)", fmt.Sprintf("%d", s.cfg.Transfer.BandwidthLimit)),
+		s.makeField("Transfer Window (HH:MM-HH:MM, empty = any time)", s.cfg.Transfer.Window),
+	}
+}
+
+func (s *SettingsScreen) buildCapacityFields() {
+	cc := s.cfg.Transfer.Capacity
+	budget := ""
+	if cc.BudgetGB > 0 {
+		budget = strconv.FormatFloat(cc.BudgetGB, 'f', -1, 64)
+	}
+	s.fields = []settingsField{
+		s.makeField("Fit To Device Free Space (true/false)", fmt.Sprintf("%v", cc.FitToDevice)),
+		s.makeField("Budget GB (empty = none)", budget),
+		s.makeField("Reserve MB", fmt.Sprintf("%d", cc.ReserveMB)),
+		s.makeField("Priorities (folder=N, comma-separated)", formatPriorities(cc.Priorities)),
+		s.makeField("Include (comma-separated patterns)", strings.Join(cc.Include, ",")),
+		s.makeField("Exclude (comma-separated patterns)", strings.Join(cc.Exclude, ",")),
+	}
+}
+
+// formatPriorities renders priorities as "folder=N" pairs sorted by folder.
+func formatPriorities(p map[string]int) string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%d", k, p[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// parsePriorities parses "folder=N" pairs, ignoring malformed entries.
+func parsePriorities(s string) map[string]int {
+	out := make(map[string]int)
+	for _, pair := range splitCommaList(s) {
+		name, val, ok := strings.Cut(pair, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if !ok || err != nil {
+			continue
+		}
+		out[strings.TrimSpace(name)] = n
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// splitCommaList splits a comma-separated field, dropping empty entries.
+func splitCommaList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
 	}
+	return out
 }
 
 func (s *SettingsScreen) makeField(label, value string) settingsField {
@@ -266,12 +341,31 @@ func (s *SettingsScreen) applyFields() {
 		s.cfg.Device.User = s.fields[2].input.Value()
 		s.cfg.Device.Password = s.fields[3].input.Value()
 		s.cfg.Device.RootPath = s.fields[4].input.Value()
+		s.cfg.Device.IdentityFile = strings.TrimSpace(s.fields[5].input.Value())
+		s.cfg.Device.UseAgent = s.fields[6].input.Value() == "true"
+		s.cfg.Device.KnownHostsFile = strings.TrimSpace(s.fields[7].input.Value())
+		s.cfg.Device.InsecureHostKey = s.fields[8].input.Value() == "true"
 
 	case sectionUSB:
 		s.cfg.Transfer.USBPath = s.fields[0].input.Value()
+		s.cfg.Transfer.USBFilesystem = strings.TrimSpace(s.fields[1].input.Value())
 
 	case sectionConcurrency:
 		fmt.Sscanf(s.fields[0].input.Value(), "%d", &s.cfg.Transfer.Concurrency)
+		s.cfg.Transfer.Verify = s.fields[1].input.Value() == "true"
+		s.cfg.Transfer.Mirror = s.fields[2].input.Value() == "true"
+		s.cfg.Transfer.Manifest = s.fields[3].input.Value() == "true"
+		fmt.Sscanf(s.fields[4].input.Value(), "%d", &s.cfg.Transfer.BandwidthLimit)
+		s.cfg.Transfer.Window = strings.TrimSpace(s.fields[5].input.Value())
+
+	case sectionCapacity:
+		cc := &s.cfg.Transfer.Capacity
+		cc.FitToDevice = s.fields[0].input.Value() == "true"
+		cc.BudgetGB, _ = strconv.ParseFloat(strings.TrimSpace(s.fields[1].input.Value()), 64)
+		fmt.Sscanf(s.fields[2].input.Value(), "%d", &cc.ReserveMB)
+		cc.Priorities = parsePriorities(s.fields[3].input.Value())
+		cc.Include = splitCommaList(s.fields[4].input.Value())
+		cc.Exclude = splitCommaList(s.fields[5].input.Value())
 	}
 }
 
@@ -1,12 +1,14 @@
 package screens
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/discovery"
 	"github.com/kurlmarx/romwrangler/internal/organizer"
 	"github.com/kurlmarx/romwrangler/internal/systems"
 	"github.com/kurlmarx/romwrangler/internal/tui"
@@ -18,6 +20,7 @@ const (
 	setupPhaseOverview setupPhase = iota
 	setupPhaseConfirm
 	setupPhaseDone
+	setupPhaseDiscover
 )
 
 type setupDoneMsg struct {
@@ -25,6 +28,11 @@ type setupDoneMsg struct {
 	errs    []error
 }
 
+type setupDiscoveredMsg struct {
+	devices []discovery.Device
+	err     error
+}
+
 type SetupScreen struct {
 	cfg           *config.Config
 	width, height int
@@ -38,6 +46,14 @@ type SetupScreen struct {
 	created int
 	errs    []error
 
+	// Device discovery
+	discovering  bool
+	devices      []discovery.Device
+	discoverErr  error
+	deviceCursor int
+	chosen       string // host written to the config, if any
+	saveErr      error
+
 	// UI
 	scroll int
 }
@@ -78,10 +94,18 @@ func (s *SetupScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
 		// Refresh folder status
 		s.statuses = organizer.CheckFolders(s.baseDir)
 
+	case setupDiscoveredMsg:
+		s.discovering = false
+		s.devices = msg.devices
+		s.discoverErr = msg.err
+		s.deviceCursor = 0
+
 	case tea.KeyMsg:
 		switch s.phase {
 		case setupPhaseOverview:
 			return s.updateOverview(msg)
+		case setupPhaseDiscover:
+			return s.updateDiscover(msg)
 		case setupPhaseConfirm:
 			return s.updateConfirm(msg)
 		case setupPhaseDone:
@@ -110,7 +134,53 @@ func (s *SetupScreen) updateOverview(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 			s.scroll++
 		}
 	case key.Matches(msg, tui.Keys.Enter):
-		s.phase = setupPhaseConfirm
+		if s.baseDir != "" {
+			s.phase = setupPhaseConfirm
+		}
+	case msg.String() == "d":
+		s.phase = setupPhaseDiscover
+		s.chosen = ""
+		return s, s.discover()
+	}
+	return s, nil
+}
+
+// discover browses the LAN for ReplayOS devices and SSH hosts.
+func (s *SetupScreen) discover() tea.Cmd {
+	s.discovering = true
+	s.devices = nil
+	s.discoverErr = nil
+	return func() tea.Msg {
+		devices, err := discovery.Browse(context.Background(), discovery.Options{})
+		return setupDiscoveredMsg{devices: devices, err: err}
+	}
+}
+
+func (s *SetupScreen) updateDiscover(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		s.phase = setupPhaseOverview
+	case s.discovering:
+		// Wait for the scan to finish.
+	case key.Matches(msg, tui.Keys.Up):
+		if s.deviceCursor > 0 {
+			s.deviceCursor--
+		}
+	case key.Matches(msg, tui.Keys.Down):
+		if s.deviceCursor < len(s.devices)-1 {
+			s.deviceCursor++
+		}
+	case msg.String() == "r":
+		s.chosen = ""
+		return s, s.discover()
+	case key.Matches(msg, tui.Keys.Enter):
+		if s.deviceCursor < len(s.devices) {
+			d := s.devices[s.deviceCursor]
+			s.cfg.Device.Host = d.Host()
+			s.cfg.Device.Port = d.Port
+			s.saveErr = config.Save(s.cfg, "")
+			s.chosen = d.Host()
+		}
 	}
 	return s, nil
 }
@@ -136,11 +206,14 @@ func (s *SetupScreen) updateConfirm(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 }
 
 func (s *SetupScreen) View() string {
+	if s.phase == setupPhaseDiscover {
+		return s.viewDiscover()
+	}
 	if s.baseDir == "" {
 		content := tui.StyleSubtitle.Render("Setup Folders") + "\n\n"
 		content += tui.StyleWarning.Render("No root directory configured.") + "\n\n"
 		content += tui.StyleDim.Render("Go to Settings and set a root directory first.")
-		content += "\n\n" + tui.StyleDim.Render("esc: back")
+		content += "\n\n" + tui.StyleDim.Render("d: find devices on the network  esc: back")
 		return lipgloss.NewStyle().Padding(1, 2).Render(content)
 	}
 
@@ -217,9 +290,9 @@ func (s *SetupScreen) viewOverview() string {
 	}
 
 	if missing > 0 {
-		content += "\n\n" + tui.StyleDim.Render("enter: create missing folders  esc: back")
+		content += "\n\n" + tui.StyleDim.Render("enter: create missing folders  d: find devices  esc: back")
 	} else {
-		content += "\n\n" + tui.StyleSuccess.Render("All folders exist!") + "  " + tui.StyleDim.Render("esc: back")
+		content += "\n\n" + tui.StyleSuccess.Render("All folders exist!") + "  " + tui.StyleDim.Render("d: find devices  esc: back")
 	}
 
 	return lipgloss.NewStyle().Padding(1, 2).Render(content)
@@ -265,6 +338,59 @@ func (s *SetupScreen) viewDone() string {
 	return lipgloss.NewStyle().Padding(1, 2).Render(content)
 }
 
+func (s *SetupScreen) viewDiscover() string {
+	content := tui.StyleSubtitle.Render("Find Devices") + "\n\n"
+
+	if s.discovering {
+		content += tui.StyleDim.Render("Looking for ReplayOS devices and SSH hosts on the network...")
+		content += "\n\n" + tui.StyleDim.Render("esc: back")
+		return lipgloss.NewStyle().Padding(1, 2).Render(content)
+	}
+
+	if s.discoverErr != nil {
+		content += tui.StyleError.Render("Discovery failed: "+s.discoverErr.Error()) + "\n"
+	} else if len(s.devices) == 0 {
+		content += tui.StyleWarning.Render("No devices found.") + "\n"
+		content += tui.StyleDim.Render("Check the device is on and on the same network, or enter its address in Settings.") + "\n"
+	}
+
+	for i, d := range s.devices {
+		cursor := "  "
+		if i == s.deviceCursor {
+			cursor = tui.StyleMenuCursor.Render("> ")
+		}
+		ip := "-"
+		if len(d.Addrs) > 0 {
+			ip = d.Addrs[0].String()
+		}
+		status := tui.StyleError.Render("unreachable")
+		if d.Reachable {
+			status = tui.StyleSuccess.Render("reachable")
+		}
+		name := fmt.Sprintf("%-24s", d.Host())
+		if i == s.deviceCursor {
+			name = tui.StyleSelected.Render(name)
+		}
+		line := fmt.Sprintf("%s%s %-16s :%-5d %s", cursor, name, ip, d.Port, status)
+		if d.ReplayOS {
+			line += "  " + tui.StyleDim.Render("ReplayOS")
+		}
+		content += line + "\n"
+	}
+
+	if s.chosen != "" {
+		content += "\n"
+		if s.saveErr != nil {
+			content += tui.StyleError.Render("Save failed: "+s.saveErr.Error()) + "\n"
+		} else {
+			content += tui.StyleSuccess.Render("Device set to "+s.chosen) + "\n"
+		}
+	}
+
+	content += "\n" + tui.StyleDim.Render("enter: use this device  r: rescan  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(content)
+}
+
 func (s *SetupScreen) ShortHelp() []key.Binding {
 	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Enter, tui.Keys.Back}
 }
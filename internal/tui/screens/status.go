@@ -0,0 +1,233 @@
+package screens
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/transfer"
+	"github.com/kurlmarx/romwrangler/internal/tui"
+)
+
+type deviceStatusMsg struct {
+	status *transfer.DeviceStatus
+	err    error
+}
+
+// DeviceStatusScreen shows the device's storage, space per system folder,
+// BIOS completeness and system information.
+type DeviceStatusScreen struct {
+	cfg           *config.Config
+	width, height int
+
+	loading bool
+	cancel  context.CancelFunc
+
+	status       *transfer.DeviceStatus
+	err          error
+	showBIOS     bool // list missing BIOS files per folder
+	scrollOffset int
+}
+
+func NewDeviceStatusScreen(cfg *config.Config, width, height int) *DeviceStatusScreen {
+	return &DeviceStatusScreen{cfg: cfg, width: width, height: height}
+}
+
+func (s *DeviceStatusScreen) Init() tea.Cmd {
+	return s.load()
+}
+
+// load connects to the device and reads its status.
+func (s *DeviceStatusScreen) load() tea.Cmd {
+	s.loading = true
+	s.err = nil
+
+	cfg := s.cfg
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	return func() tea.Msg {
+		backend, err := transfer.NewBackend(cfg, "")
+		if err != nil {
+			return deviceStatusMsg{err: err}
+		}
+		if err := backend.Connect(ctx); err != nil {
+			return deviceStatusMsg{err: fmt.Errorf("connect: %w", err)}
+		}
+		defer backend.Close()
+
+		status, err := transfer.ReadDeviceStatus(ctx, backend)
+		return deviceStatusMsg{status: status, err: err}
+	}
+}
+
+func (s *DeviceStatusScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+
+	case deviceStatusMsg:
+		s.loading = false
+		s.cancel = nil
+		s.err = msg.err
+		if msg.status != nil {
+			s.status = msg.status
+			s.scrollOffset = 0
+		}
+
+	case tea.KeyMsg:
+		if s.loading {
+			if key.Matches(msg, tui.Keys.Back) && s.cancel != nil {
+				s.cancel()
+			}
+			return s, nil
+		}
+		switch {
+		case key.Matches(msg, tui.Keys.Back):
+			return s, func() tea.Msg { return tui.NavigateBackMsg{} }
+		case key.Matches(msg, tui.Keys.Up):
+			if s.scrollOffset > 0 {
+				s.scrollOffset--
+			}
+		case key.Matches(msg, tui.Keys.Down):
+			s.scrollOffset++
+		case msg.String() == "b":
+			s.showBIOS = !s.showBIOS
+		case msg.String() == "r":
+			return s, s.load()
+		}
+	}
+	return s, nil
+}
+
+func (s *DeviceStatusScreen) View() string {
+	out := tui.StyleSubtitle.Render("Device Status") + "  " + tui.StyleDim.Render(s.cfg.Device.Host) + "\n\n"
+
+	if s.loading {
+		out += tui.StyleDim.Render("Reading device status...") + "\n"
+		out += "\n" + tui.StyleDim.Render("esc: cancel")
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+
+	if s.err != nil {
+		out += tui.StyleError.Render("Error: "+s.err.Error()) + "\n"
+	}
+	if s.status == nil {
+		out += "\n" + tui.StyleDim.Render("r: retry  esc: back")
+		return lipgloss.NewStyle().Padding(1, 2).Render(out)
+	}
+
+	lines := s.statusLines()
+	maxVisible := s.height - 10
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	if s.scrollOffset > len(lines)-maxVisible {
+		s.scrollOffset = len(lines) - maxVisible
+	}
+	if s.scrollOffset < 0 {
+		s.scrollOffset = 0
+	}
+	end := s.scrollOffset + maxVisible
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for _, line := range lines[s.scrollOffset:end] {
+		out += line + "\n"
+	}
+	if len(lines) > maxVisible {
+		out += tui.StyleDim.Render(fmt.Sprintf("(%d more, use arrows to scroll)", len(lines)-maxVisible)) + "\n"
+	}
+
+	out += "\n" + tui.StyleDim.Render("b: show missing BIOS files  r: refresh  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(out)
+}
+
+// statusLines renders the status as scrollable lines.
+func (s *DeviceStatusScreen) statusLines() []string {
+	return deviceStatusLines(s.status, s.showBIOS)
+}
+
+// deviceStatusLines renders a device status; showBIOS lists the missing
+// BIOS files under each folder.
+func deviceStatusLines(st *transfer.DeviceStatus, showBIOS bool) []string {
+	var lines []string
+
+	if info := st.Info; info != nil {
+		lines = append(lines, tui.StyleSubtitle.Render("System"))
+		field := func(label, value string) {
+			if value == "" {
+				value = tui.StyleDim.Render("unknown")
+			}
+			lines = append(lines, fmt.Sprintf("  %-10s %s", label, value))
+		}
+		field("Model", info.Model)
+		field("OS", info.OS)
+		field("Kernel", info.Kernel)
+		mem := ""
+		if info.Memory > 0 {
+			mem = formatBytes(info.Memory)
+		}
+		field("Memory", mem)
+		lines = append(lines, "")
+	}
+
+	if d := st.Disk; d != nil && d.Total > 0 {
+		pct := float64(d.Used) / float64(d.Total) * 100
+		lines = append(lines, tui.StyleSubtitle.Render("Storage"))
+		lines = append(lines, "  "+renderProgressBar(pct, 40))
+		lines = append(lines, fmt.Sprintf("  %s used of %s, %s free",
+			formatBytes(d.Used), formatBytes(d.Total), tui.StyleSuccess.Render(formatBytes(d.Free))))
+		lines = append(lines, "")
+	}
+
+	if st.BIOSTotal > 0 {
+		style := tui.StyleWarning
+		if st.BIOSPresent == st.BIOSTotal {
+			style = tui.StyleSuccess
+		}
+		lines = append(lines, tui.StyleSubtitle.Render("BIOS")+"  "+
+			style.Render(fmt.Sprintf("%d of %d known files", st.BIOSPresent, st.BIOSTotal)))
+		for _, g := range st.BIOS {
+			folder := "bios/" + g.Folder
+			total := len(g.Present) + len(g.Missing)
+			mark := tui.StyleSuccess.Render("OK")
+			if len(g.Missing) > 0 {
+				mark = tui.StyleWarning.Render("--")
+			}
+			lines = append(lines, fmt.Sprintf("  %s  %-24s %d/%d", mark, folder, len(g.Present), total))
+			if showBIOS && len(g.Missing) > 0 {
+				lines = append(lines, tui.StyleDim.Render("        missing: "+strings.Join(g.Missing, ", ")))
+			}
+		}
+		lines = append(lines, "")
+	}
+
+	if len(st.Systems) > 0 {
+		lines = append(lines, tui.StyleSubtitle.Render("ROMs by system")+"  "+
+			tui.StyleDim.Render(formatBytes(st.ROMBytes)+" in roms/"))
+		for _, u := range st.Systems {
+			pct := 0.0
+			if st.ROMBytes > 0 {
+				pct = float64(u.Bytes) / float64(st.ROMBytes) * 100
+			}
+			lines = append(lines, fmt.Sprintf("  %-24s %10s %6d files %5.1f%%",
+				u.Folder, formatBytes(u.Bytes), u.Files, pct))
+		}
+		lines = append(lines, "")
+	}
+
+	for _, err := range st.Errors {
+		lines = append(lines, tui.StyleError.Render("! ")+tui.StyleDim.Render(err.Error()))
+	}
+	return lines
+}
+
+func (s *DeviceStatusScreen) ShortHelp() []key.Binding {
+	return []key.Binding{tui.Keys.Up, tui.Keys.Down, tui.Keys.Back}
+}
@@ -6,11 +6,14 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kurlmarx/romwrangler/internal/config"
+	"github.com/kurlmarx/romwrangler/internal/overrides"
+	"github.com/kurlmarx/romwrangler/internal/replaycfg"
 	"github.com/kurlmarx/romwrangler/internal/transfer"
 	"github.com/kurlmarx/romwrangler/internal/tui"
 )
@@ -22,6 +25,7 @@ const (
 	transferPhaseFolders
 	transferPhaseConnect
 	transferPhasePlan
+	transferPhaseConfirmDelete
 	transferPhaseProgress
 	transferPhaseResults
 )
@@ -31,21 +35,36 @@ type transferConnectMsg struct {
 }
 
 type transferPlanMsg struct {
-	plan *transfer.TransferPlan
-	err  error
+	plan      *transfer.TransferPlan
+	requeued  int   // sync-skipped files re-queued after a checksum mismatch
+	budget    int64 // capacity budget, when limited
+	limited   bool
+	fsType    string // destination filesystem, when it restricts the plan
+	manifests transfer.Manifests
+	err       error
 }
 
 type transferProgressMsg struct {
 	progress transfer.TransferProgress
 }
 
+// transferPausedMsg reports that uploads are held until the transfer
+// window opens again.
+type transferPausedMsg struct {
+	until time.Time
+}
+
 type transferDoneMsg struct {
-	err error
+	err         error
+	deleted     int     // mirror mode: files removed from the device
+	deleteErrs  []error // mirror mode: files that could not be removed
+	manifestErr error   // the device manifest could not be saved
+	kioskErr    error   // kiosk mode could not be set in replay.cfg
 }
 
 type transferFolder struct {
-	label   string // "ROMs", "BIOS", "Saves", "Config"
-	dirName string // "roms", "bios", "saves", "config"
+	label    string // "ROMs", "BIOS", "Saves", "Config"
+	dirName  string // "roms", "bios", "saves", "config"
 	selected bool
 }
 
@@ -58,19 +77,30 @@ type TransferScreen struct {
 	methods []string
 	cursor  int
 	toolErr string // shown when a required tool (sshpass/rsync) is missing
+	isSFTP  bool
+
+	throttle *transfer.Throttle // bandwidth cap and window for per-file uploads
 
 	// Folder selection
-	folderOptions []transferFolder
-	folderCursor  int
+	folderOptions   []transferFolder
+	folderCursor    int
+	overrideCount   int  // per-game/system overrides in the local config folder
+	rebuildManifest bool // ignore the device manifest and rebuild it from a listing
 
-	// Connection (USB path)
+	// Connection (USB path or SFTP)
 	backend    transfer.TransferBackend
 	connectErr error
 
-	// Plan (USB)
+	// Plan (USB/SFTP)
 	plan             *transfer.TransferPlan
 	planErr          error
 	planFolderLabels []string
+	planRequeued     int
+	planBudget       int64
+	planLimited      bool
+	planFSType       string
+	manifests        transfer.Manifests
+	deleteConfirmed  bool // mirror mode: user agreed to delete plan.Deletions
 
 	// Bulk backend (rsync)
 	bulkBackend transfer.BulkTransferBackend
@@ -79,26 +109,50 @@ type TransferScreen struct {
 	// Progress
 	progressCh      <-chan transfer.TransferProgress
 	currentProgress transfer.TransferProgress
+	pauseCh         <-chan time.Time
+	pausedUntil     time.Time
 
 	// Cancellation
 	cancel context.CancelFunc
 
 	// Results
 	itemsTransferred int // files (USB) or folders (rsync)
+	mismatches       int // checksum mismatches that were re-sent
+	deleted          int
+	deleteErrs       []error
+	manifestErr      error
+	kioskErr         error
 	totalErr         error
 }
 
+// Transfer method menu indices.
+const (
+	transferMethodSFTP = iota
+	transferMethodRsync
+	transferMethodUSB
+	transferMethodManual
+)
+
 func NewTransferScreen(cfg *config.Config, width, height int) *TransferScreen {
-	return &TransferScreen{
+	t := &TransferScreen{
 		cfg:    cfg,
 		width:  width,
 		height: height,
 		methods: []string{
+			"SFTP - Built-in transfer over SSH (no external tools)",
 			"rsync - Fast incremental sync over SSH",
 			"USB / SD Card",
 			"Manual Instructions",
 		},
 	}
+	// Start on the configured default method.
+	switch cfg.Transfer.Method {
+	case "rsync":
+		t.cursor = transferMethodRsync
+	case "usb":
+		t.cursor = transferMethodUSB
+	}
+	return t
 }
 
 func (t *TransferScreen) Init() tea.Cmd { return nil }
@@ -124,17 +178,39 @@ func (t *TransferScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
 			t.phase = transferPhaseMethod
 		} else {
 			t.plan = msg.plan
+			t.planRequeued = msg.requeued
+			t.planBudget = msg.budget
+			t.planLimited = msg.limited
+			t.planFSType = msg.fsType
+			t.manifests = msg.manifests
 		}
 
+	case transferPausedMsg:
+		t.pausedUntil = msg.until
+		return t, listenTransferPause(t.pauseCh)
+
 	case transferProgressMsg:
 		t.currentProgress = msg.progress
+		if !t.throttle.Paused() {
+			t.pausedUntil = time.Time{}
+		}
 		if msg.progress.Done {
 			t.itemsTransferred++
 		}
+		if msg.progress.Mismatch {
+			t.mismatches++
+		}
 		return t, listenTransferProgress(t.progressCh)
 
 	case transferDoneMsg:
+		if t.plan != nil {
+			t.plan.Cleanup()
+		}
 		t.totalErr = msg.err
+		t.deleted = msg.deleted
+		t.deleteErrs = msg.deleteErrs
+		t.manifestErr = msg.manifestErr
+		t.kioskErr = msg.kioskErr
 		t.cancel = nil
 		t.phase = transferPhaseResults
 
@@ -146,6 +222,8 @@ func (t *TransferScreen) Update(msg tea.Msg) (tui.Screen, tea.Cmd) {
 			return t.updateFolders(msg)
 		case transferPhasePlan:
 			return t.updatePlan(msg)
+		case transferPhaseConfirmDelete:
+			return t.updateConfirmDelete(msg)
 		case transferPhaseProgress:
 			if key.Matches(msg, tui.Keys.Back) {
 				if t.cancel != nil {
@@ -190,11 +268,34 @@ func (t *TransferScreen) updateMethod(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 		}
 	case key.Matches(msg, tui.Keys.Enter):
 		t.toolErr = ""
+		throttle, err := transfer.ThrottleFromConfig(t.cfg.Transfer)
+		if err != nil {
+			t.toolErr = err.Error()
+			return t, nil
+		}
+		t.throttle = throttle
 		switch t.cursor {
-		case 0: // rsync
-			if _, err := transfer.FindTool("sshpass"); err != nil {
-				t.toolErr = "Requires 'sshpass' \u2014 install: sudo pacman -S sshpass"
-				return t, nil
+		case transferMethodSFTP:
+			backend := transfer.NewSFTPBackend(
+				t.cfg.Device.Host,
+				t.cfg.Device.Port,
+				t.cfg.Device.User,
+				transfer.SSHAuthFromDevice(t.cfg.Device),
+				t.cfg.Device.RootPath,
+			)
+			backend.Throttle = throttle
+			t.backend = backend
+			t.isBulk = false
+			t.isSFTP = true
+			t.initFolderSelection()
+			t.phase = transferPhaseFolders
+		case transferMethodRsync:
+			auth := transfer.SSHAuthFromDevice(t.cfg.Device)
+			if auth.NeedsSSHPass() {
+				if _, err := transfer.FindTool("sshpass"); err != nil {
+					t.toolErr = "Password login requires 'sshpass' \u2014 install: sudo pacman -S sshpass (or configure an SSH key)"
+					return t, nil
+				}
 			}
 			if _, err := transfer.FindTool("rsync"); err != nil {
 				t.toolErr = "Requires 'rsync' \u2014 install: sudo pacman -S rsync"
@@ -204,19 +305,25 @@ func (t *TransferScreen) updateMethod(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 				t.cfg.Device.Host,
 				t.cfg.Device.Port,
 				t.cfg.Device.User,
-				t.cfg.Device.Password,
+				auth,
 			)
 			backend.Concurrency = t.cfg.Transfer.Concurrency
+			backend.Checksum = t.cfg.Transfer.Verify
+			backend.BWLimit = t.cfg.Transfer.BandwidthLimit
 			t.bulkBackend = backend
 			t.isBulk = true
 			t.initFolderSelection()
 			t.phase = transferPhaseFolders
-		case 1: // USB
-			t.backend = transfer.NewUSBBackend(t.cfg.Transfer.USBPath)
+		case transferMethodUSB:
+			backend := transfer.NewUSBBackend(t.cfg.Transfer.USBPath)
+			backend.FSType = t.cfg.Transfer.USBFilesystem
+			backend.Throttle = throttle
+			t.backend = backend
 			t.isBulk = false
+			t.isSFTP = false
 			t.initFolderSelection()
 			t.phase = transferPhaseFolders
-		case 2: // Manual
+		case transferMethodManual:
 			// Instructions shown in viewMethod below the list
 		}
 	}
@@ -229,9 +336,16 @@ func (t *TransferScreen) updatePlan(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 		if t.backend != nil {
 			t.backend.Close()
 		}
+		if t.plan != nil {
+			t.plan.Cleanup()
+		}
 		t.phase = transferPhaseMethod
 	case key.Matches(msg, tui.Keys.Enter):
 		if t.plan != nil {
+			if len(t.plan.Deletions) > 0 {
+				t.phase = transferPhaseConfirmDelete
+				return t, nil
+			}
 			t.phase = transferPhaseProgress
 			return t, t.startTransfer()
 		}
@@ -239,6 +353,24 @@ func (t *TransferScreen) updatePlan(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 	return t, nil
 }
 
+// updateConfirmDelete asks before mirror mode removes anything: y deletes
+// and transfers, n transfers without deleting.
+func (t *TransferScreen) updateConfirmDelete(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
+	switch {
+	case key.Matches(msg, tui.Keys.Back):
+		t.phase = transferPhasePlan
+	case msg.String() == "y":
+		t.deleteConfirmed = true
+		t.phase = transferPhaseProgress
+		return t, t.startTransfer()
+	case msg.String() == "n":
+		t.deleteConfirmed = false
+		t.phase = transferPhaseProgress
+		return t, t.startTransfer()
+	}
+	return t, nil
+}
+
 func (t *TransferScreen) initFolderSelection() {
 	t.folderOptions = []transferFolder{
 		{label: "ROMs", dirName: "roms", selected: true},
@@ -247,6 +379,13 @@ func (t *TransferScreen) initFolderSelection() {
 		{label: "Config", dirName: "config", selected: false},
 	}
 	t.folderCursor = 0
+
+	t.overrideCount = 0
+	if len(t.cfg.SourceDirs) > 0 {
+		if list, err := overrides.List(filepath.Join(t.cfg.SourceDirs[0], overrides.ConfigFolder)); err == nil {
+			t.overrideCount = len(list)
+		}
+	}
 }
 
 func (t *TransferScreen) updateFolders(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
@@ -261,6 +400,7 @@ func (t *TransferScreen) updateFolders(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 			t.bulkBackend = nil
 		}
 		t.isBulk = false
+		t.isSFTP = false
 		t.phase = transferPhaseMethod
 	case key.Matches(msg, tui.Keys.Up):
 		if t.folderCursor > 0 {
@@ -272,6 +412,8 @@ func (t *TransferScreen) updateFolders(msg tea.KeyMsg) (tui.Screen, tea.Cmd) {
 		}
 	case msg.Type == tea.KeySpace:
 		t.folderOptions[t.folderCursor].selected = !t.folderOptions[t.folderCursor].selected
+	case msg.String() == "m" && !t.isBulk && t.useManifest():
+		t.rebuildManifest = !t.rebuildManifest
 	case key.Matches(msg, tui.Keys.Enter):
 		if len(t.selectedFolders()) > 0 {
 			if t.isBulk {
@@ -305,6 +447,11 @@ func (t *TransferScreen) selectedLabels() []string {
 	return labels
 }
 
+// useManifest reports whether sync decisions come from the device manifest.
+func (t *TransferScreen) useManifest() bool {
+	return t.cfg.Transfer.SyncMode && t.cfg.Transfer.Manifest
+}
+
 func (t *TransferScreen) connect() tea.Cmd {
 	backend := t.backend
 	return func() tea.Msg {
@@ -316,36 +463,51 @@ func (t *TransferScreen) connect() tea.Cmd {
 func (t *TransferScreen) buildPlan() tea.Cmd {
 	backend := t.backend
 	cfg := t.cfg
-	selected := t.selectedFolders()
 	t.planFolderLabels = t.selectedLabels()
 
+	opts := transfer.SyncOptions{
+		Folders:  t.selectedFolders(),
+		Transfer: cfg.Transfer,
+		Systems:  cfg.Device.Systems,
+		Lists:    cfg.Device.Lists,
+		// Mirror only ever prunes ROMs; saves and config live on the device.
+		Mirror: true,
+	}
+	if len(cfg.SourceDirs) > 0 {
+		opts.LocalRoot = cfg.SourceDirs[0]
+		opts.OtherRoots = cfg.SourceDirs[1:]
+	}
+	opts.RebuildManifest = t.useManifest() && t.rebuildManifest
+
 	return func() tea.Msg {
-		rootDir := ""
-		if len(cfg.SourceDirs) > 0 {
-			rootDir = cfg.SourceDirs[0]
-		}
-
-		var plans []*transfer.TransferPlan
-		for _, folder := range selected {
-			localDir := filepath.Join(rootDir, folder)
-			remoteBase := folder // USB: relative path
-			plan, err := transfer.BuildTransferPlan(context.Background(), backend, localDir, remoteBase, cfg.Transfer.SyncMode)
-			if err != nil {
-				continue
-			}
-			plans = append(plans, plan)
+		sp, err := transfer.PlanSync(context.Background(), backend, opts)
+		if err != nil {
+			return transferPlanMsg{err: err}
+		}
+		msg := transferPlanMsg{
+			plan:      sp.Plan,
+			requeued:  sp.Requeued,
+			budget:    sp.Budget,
+			limited:   sp.Limited,
+			manifests: sp.Manifests,
 		}
-		return transferPlanMsg{plan: transfer.MergeTransferPlans(plans...)}
+		if sp.Filesystem.Restricted() {
+			msg.fsType = sp.Filesystem.Type
+		}
+		return msg
 	}
 }
 
 func (t *TransferScreen) startTransfer() tea.Cmd {
 	backend := t.backend
 	plan := t.plan
-	concurrency := t.cfg.Transfer.Concurrency
-	if concurrency < 1 {
-		concurrency = 1
+	opts := transfer.ExecuteOptions{
+		Concurrency: t.cfg.Transfer.Concurrency,
+		Verify:      t.cfg.Transfer.Verify,
+		Manifests:   t.manifests,
 	}
+	manifests := t.manifests
+	kiosk := t.cfg.Device.Lists.Kiosk
 
 	ctx, cancel := context.WithCancel(context.Background())
 	t.cancel = cancel
@@ -353,21 +515,70 @@ func (t *TransferScreen) startTransfer() tea.Cmd {
 	progressCh := make(chan transfer.TransferProgress, 100)
 	t.progressCh = progressCh
 
-	errCh := make(chan error, 1)
+	deleteFirst := t.deleteConfirmed && len(plan.Deletions) > 0
+
+	pauseCh := make(chan time.Time, 1)
+	t.pauseCh = pauseCh
+	if t.throttle != nil {
+		t.throttle.OnPause = func(until time.Time) {
+			select {
+			case pauseCh <- until:
+			default:
+			}
+		}
+	}
+
+	doneCh := make(chan transferDoneMsg, 1)
 	go func() {
-		err := transfer.Execute(ctx, backend, plan, concurrency, progressCh)
-		errCh <- err
+		var done transferDoneMsg
+		// Delete first so the freed space is available for the uploads.
+		if deleteFirst {
+			done.deleted, done.deleteErrs = transfer.ExecuteDeletions(ctx, backend, plan, manifests)
+		}
+		done.err = transfer.ExecuteWithOptions(ctx, backend, plan, opts, progressCh)
+		// Record what was sent even when the transfer was cancelled.
+		done.manifestErr = manifests.Save(context.Background(), backend)
+		if kiosk != nil && done.err == nil {
+			_, done.kioskErr = replaycfg.SetKiosk(ctx, backend, *kiosk)
+		}
+		// The kiosk push is throttled too and may report a pause.
+		close(pauseCh)
+		doneCh <- done
 	}()
 
 	return tea.Batch(
 		listenTransferProgress(progressCh),
-		waitTransferDone(errCh),
+		listenTransferPause(pauseCh),
+		func() tea.Msg { return <-doneCh },
 	)
 }
 
+func listenTransferPause(ch <-chan time.Time) tea.Cmd {
+	return func() tea.Msg {
+		until, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return transferPausedMsg{until: until}
+	}
+}
+
+// throttleSummary describes the configured bandwidth cap and window.
+func (t *TransferScreen) throttleSummary() string {
+	var parts []string
+	if t.throttle.BytesPerSec > 0 {
+		parts = append(parts, formatBytes(t.throttle.BytesPerSec)+"/s")
+	}
+	if t.throttle.Window != nil {
+		parts = append(parts, "uploads only "+t.throttle.Window.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (t *TransferScreen) startBulkTransfer() tea.Cmd {
 	folders := t.buildFolderMappings()
 	backend := t.bulkBackend
+	throttle := t.throttle
 
 	ctx, cancel := context.WithCancel(context.Background())
 	t.cancel = cancel
@@ -375,14 +586,34 @@ func (t *TransferScreen) startBulkTransfer() tea.Cmd {
 	progressCh := make(chan transfer.TransferProgress, 100)
 	t.progressCh = progressCh
 
+	pauseCh := make(chan time.Time, 1)
+	t.pauseCh = pauseCh
+	if throttle != nil {
+		throttle.OnPause = func(until time.Time) {
+			select {
+			case pauseCh <- until:
+			default:
+			}
+		}
+	}
+
 	errCh := make(chan error, 1)
 	go func() {
-		err := backend.TransferFolders(ctx, folders, progressCh)
-		errCh <- err
+		// rsync cannot be paused mid-run: wait for the window to open
+		// before starting it.
+		err := throttle.WaitWindow(ctx)
+		close(pauseCh)
+		if err != nil {
+			close(progressCh)
+			errCh <- err
+			return
+		}
+		errCh <- backend.TransferFolders(ctx, folders, progressCh)
 	}()
 
 	return tea.Batch(
 		listenTransferProgress(progressCh),
+		listenTransferPause(pauseCh),
 		waitTransferDone(errCh),
 	)
 }
@@ -392,15 +623,7 @@ func (t *TransferScreen) buildFolderMappings() []transfer.FolderMapping {
 	if len(t.cfg.SourceDirs) > 0 {
 		rootDir = t.cfg.SourceDirs[0]
 	}
-
-	var mappings []transfer.FolderMapping
-	for _, folder := range t.selectedFolders() {
-		mappings = append(mappings, transfer.FolderMapping{
-			LocalDir:  filepath.Join(rootDir, folder),
-			RemoteDir: path.Join(t.cfg.Device.RootPath, folder),
-		})
-	}
-	return mappings
+	return transfer.DeviceFolderMappings(rootDir, t.cfg.Device.RootPath, t.selectedFolders(), t.cfg.Device.Systems)
 }
 
 func listenTransferProgress(ch <-chan transfer.TransferProgress) tea.Cmd {
@@ -429,6 +652,8 @@ func (t *TransferScreen) View() string {
 		return t.viewConnect()
 	case transferPhasePlan:
 		return t.viewPlan()
+	case transferPhaseConfirmDelete:
+		return t.viewConfirmDelete()
 	case transferPhaseProgress:
 		return t.viewProgress()
 	case transferPhaseResults:
@@ -461,7 +686,7 @@ func (t *TransferScreen) viewMethod() string {
 		s += "\n" + tui.StyleError.Render(t.toolErr) + "\n"
 	}
 
-	if t.cursor == 2 { // Manual Instructions
+	if t.cursor == transferMethodManual {
 		s += "\n" + tui.StyleDim.Render("Manual transfer instructions:") + "\n"
 		s += tui.StyleDim.Render("1. Copy the desired folders (roms/, bios/, saves/, config/) to a USB drive") + "\n"
 		s += tui.StyleDim.Render("2. Insert into your ReplayOS device") + "\n"
@@ -486,16 +711,32 @@ func (t *TransferScreen) viewFolders() string {
 			cursor = tui.StyleMenuCursor.String()
 			style = tui.StyleSelected
 		}
-		s += cursor + style.Render(fmt.Sprintf("%s %-10s %s/", check, f.label, f.dirName)) + "\n"
+		s += cursor + style.Render(fmt.Sprintf("%s %-10s %s/", check, f.label, f.dirName))
+		if f.dirName == overrides.ConfigFolder && t.overrideCount > 0 {
+			s += tui.StyleDim.Render(fmt.Sprintf("  %d per-game/system overrides", t.overrideCount))
+		}
+		s += "\n"
 	}
 
-	s += "\n" + tui.StyleDim.Render("space: toggle  enter: confirm  esc: back")
+	help := "space: toggle  enter: confirm  esc: back"
+	if !t.isBulk && t.useManifest() {
+		if t.rebuildManifest {
+			s += "\n" + tui.StyleWarning.Render("Device manifest will be rebuilt from a device listing") + "\n"
+		}
+		help = "space: toggle  m: rebuild manifest  enter: confirm  esc: back"
+	}
+
+	s += "\n" + tui.StyleDim.Render(help)
 	return lipgloss.NewStyle().Padding(1, 2).Render(s)
 }
 
 func (t *TransferScreen) viewConnect() string {
 	s := tui.StyleSubtitle.Render("Connecting...") + "\n\n"
-	s += tui.StyleDim.Render(fmt.Sprintf("Mounting: %s", t.cfg.Transfer.USBPath))
+	if t.isSFTP {
+		s += tui.StyleDim.Render(fmt.Sprintf("Connecting: %s@%s:%d", t.cfg.Device.User, t.cfg.Device.Host, t.cfg.Device.Port))
+	} else {
+		s += tui.StyleDim.Render(fmt.Sprintf("Mounting: %s", t.cfg.Transfer.USBPath))
+	}
 	return lipgloss.NewStyle().Padding(1, 2).Render(s)
 }
 
@@ -515,14 +756,104 @@ func (t *TransferScreen) viewPlan() string {
 	if t.plan.SkipCount > 0 {
 		s += fmt.Sprintf("Files to skip:     %d (already exist)\n", t.plan.SkipCount)
 	}
+	if t.planRequeued > 0 {
+		s += tui.StyleWarning.Render(fmt.Sprintf("Checksum mismatch: %d existing files will be re-sent", t.planRequeued)) + "\n"
+	}
+	if t.manifests != nil {
+		s += tui.StyleDim.Render("Sync: decided from the device manifest") + "\n"
+	}
+	if t.throttle != nil {
+		s += tui.StyleDim.Render("Limits: "+t.throttleSummary()) + "\n"
+	}
+	if t.cfg.Transfer.Verify {
+		s += tui.StyleDim.Render("Verify: each file is checksummed on the destination after upload") + "\n"
+	}
 	s += fmt.Sprintf("Total size:        %s\n", formatBytes(t.plan.TotalSize))
+	if t.planLimited {
+		s += fmt.Sprintf("Capacity budget:   %s\n", formatBytes(t.planBudget))
+	}
+	if t.planFSType != "" {
+		s += t.viewPreflight()
+	}
+	if len(t.plan.Omitted) > 0 {
+		s += "\n" + tui.StyleWarning.Render(fmt.Sprintf("Left out: %d files (%s)", len(t.plan.Omitted), formatBytes(t.plan.OmittedSize))) + "\n"
+		summary := t.plan.OmittedBySystem()
+		for i, o := range summary {
+			if i == maxOmittedShown {
+				s += tui.StyleDim.Render(fmt.Sprintf("  ... and %d more", len(summary)-maxOmittedShown)) + "\n"
+				break
+			}
+			s += tui.StyleDim.Render(fmt.Sprintf("  %-24s %5d files %10s  (%s)", o.System, o.Files, formatBytes(o.Size), o.Reason)) + "\n"
+		}
+	}
+	if n := len(t.plan.Replaced); n > 0 {
+		s += tui.StyleDim.Render(fmt.Sprintf("Lists: removes %d files from roms/%s and roms/%s", n, transfer.FavoritesFolder, transfer.AutostartFolder)) + "\n"
+	}
+	if n := len(t.plan.Deletions); n > 0 {
+		s += tui.StyleWarning.Render(fmt.Sprintf("Mirror mode: %d files (%s) on the device no longer exist locally", n, formatBytes(t.plan.DeleteSize))) + "\n"
+	}
 
 	s += "\n" + tui.StyleDim.Render("enter: start transfer  esc: back")
 	return lipgloss.NewStyle().Padding(1, 2).Render(s)
 }
 
+// viewPreflight reports what the FAT/exFAT preflight changed or dropped.
+func (t *TransferScreen) viewPreflight() string {
+	s := "\n" + tui.StyleDim.Render(fmt.Sprintf("Destination filesystem: %s", t.planFSType)) + "\n"
+	if n := len(t.plan.Renamed); n > 0 {
+		s += tui.StyleWarning.Render(fmt.Sprintf("%d files renamed to be valid on %s:", n, t.planFSType)) + "\n"
+		for i, r := range t.plan.Renamed {
+			if i == maxOmittedShown {
+				s += tui.StyleDim.Render(fmt.Sprintf("  ... and %d more", n-maxOmittedShown)) + "\n"
+				break
+			}
+			s += tui.StyleDim.Render(fmt.Sprintf("  %s \u2192 %s", path.Base(r.From), path.Base(r.To))) + "\n"
+		}
+	}
+	if n := len(t.plan.Rewritten); n > 0 {
+		s += tui.StyleDim.Render(fmt.Sprintf("%d .m3u/.cue files updated to match renamed files", n)) + "\n"
+	}
+	for _, o := range t.plan.Omitted {
+		if o.Reason != transfer.OmitTooLarge && o.Reason != transfer.OmitNameConflict {
+			continue
+		}
+		s += tui.StyleError.Render(fmt.Sprintf("  %s (%s): %s", path.Base(o.RemotePath), formatBytes(o.Size), o.Reason)) + "\n"
+		if o.Hint != "" {
+			s += tui.StyleDim.Render("    "+o.Hint) + "\n"
+		}
+	}
+	return s
+}
+
+// maxOmittedShown caps the per-system list of left-out files in the plan.
+const maxOmittedShown = 8
+
+// maxDeletionsShown caps the file list on the delete confirmation screen.
+const maxDeletionsShown = 15
+
+func (t *TransferScreen) viewConfirmDelete() string {
+	s := tui.StyleSubtitle.Render("Delete Files From Device?") + "\n\n"
+
+	dels := t.plan.Deletions
+	s += tui.StyleWarning.Render(fmt.Sprintf("%d files (%s) are on the device but not in your library:", len(dels), formatBytes(t.plan.DeleteSize))) + "\n\n"
+	for i, f := range dels {
+		if i == maxDeletionsShown {
+			s += tui.StyleDim.Render(fmt.Sprintf("  ... and %d more", len(dels)-maxDeletionsShown)) + "\n"
+			break
+		}
+		s += "  " + f.Path + "\n"
+	}
+	s += "\n" + tui.StyleDim.Render("_favorites, _recent, _autostart and _extra are never touched.") + "\n"
+
+	s += "\n" + tui.StyleDim.Render("y: delete and transfer  n: transfer without deleting  esc: back")
+	return lipgloss.NewStyle().Padding(1, 2).Render(s)
+}
+
 func (t *TransferScreen) viewProgress() string {
 	s := tui.StyleSubtitle.Render("Transferring...") + "\n\n"
+	if !t.pausedUntil.IsZero() {
+		s += tui.StyleWarning.Render(fmt.Sprintf("Outside the transfer window \u2014 paused until %s", t.pausedUntil.Format("15:04"))) + "\n\n"
+	}
 
 	p := t.currentProgress
 	if p.TotalFiles > 0 {
@@ -541,8 +872,11 @@ func (t *TransferScreen) viewProgress() string {
 				s += renderProgressBar(totalPct, 40) + "\n"
 			}
 		} else {
-			// USB: file-level progress with byte counts
+			// USB/SFTP: file-level progress with byte counts
 			s += fmt.Sprintf("File %d / %d: %s\n", p.FileIndex+1, p.TotalFiles, p.Filename)
+			if p.Attempt > 0 {
+				s += tui.StyleWarning.Render(fmt.Sprintf("Checksum mismatch \u2014 resending (attempt %d)", p.Attempt+1)) + "\n"
+			}
 			if p.FileSize > 0 {
 				pct := float64(p.BytesSent) / float64(p.FileSize) * 100
 				s += renderProgressBar(pct, 40) + "\n"
@@ -571,6 +905,24 @@ func (t *TransferScreen) viewResults() string {
 			tui.StyleSuccess.Render("OK"), t.itemsTransferred)
 	}
 
+	if t.deleted > 0 {
+		s += fmt.Sprintf("%s %d files deleted from the device\n", tui.StyleSuccess.Render("OK"), t.deleted)
+	}
+	for _, err := range t.deleteErrs {
+		s += tui.StyleError.Render(err.Error()) + "\n"
+	}
+
+	if t.mismatches > 0 {
+		s += tui.StyleWarning.Render(fmt.Sprintf("%d checksum mismatches detected and re-sent", t.mismatches)) + "\n"
+	}
+
+	if t.manifestErr != nil {
+		s += tui.StyleWarning.Render("Device manifest not saved: "+t.manifestErr.Error()) + "\n"
+	}
+	if t.kioskErr != nil {
+		s += tui.StyleWarning.Render("Kiosk mode not set: "+t.kioskErr.Error()) + "\n"
+	}
+
 	if t.totalErr != nil {
 		s += tui.StyleError.Render("Error: "+t.totalErr.Error()) + "\n"
 	}